@@ -52,7 +52,7 @@ func (bb *Biker1) energyToReachableDistance(energy float64, bike obj.IMegaBike)
 	}
 
 	estimatedForce := bb.estimateForce(bike.GetVelocity(), numberOfAgents)
-	newState := physics.GenerateNewState(AddingMass, estimatedForce, bike.GetOrientation())
+	newState := physics.GenerateNewState(AddingMass, estimatedForce, bike.GetOrientation(), nil)
 	remainingEnergy = remainingEnergy - bb.getPedalForce()*utils.MovingDepletion
 	distance = bb.ComputeDistance(AddingMass.Position, newState.Position)
 	totalDistance = totalDistance + distance
@@ -63,7 +63,7 @@ func (bb *Biker1) energyToReachableDistance(energy float64, bike obj.IMegaBike)
 
 	for remainingEnergy > 0 {
 		estimatedForce = bb.estimateForce(newState.Velocity, numberOfAgents)
-		newState = physics.GenerateNewState(newState, estimatedForce, bike.GetOrientation())
+		newState = physics.GenerateNewState(newState, estimatedForce, bike.GetOrientation(), nil)
 		distance = bb.ComputeDistance(oldState.Position, newState.Position)
 		oldState = newState
 		totalDistance = totalDistance + distance
@@ -82,7 +82,7 @@ func (bb *Biker1) distanceToEnergy(distance float64, initialEnergy float64) floa
 	extraDist := 0.0
 
 	estimatedForce := bb.estimateForce(bb.GetBikeInstance().GetVelocity(), float64(len(bb.GetFellowBikers())))
-	newState := physics.GenerateNewState(bb.GetBikeInstance().GetPhysicalState(), estimatedForce, bb.GetBikeInstance().GetOrientation())
+	newState := physics.GenerateNewState(bb.GetBikeInstance().GetPhysicalState(), estimatedForce, bb.GetBikeInstance().GetOrientation(), nil)
 	remainingEnergy = remainingEnergy - bb.getPedalForce()*utils.MovingDepletion
 	extraDist = bb.ComputeDistance(bb.GetBikeInstance().GetPhysicalState().Position, newState.Position)
 	totalDistance = totalDistance + extraDist
@@ -90,7 +90,7 @@ func (bb *Biker1) distanceToEnergy(distance float64, initialEnergy float64) floa
 
 	for totalDistance < distance {
 		estimatedForce = bb.estimateForce(newState.Velocity, float64(len(bb.GetFellowBikers())))
-		newState = physics.GenerateNewState(newState, estimatedForce, bb.GetBikeInstance().GetOrientation())
+		newState = physics.GenerateNewState(newState, estimatedForce, bb.GetBikeInstance().GetOrientation(), nil)
 		extraDist = bb.ComputeDistance(oldState.Position, newState.Position)
 		oldState = newState
 		remainingEnergy = remainingEnergy - bb.getPedalForce()*utils.MovingDepletion
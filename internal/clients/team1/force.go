@@ -3,8 +3,8 @@
 package team1
 
 import (
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
-	"math"
 
 	"github.com/google/uuid"
 )
@@ -33,11 +33,8 @@ func (bb *Biker1) DecideForce(direction uuid.UUID) {
 	// If audi is close, steer away from it
 	if bb.DistanceFromAudi(bb.GetBikeInstance()) < audiDistanceThreshold {
 		audiPos := bb.GetGameState().GetAudi().GetPosition()
-		deltaX := audiPos.X - currLocation.X
-		deltaY := audiPos.Y - currLocation.Y
 		// Steer in opposite direction to audi (regardless of governance)
-		angle := math.Atan2(-deltaY, -deltaX)
-		normalisedAngle := angle / math.Pi
+		normalisedAngle := physics.ComputeOrientation(audiPos, currLocation)
 		turningDecision := utils.TurningDecision{
 			SteerBike:     true,
 			SteeringForce: normalisedAngle - bb.GetBikeInstance().GetOrientation(),
@@ -62,10 +59,7 @@ func (bb *Biker1) DecideForce(direction uuid.UUID) {
 		}
 	}
 
-	deltaX := targetPos.X - currLocation.X
-	deltaY := targetPos.Y - currLocation.Y
-	angle := math.Atan2(deltaY, deltaX)
-	normalisedAngle := angle / math.Pi
+	normalisedAngle := physics.ComputeOrientation(currLocation, targetPos)
 
 	// if the governance is ruler-based and we're not the ruler, don't steer
 	var turningDecision utils.TurningDecision
@@ -2,6 +2,7 @@ package team1
 
 import (
 	obj "SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 	"math"
 
@@ -10,7 +11,7 @@ import (
 
 // -------------------MATHS HELPER FUNCTIONS ------------------------
 func (bb *Biker1) ComputeDistance(a utils.Coordinates, b utils.Coordinates) float64 {
-	return math.Sqrt((math.Pow((a.X-b.X), 2) + math.Pow((a.Y-b.Y), 2)))
+	return math.Sqrt(physics.ComputeDistance(a, b))
 }
 
 // -------------------SETTERS AND GETTERS-----------------------------
@@ -4,6 +4,7 @@ package team1
 
 import (
 	obj "SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 	"math"
 
@@ -53,10 +54,7 @@ func (bb *Biker1) UpdateTrust(agentID uuid.UUID) {
 	finalTrust := bb.opinions[id].trust //nothing changes
 	targetPos := bb.recentDecidedPosition
 	currLocation := bb.GetLocation()
-	deltaX := targetPos.X - currLocation.X
-	deltaY := targetPos.Y - currLocation.Y
-	angle := math.Atan2(deltaY, deltaX)
-	normalisedAngle := angle / math.Pi
+	normalisedAngle := physics.ComputeOrientation(currLocation, targetPos)
 	steeringAngle := normalisedAngle - bb.GetBikeInstance().GetOrientation()
 	if math.Abs(steeringAngle) < 0.01 { //we are headed in direction towards lootbox
 		finalTrust = bb.opinions[id].trust + deviatePositive //will change to be based on weighting
@@ -2,6 +2,7 @@ package modules
 
 import (
 	objects "SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"fmt"
 	"math"
@@ -112,8 +113,7 @@ func (e *EnvironmentModule) GetNearestLootboxAwayFromAudi() uuid.UUID {
 	audiPos := e.GetAudi().GetPosition()
 
 	// Find position away from audi.
-	deltaX := audiPos.X - bikePos.X
-	deltaY := audiPos.Y - bikePos.Y
+	deltaX, deltaY := physics.ComputeDisplacement(bikePos, audiPos)
 
 	awayX := bikePos.X - deltaX
 	awayY := bikePos.Y - deltaY
@@ -258,7 +258,7 @@ func (e *EnvironmentModule) GetBikerAgents() map[uuid.UUID]objects.IBaseBiker {
 
 func (e *EnvironmentModule) GetDistance(pos1, pos2 utils.Coordinates) float64 {
 
-	return math.Sqrt(math.Pow(pos1.X-pos2.X, 2) + math.Pow(pos1.Y-pos2.Y, 2))
+	return math.Sqrt(physics.ComputeDistance(pos1, pos2))
 }
 
 func GetEnvironmentModule(agentId uuid.UUID, gameState objects.IGameState, bikeId uuid.UUID) *EnvironmentModule {
@@ -1,6 +1,7 @@
 package modules
 
 import (
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"math"
 
@@ -24,10 +25,7 @@ func (um *UtilsModule) ProjectForce(actual, expected utils.Forces) float64 {
 // Get the forces to the target coordinated
 func (um *UtilsModule) GetForcesToTarget(agentPosition, targetPosition utils.Coordinates) utils.Forces {
 
-	deltaX := targetPosition.X - agentPosition.X
-	deltaY := targetPosition.Y - agentPosition.Y
-	angle := math.Atan2(deltaY, deltaX)
-	normalisedAngle := angle / math.Pi
+	normalisedAngle := physics.ComputeOrientation(agentPosition, targetPosition)
 	turningDecision := utils.TurningDecision{
 		SteerBike:     true,
 		SteeringForce: normalisedAngle,
@@ -50,10 +48,7 @@ func (um *UtilsModule) RuleAdherenceValue(agentID uuid.UUID, expectedAction, act
 // GetForcesToTargetWithDirectionOffset calculates the forces to be applied on an agent to steer towards a target position,
 // taking into account a specified degree of angular offset.
 func (um *UtilsModule) GetForcesToTargetWithDirectionOffset(force, degree float64, currPos, targetPos utils.Coordinates) utils.Forces {
-	deltaX := targetPos.X - currPos.X
-	deltaY := targetPos.Y - currPos.Y
-	angle := math.Atan2(deltaY, deltaX)
-	normalisedAngle := angle/math.Pi + math.Remainder(degree, 2)
+	normalisedAngle := physics.ComputeOrientation(currPos, targetPos) + math.Remainder(degree, 2)
 
 	if normalisedAngle < -1 {
 		normalisedAngle = normalisedAngle + 2
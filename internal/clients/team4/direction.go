@@ -103,11 +103,8 @@ func (agent *BaselineAgent) DecideForce(direction uuid.UUID) {
 	pedalForce := 1.0
 
 	if distanceFromAudi < audiDistanceThreshold {
-		deltaX := audiPos.X - currLocation.X
-		deltaY := audiPos.Y - currLocation.Y
 		// Steer in opposite direction to audi
-		angle := math.Atan2(deltaY, deltaX)
-		normalisedAngle := angle / math.Pi
+		normalisedAngle := physics.ComputeOrientation(currLocation, audiPos)
 		// Steer in opposite direction to audi
 		var flipAngle float64
 		if normalisedAngle < 0.0 {
@@ -127,10 +124,7 @@ func (agent *BaselineAgent) DecideForce(direction uuid.UUID) {
 		agent.SetForces(escapeAudiForces)
 	} else {
 		targetPos := currentLootBoxes[agent.targetLoot].GetPosition()
-		deltaX := targetPos.X - currLocation.X
-		deltaY := targetPos.Y - currLocation.Y
-		angle := math.Atan2(deltaY, deltaX)
-		normalisedAngle := angle / math.Pi
+		normalisedAngle := physics.ComputeOrientation(currLocation, targetPos)
 
 		// Default BaseBiker will always
 		turningDecision := utils.TurningDecision{
@@ -155,8 +149,7 @@ func (agent *BaselineAgent) nearestLoot() uuid.UUID {
 	var nearestBox uuid.UUID
 	var currDist float64
 	for _, loot := range agent.GetGameState().GetLootBoxes() {
-		x, y := loot.GetPosition().X, loot.GetPosition().Y
-		currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
+		currDist = math.Sqrt(physics.ComputeDistance(currLocation, loot.GetPosition()))
 		if currDist < shortestDist {
 			nearestBox = loot.GetID()
 			shortestDist = currDist
@@ -2,6 +2,7 @@ package team5Agent
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"math"
 	"sort"
@@ -57,9 +58,9 @@ func SortPreferences(prefs map[uuid.UUID]float64) map[uuid.UUID]float64 {
 
 }
 
-// calculates the Euclidean distance between two points
+// calculates the Euclidean distance between two points, across the edges of a toroidal world
 func calculateDistanceToObject(a, t5 utils.Coordinates) float64 {
-	return math.Sqrt(math.Pow(a.X-t5.X, 2) + math.Pow(a.Y-t5.Y, 2))
+	return math.Sqrt(physics.ComputeDistance(a, t5))
 }
 
 // calculates preference based on color match
@@ -2,6 +2,7 @@ package team5Agent
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"math"
 
@@ -59,9 +60,9 @@ func (t5 *team5Agent) ProposeDirection() uuid.UUID {
 	return prefLootId
 }
 
-// Calculate the Euclidean distance between two coordinates
+// Calculate the Euclidean distance between two coordinates, across the edges of a toroidal world
 func calculateDistance(a, b utils.Coordinates) float64 {
-	return math.Sqrt(math.Pow(a.X-b.X, 2) + math.Pow(a.Y-b.Y, 2))
+	return math.Sqrt(physics.ComputeDistance(a, b))
 }
 
 // Calculate the preference for a lootbox based on colour
@@ -1,6 +1,7 @@
 package team5Agent
 
 import (
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"math"
 
@@ -35,20 +36,13 @@ func (t5 *team5Agent) DecideForce(targetLootBoxID uuid.UUID) {
 		targetPos := currentLootBoxes[targetLootBoxID].GetPosition()
 		//fmt.Println("Target Position: ", targetPos)
 
-		deltaXB := targetPos.X - currLocation.X
-		deltaYB := targetPos.Y - currLocation.Y
-		//fmt.Println("Delta X: ", deltaX, "Delta Y: ", deltaY)
-
-		angleToGoal := math.Atan2(deltaYB, deltaXB) / math.Pi
+		angleToGoal := physics.ComputeOrientation(currLocation, targetPos)
 
 		audiPos := t5.GetGameState().GetAudi().GetPosition()
 
-		deltaXA := audiPos.X - currLocation.X
-		deltaYA := audiPos.Y - currLocation.Y
-
-		angleToAudi := math.Atan2(deltaYA, deltaXA) / math.Pi
+		angleToAudi := physics.ComputeOrientation(currLocation, audiPos)
 
-		distance_to_audi := math.Sqrt((((deltaXA) * (deltaXA)) + (deltaYA * (deltaYA))))
+		distance_to_audi := math.Sqrt(physics.ComputeDistance(currLocation, audiPos))
 
 		if distance_to_audi < (2*utils.CollisionThreshold) && math.Abs(angleToAudi-angleToGoal) < 0.5 {
 			angleToGoal = angleToAudi - math.Copysign(0.5, angleToAudi-angleToGoal)
@@ -2,6 +2,7 @@ package team6
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 	voting "SOMAS2023/internal/common/voting"
 	"fmt"
@@ -140,8 +141,7 @@ func (bb *Team6Biker) bikeToNearestLoot(Bike objects.IMegaBike) float64 {
 
 	var currDist float64
 	for _, loot := range bb.GetGameState().GetLootBoxes() {
-		x, y := loot.GetPosition().X, loot.GetPosition().Y
-		currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
+		currDist = math.Sqrt(physics.ComputeDistance(currLocation, loot.GetPosition()))
 		if currDist < shortestDist {
 			//nearestBox = loot.GetID()
 			shortestDist = currDist
@@ -156,8 +156,7 @@ func (bb *Team6Biker) bikeToSameColorNearestLoot(bike objects.IMegaBike, lootBox
 
 	var currDist float64
 	for _, loot := range lootBoxes {
-		x, y := loot.GetPosition().X, loot.GetPosition().Y
-		currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
+		currDist = math.Sqrt(physics.ComputeDistance(currLocation, loot.GetPosition()))
 		if currDist < shortestDist {
 			//nearestBox = loot.GetID()
 			shortestDist = currDist
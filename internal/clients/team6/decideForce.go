@@ -1,6 +1,7 @@
 package team6
 
 import (
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 	"math"
 
@@ -14,15 +15,13 @@ func (bb *Team6Biker) DecideForce(direction uuid.UUID) {
 	currLocation := bb.GetLocation()
 
 	audiPos := bb.GetGameState().GetAudi().GetPosition()
-	deltaXAudi := audiPos.X - currLocation.X
-	deltaYAudi := audiPos.Y - currLocation.Y
+	deltaXAudi, deltaYAudi := physics.ComputeDisplacement(currLocation, audiPos)
 	distAudi := math.Sqrt(deltaXAudi*deltaXAudi + deltaYAudi*deltaYAudi)
 
 	// Check if there are lootboxes available and move towards closest one
 	if distAudi > distAudiThreshold {
 		targetPos := bb.GetGameState().GetLootBoxes()[direction].GetPosition()
-		deltaX := targetPos.X - currLocation.X
-		deltaY := targetPos.Y - currLocation.Y
+		deltaX, deltaY := physics.ComputeDisplacement(currLocation, targetPos)
 		angle := math.Atan2(deltaX, deltaY)
 		normalisedAngle := angle / math.Pi
 		// Default BaseBiker will always
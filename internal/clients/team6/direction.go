@@ -1,6 +1,7 @@
 package team6
 
 import (
+	"SOMAS2023/internal/common/physics"
 	voting "SOMAS2023/internal/common/voting"
 	"math"
 
@@ -33,8 +34,7 @@ func (bb *Team6Biker) nearestLoot() uuid.UUID {
 	var nearestBox uuid.UUID
 	var currDist float64
 	for _, loot := range bb.GetGameState().GetLootBoxes() {
-		x, y := loot.GetPosition().X, loot.GetPosition().Y
-		currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
+		currDist = math.Sqrt(physics.ComputeDistance(currLocation, loot.GetPosition()))
 		if currDist < shortestDist {
 			nearestBox = loot.GetID()
 			shortestDist = currDist
@@ -54,8 +54,7 @@ func (bb *Team6Biker) nearestSameColourLoot() uuid.UUID {
 	for _, loot := range bb.GetGameState().GetLootBoxes() {
 		lootColour := loot.GetColour() // Get the colour of the lootbox
 		if lootColour == bikerColour {
-			x, y := loot.GetPosition().X, loot.GetPosition().Y
-			currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
+			currDist = math.Sqrt(physics.ComputeDistance(currLocation, loot.GetPosition()))
 			if currDist < shortestDist {
 				nearestSameColourBox = loot.GetID()
 				shortestDist = currDist
@@ -112,8 +111,7 @@ func (bb *Team6Biker) FinalDirectionVote(proposals map[uuid.UUID]uuid.UUID) voti
 			var currDist float64
 			for _, proposal := range mostCommonProposal {
 				loot := bb.GetGameState().GetLootBoxes()[proposal]
-				x, y := loot.GetPosition().X, loot.GetPosition().Y
-				currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
+				currDist = math.Sqrt(physics.ComputeDistance(currLocation, loot.GetPosition()))
 				if currDist < shortestDist {
 					nearestProposal = proposal
 					shortestDist = currDist
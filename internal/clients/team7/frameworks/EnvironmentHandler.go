@@ -2,6 +2,7 @@ package frameworks
 
 import (
 	objects "SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 	"math"
 
@@ -61,13 +62,12 @@ func (env *EnvironmentHandler) GetBikeAgentsByBikeId(bikeId uuid.UUID) []objects
 // }
 
 func (env *EnvironmentHandler) GetNearestLootBox() objects.ILootBox {
-	X, Y := env.GetCurrentBike().GetPosition().X, env.GetCurrentBike().GetPosition().Y
+	bikePos := env.GetCurrentBike().GetPosition()
 	lootBoxes := env.GameState.GetLootBoxes()
 	var nearestLootBox objects.ILootBox
 	var nearestDistance float64
 	for _, lootBox := range lootBoxes {
-		x, y := lootBox.GetPosition().X, lootBox.GetPosition().Y
-		distance := math.Sqrt(math.Pow(X-x, 2) + math.Pow(Y-y, 2))
+		distance := math.Sqrt(physics.ComputeDistance(bikePos, lootBox.GetPosition()))
 		if nearestLootBox == nil || distance < nearestDistance {
 			nearestLootBox = lootBox
 			nearestDistance = distance
@@ -78,13 +78,12 @@ func (env *EnvironmentHandler) GetNearestLootBox() objects.ILootBox {
 }
 
 func (env *EnvironmentHandler) GetNearestLootBoxByColour(colour utils.Colour) objects.ILootBox {
-	X, Y := env.GetCurrentBike().GetPosition().X, env.GetCurrentBike().GetPosition().Y
+	bikePos := env.GetCurrentBike().GetPosition()
 	lootBoxes := env.GetLootBoxesByColour(colour)
 	var nearestLootBox objects.ILootBox
 	var nearestDistance float64
 	for _, lootBox := range lootBoxes {
-		x, y := lootBox.GetPosition().X, lootBox.GetPosition().Y
-		distance := math.Sqrt(math.Pow(X-x, 2) + math.Pow(Y-y, 2))
+		distance := math.Sqrt(physics.ComputeDistance(bikePos, lootBox.GetPosition()))
 		if nearestLootBox == nil || distance < nearestDistance {
 			nearestLootBox = lootBox
 			nearestDistance = distance
@@ -100,7 +99,7 @@ func (env *EnvironmentHandler) GetDistanceBetweenLootboxes(lootbox1 uuid.UUID, l
 	}
 	lootbox1Pos := env.GetLootboxById(lootbox1).GetPosition()
 	lootbox2Pos := env.GetLootboxById(lootbox2).GetPosition()
-	return math.Sqrt(math.Pow(lootbox1Pos.X-lootbox2Pos.X, 2) + math.Pow(lootbox1Pos.Y-lootbox2Pos.Y, 2))
+	return math.Sqrt(physics.ComputeDistance(lootbox1Pos, lootbox2Pos))
 }
 
 func (env *EnvironmentHandler) GetBikeMap() map[uuid.UUID]objects.IMegaBike {
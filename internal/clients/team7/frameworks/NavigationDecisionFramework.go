@@ -1,8 +1,8 @@
 package frameworks
 
 import (
+	"SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
-	"math"
 )
 
 type NavigationInputs struct {
@@ -38,14 +38,8 @@ func (ndf *NavigationDecisionFramework) GetTurnAngle(inputs NavigationInputs) fl
 	if !inputs.IsDestination {
 		return 0
 	}
-	// Get distances between current location and desired lootbox
-	dx := ndf.inputs.Destination.X - ndf.inputs.CurrentLocation.X
-	dy := ndf.inputs.Destination.Y - ndf.inputs.CurrentLocation.Y
-
-	angleRadians := math.Atan2(dy, dx)
-
-	// Normalize angle to be between -1 and 1
-	turningForce := angleRadians / math.Pi
+	// Get the orientation from current location to desired lootbox, normalised to be between -1 and 1
+	turningForce := physics.ComputeOrientation(ndf.inputs.CurrentLocation, ndf.inputs.Destination)
 	return turningForce
 }
 
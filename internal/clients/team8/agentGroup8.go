@@ -2,11 +2,11 @@ package team8
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"fmt"
 
 	"SOMAS2023/internal/common/voting"
-	"math"
 
 	"github.com/google/uuid"
 )
@@ -301,10 +301,10 @@ func (bb *Agent8) DecideForce(direction uuid.UUID) {
 	distanceAudiBike := calculateDistance(bb.GetLocation(), bb.GetGameState().GetAudi().GetPosition())
 	var angle float64
 	if distanceAudiBike > 10 {
-		angle = math.Atan2(target.GetPosition().Y-bb.GetLocation().Y, target.GetPosition().X-bb.GetLocation().X)/math.Pi -
+		angle = physics.ComputeOrientation(bb.GetLocation(), target.GetPosition()) -
 			bb.GetGameState().GetMegaBikes()[bb.GetBike()].GetOrientation()
 	} else {
-		angle = math.Atan2(bb.GetLocation().Y-bb.GetGameState().GetAudi().GetPosition().Y, bb.GetLocation().X-bb.GetGameState().GetAudi().GetPosition().X)/math.Pi -
+		angle = physics.ComputeOrientation(bb.GetGameState().GetAudi().GetPosition(), bb.GetLocation()) -
 			bb.GetGameState().GetMegaBikes()[bb.GetBike()].GetOrientation()
 	}
 
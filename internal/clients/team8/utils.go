@@ -2,6 +2,7 @@ package team8
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"math"
 	"sort"
@@ -36,9 +37,9 @@ func softmax(preferences map[uuid.UUID]float64) map[uuid.UUID]float64 {
 	return softmaxPreferences
 }
 
-// calculateDistance computes the Euclidean distance between two points
+// calculateDistance computes the Euclidean distance between two points, across the edges of a toroidal world
 func calculateDistance(a, b utils.Coordinates) float64 {
-	return math.Sqrt(math.Pow(a.X-b.X, 2) + math.Pow(a.Y-b.Y, 2))
+	return math.Sqrt(physics.ComputeDistance(a, b))
 }
 
 // calculateColorPreference returns 1 if the colors match, 0 otherwise
@@ -1,6 +1,7 @@
 package objects
 
 import (
+	phy "SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 	voting "SOMAS2023/internal/common/voting"
 	"math"
@@ -35,6 +36,31 @@ type IBaseBiker interface {
 	// leader functions
 	DecideWeights(action utils.Action) map[uuid.UUID]float64 // decide on weights for various actions
 
+	// contract functions
+	ProposeContracts() []Contract                                     // contracts the agent wants to make this round, the server fills in their ID and proposer
+	SignContract(contract Contract) bool                              // called when the agent is a party of a contract proposed by another agent
+	HandleContractStatus(contractID uuid.UUID, status ContractStatus) // called when a contract the agent is a party of changes status
+
+	// energy transfer functions
+	DecideEnergyTransfers() []EnergyTransfer                           // energy the agent gives or lends to other agents at the end of the round
+	AcceptLoan(lender uuid.UUID, amount float64, terms LoanTerms) bool // called when another agent offers a loan to the agent
+	DecideRepayment(loan Loan) bool                                    // called every round a loan instalment is due, not repaying defaults on the loan
+	HandleLoanDefault(loan Loan)                                       // called when a borrower of the agent defaults
+
+	// treasury functions, only called when the treasury is enabled
+	VoteTaxRate() float64                          // the tax rate the agent wants for its bike (democracy and leadership)
+	DecideTaxRate() float64                        // ** called only when the agent is the dictator
+	DecideTreasurySpending() map[uuid.UUID]float64 // ** called only when the agent is the ruler, energy to give to each rider from the treasury
+
+	// judiciary functions, only called when the judiciary is enabled
+	FileAccusations() []Accusation                                        // accusations against riders of the agent's bike, the server fills in their ID and evidence
+	JudgeAccusation(accusation Accusation) bool                           // called when the agent is a juror or the ruler judging an accusation, returns whether the accused is guilty
+	HandleVerdict(accusation Accusation, guilty bool, sanction *Sanction) // called when an accusation the agent filed or was accused in is ruled on, the sanction is nil on acquittal
+
+	// points market functions, only called when the market is enabled
+	DecideMarketOrders() []Order // orders the agent places on the points market this round
+	HandleTrade(trade Trade)     // called when an order of the agent is (partially) filled
+
 	GetForces() utils.Forces        // returns forces for current round
 	GetColour() utils.Colour        // returns the colour of the lootbox that the agent is currently seeking
 	GetLocation() utils.Coordinates // gets the agent's location
@@ -68,8 +94,13 @@ type IBaseBiker interface {
 	HandleVoteRulerMessage(msg VoteRulerMessage)
 	HandleVoteKickoutMessage(msg VoteKickoutMessage)
 	HandleVoteAllocationMessage(msg VoteAllocationMessage)
+	HandleProposalMessage(msg ProposalMessage)
+	HandleAcknowledgementMessage(msg AcknowledgementMessage)
+	HandleMessageRejection(rejection MessageRejection) // called by the server when a message couldn't be delivered
 
 	GetAllMessages([]IBaseBiker) []messaging.IMessage[IBaseBiker]
+	GetQueuedMessages() []messaging.IMessage[IBaseBiker] // returns and clears the messages enqueued (e.g. replies from the handlers) since the last call
+	GetPrivateChannels() []uuid.UUID                     // agents this agent wants a private channel with, the channel opens when both sides want it
 }
 
 type BikerAction int
@@ -89,6 +120,7 @@ type BaseBiker struct {
 	megaBikeId                       uuid.UUID             // if they are not on a bike it will be 0
 	gameState                        IGameState            // updated by the server at every round
 	reputation                       map[uuid.UUID]float64 // record reputation for other agents in float
+	outbox                           []messaging.IMessage[IBaseBiker]
 	GroupID                          int
 }
 
@@ -146,8 +178,7 @@ func (bb *BaseBiker) nearestLoot() uuid.UUID {
 	var nearestBox uuid.UUID
 	var currDist float64
 	for _, loot := range bb.gameState.GetLootBoxes() {
-		x, y := loot.GetPosition().X, loot.GetPosition().Y
-		currDist = math.Sqrt(math.Pow(currLocation.X-x, 2) + math.Pow(currLocation.Y-y, 2))
+		currDist = math.Sqrt(phy.ComputeDistance(currLocation, loot.GetPosition()))
 		if currDist < shortestDist {
 			nearestBox = loot.GetID()
 			shortestDist = currDist
@@ -177,11 +208,7 @@ func (bb *BaseBiker) DecideForce(direction uuid.UUID) {
 	// Check if there are lootboxes available and move towards closest one
 	if len(currentLootBoxes) > 0 {
 		targetPos := currentLootBoxes[direction].GetPosition()
-
-		deltaX := targetPos.X - currLocation.X
-		deltaY := targetPos.Y - currLocation.Y
-		angle := math.Atan2(deltaY, deltaX)
-		normalisedAngle := angle / math.Pi
+		normalisedAngle := phy.ComputeOrientation(currLocation, targetPos)
 
 		// Default BaseBiker will always
 		turningDecision := utils.TurningDecision{
@@ -197,13 +224,7 @@ func (bb *BaseBiker) DecideForce(direction uuid.UUID) {
 		bb.SetForces(nearestBoxForces)
 	} else { // otherwise move away from audi
 		audiPos := bb.GetGameState().GetAudi().GetPosition()
-
-		deltaX := audiPos.X - currLocation.X
-		deltaY := audiPos.Y - currLocation.Y
-
-		// Steer in opposite direction to audi
-		angle := math.Atan2(deltaY, deltaX)
-		normalisedAngle := angle / math.Pi
+		normalisedAngle := phy.ComputeOrientation(currLocation, audiPos)
 
 		// Steer in opposite direction to audi
 		var flipAngle float64
@@ -418,6 +439,83 @@ func (bb *BaseBiker) DecideWeights(action utils.Action) map[uuid.UUID]float64 {
 	return weights
 }
 
+// the base biker doesn't make contracts
+func (bb *BaseBiker) ProposeContracts() []Contract {
+	return []Contract{}
+}
+
+// the base biker doesn't sign contracts
+func (bb *BaseBiker) SignContract(contract Contract) bool {
+	return false
+}
+
+func (bb *BaseBiker) HandleContractStatus(contractID uuid.UUID, status ContractStatus) {
+	// Team's agent should implement logic for keeping track of their contracts.
+}
+
+// the base biker doesn't give away energy
+func (bb *BaseBiker) DecideEnergyTransfers() []EnergyTransfer {
+	return []EnergyTransfer{}
+}
+
+// the base biker doesn't borrow energy
+func (bb *BaseBiker) AcceptLoan(lender uuid.UUID, amount float64, terms LoanTerms) bool {
+	return false
+}
+
+// the base biker repays its loans while it can afford it
+func (bb *BaseBiker) DecideRepayment(loan Loan) bool {
+	return bb.energyLevel > loan.GetInstalment()
+}
+
+func (bb *BaseBiker) HandleLoanDefault(loan Loan) {
+	// Team's agent should implement logic for handling the defaults of their borrowers.
+}
+
+// the base biker doesn't want to pay taxes
+func (bb *BaseBiker) VoteTaxRate() float64 {
+	return 0.0
+}
+
+func (bb *BaseBiker) DecideTaxRate() float64 {
+	return 0.0
+}
+
+// the base biker leaves the treasury to the welfare top-ups
+func (bb *BaseBiker) DecideTreasurySpending() map[uuid.UUID]float64 {
+	return map[uuid.UUID]float64{}
+}
+
+// the base biker doesn't accuse anyone
+func (bb *BaseBiker) FileAccusations() []Accusation {
+	return []Accusation{}
+}
+
+// the base biker convicts riders that didn't pedal at all or that lied at least once
+func (bb *BaseBiker) JudgeAccusation(accusation Accusation) bool {
+	switch accusation.Offence {
+	case Freeriding:
+		return accusation.Evidence == 0
+	case Lying:
+		return accusation.Evidence > 0
+	default:
+		return false
+	}
+}
+
+func (bb *BaseBiker) HandleVerdict(accusation Accusation, guilty bool, sanction *Sanction) {
+	// Team's agent should implement logic for reacting to the verdicts (e.g. updating the reputation of the accused).
+}
+
+// the base biker doesn't trade
+func (bb *BaseBiker) DecideMarketOrders() []Order {
+	return []Order{}
+}
+
+func (bb *BaseBiker) HandleTrade(trade Trade) {
+	// Team's agent should implement logic for keeping track of their trades.
+}
+
 // only called when the agent is the dictator
 func (bb *BaseBiker) DecideKickOut() []uuid.UUID {
 	return (make([]uuid.UUID, 0))
@@ -621,6 +719,46 @@ func (bb *BaseBiker) HandleVoteGovernanceMessage(msg VoteGoveranceMessage) {
 	// voteMap := msg.VoteMap
 }
 
+func (bb *BaseBiker) HandleProposalMessage(msg ProposalMessage) {
+	// Team's agent should implement logic for handling other biker messages that were sent to them.
+	// Replies can be enqueued with EnqueueMessage and are delivered in the next exchange of the messaging session.
+
+	// sender := msg.BaseMessage.GetSender()
+	// correlationID := msg.CorrelationID
+	// terms := msg.Terms
+}
+
+func (bb *BaseBiker) HandleAcknowledgementMessage(msg AcknowledgementMessage) {
+	// Team's agent should implement logic for handling other biker messages that were sent to them.
+
+	// sender := msg.BaseMessage.GetSender()
+	// correlationID := msg.CorrelationID
+	// accepted := msg.Accepted
+}
+
+// EnqueueMessage adds a message to be sent in the next exchange of the messaging session (or in the next session if none is running)
+func (bb *BaseBiker) EnqueueMessage(msg messaging.IMessage[IBaseBiker]) {
+	bb.outbox = append(bb.outbox, msg)
+}
+
+func (bb *BaseBiker) GetQueuedMessages() []messaging.IMessage[IBaseBiker] {
+	queued := bb.outbox
+	bb.outbox = nil
+	return queued
+}
+
+// the base biker doesn't open any private channel
+func (bb *BaseBiker) GetPrivateChannels() []uuid.UUID {
+	return []uuid.UUID{}
+}
+
+func (bb *BaseBiker) HandleMessageRejection(rejection MessageRejection) {
+	// Team's agent should implement logic for handling messages that the server couldn't deliver.
+
+	// recipients := rejection.Recipients
+	// reason := rejection.Reason
+}
+
 func (bb *BaseBiker) HandleVoteLootboxDirectionMessage(msg VoteLootboxDirectionMessage) {
 	// Team's agent should implement logic for handling other biker messages that were sent to them.
 
@@ -0,0 +1,54 @@
+package objects
+
+import (
+	"github.com/google/uuid"
+)
+
+type ObligationType int
+
+const (
+	VoteForLootbox   ObligationType = iota // the party's final direction vote must give the highest weight to LootboxID
+	MinimumPedalling                       // the party must be on a bike and pedal with at least Pedal every round
+	NoKickout                              // the party must not vote to kick out (nor kick out as a dictator) any other party
+)
+
+// Obligation is a commitment of one of the parties of a contract, checked by the server every round
+type Obligation struct {
+	Party     uuid.UUID      `json:"party"`
+	Type      ObligationType `json:"type"`
+	LootboxID uuid.UUID      `json:"lootbox_id"` // used by VoteForLootbox
+	Pedal     float64        `json:"pedal"`      // used by MinimumPedalling
+}
+
+// Payment is an energy transfer made by the server every round in which the contract is honoured
+type Payment struct {
+	From   uuid.UUID `json:"from"`
+	To     uuid.UUID `json:"to"`
+	Energy float64   `json:"energy"`
+}
+
+// Contract is a binding agreement between agents, enforced by the server once all the parties have signed it.
+// For example "I give you X energy if you vote for lootbox L" is a VoteForLootbox obligation for the other agent and a payment of X
+// from the proposer, "I will pedal at ≥0.6 for N rounds" is a MinimumPedalling obligation for the proposer lasting N rounds
+// and a non-kickout pact is a NoKickout obligation for every party
+type Contract struct {
+	ID          uuid.UUID    `json:"id"` // set by the server when the contract is proposed
+	Proposer    uuid.UUID    `json:"proposer"`
+	Parties     []uuid.UUID  `json:"parties"` // all the agents bound by the contract, including the proposer
+	Obligations []Obligation `json:"obligations"`
+	Payments    []Payment    `json:"payments"`
+	Duration    int          `json:"duration"` // number of rounds the contract lasts once signed
+	// deducted from a party for each obligation it violates, the contract is then broken
+	EnergyPenalty float64 `json:"energy_penalty"`
+	PointsPenalty int     `json:"points_penalty"`
+}
+
+type ContractStatus string
+
+const (
+	ContractRejected  ContractStatus = "rejected"  // the contract was invalid or not signed by all the parties
+	ContractActive    ContractStatus = "active"    // the contract was signed and is being enforced
+	ContractCompleted ContractStatus = "completed" // the contract was honoured for its whole duration
+	ContractBroken    ContractStatus = "broken"    // a party violated one of its obligations and was penalised
+	ContractVoid      ContractStatus = "void"      // a party died before the end of the contract
+)
@@ -0,0 +1,42 @@
+package objects
+
+import (
+	"github.com/google/uuid"
+)
+
+type LoanTerms struct {
+	InterestRate float64 `json:"interest_rate"` // fraction of the amount lent added to the debt
+	Instalments  int     `json:"instalments"`   // number of rounds over which the debt is repaid, starting the round after the loan
+}
+
+// EnergyTransfer is a voluntary transfer of energy to another agent, on the same bike or within range
+type EnergyTransfer struct {
+	To     uuid.UUID
+	Amount float64
+	Loan   *LoanTerms // nil for a gift, the borrower must accept the loan otherwise
+}
+
+// Loan is a loan between two agents, whose repayment schedule is tracked by the server
+type Loan struct {
+	ID       uuid.UUID `json:"id"`
+	Lender   uuid.UUID `json:"lender"`
+	Borrower uuid.UUID `json:"borrower"`
+	Amount   float64   `json:"amount"`
+	LoanTerms
+	Repaid     float64 `json:"repaid"`      // total energy repaid so far
+	StartRound int     `json:"start_round"` // round in which the energy was lent
+}
+
+// GetDebt returns the total energy to be repaid, interest included
+func (l Loan) GetDebt() float64 {
+	return l.Amount * (1 + l.InterestRate)
+}
+
+// GetInstalment returns the energy to be repaid every round
+func (l Loan) GetInstalment() float64 {
+	return l.GetDebt() / float64(l.Instalments)
+}
+
+func (l Loan) GetOutstanding() float64 {
+	return l.GetDebt() - l.Repaid
+}
@@ -1,6 +1,10 @@
 package objects
 
-import "github.com/google/uuid"
+import (
+	"SOMAS2023/internal/common/terrain"
+
+	"github.com/google/uuid"
+)
 
 /*
 IGameState is an interface for GameState that objects will use to get the current game state
@@ -10,4 +14,5 @@ type IGameState interface {
 	GetMegaBikes() map[uuid.UUID]IMegaBike
 	GetAgents() map[uuid.UUID]IBaseBiker
 	GetAudi() IAudi
+	GetTerrain() terrain.ITerrain
 }
@@ -2,27 +2,68 @@ package objects
 
 import (
 	utils "SOMAS2023/internal/common/utils"
+	"math/rand"
 )
 
 type ILootBox interface {
 	IPhysicsObject
-	GetTotalResources() float64
+	GetTotalResources() float64 // returns the current value of the lootbox, after decay
 	GetColour() utils.Colour
+	GetRemainingLifetime() int // returns the number of rounds before the lootbox expires, or -1 if it never expires
+	IsJackpot() bool
+}
+
+// IServerLootBox is a lootbox as the server and the lootbox spawners handle it, agents only get its ILootBox methods
+type IServerLootBox interface {
+	ILootBox
+	// scales the loot of the lootbox, used by the lootbox spawners before the lootbox is placed on the map
+	MultiplyResources(multiplier float64)
+	// Server must call this at the end of every round to decay the resources and count down the lifetime
+	Age()
 }
 
 type LootBox struct {
 	*PhysicsObject
 	colour    utils.Colour
 	totalLoot float64
+	lifetime  int // rounds left before expiring, -1 if the lootbox never expires
+	jackpot   bool
 }
 
 // GetLootBox is a constructor for LootBox that initializes it with a new UUID and default position.
 func GetLootBox() *LootBox {
-	return &LootBox{
+	return newLootBox(
+		utils.GenerateRandomCoordinates(),
+		utils.GenerateRandomColour(), // Initialize to randomized colour
+		utils.GenerateRandomFloat(utils.MinLootBoxResources, utils.MaxLootBoxResources), // Initialize to randomized totalLoot
+	)
+}
+
+// NewLootBox is a constructor for LootBox with a given position, colour and totalLoot, used by the lootbox spawners.
+func NewLootBox(position utils.Coordinates, colour utils.Colour, totalLoot float64) *LootBox {
+	return newLootBox(position, colour, totalLoot)
+}
+
+// sets up the lifetime, drift and jackpot class of a new lootbox according to the lootbox parameters
+func newLootBox(position utils.Coordinates, colour utils.Colour, totalLoot float64) *LootBox {
+	lootBox := &LootBox{
 		PhysicsObject: GetPhysicsObject(0),
-		colour:        utils.GenerateRandomColour(),    // Initialize to randomized colour
-		totalLoot:     utils.GenerateRandomFloat(2, 4), // Initialize to randomized totalLoot
+		colour:        colour,
+		totalLoot:     totalLoot,
+		lifetime:      -1,
+	}
+	lootBox.coordinates = position
+	if utils.LootBoxLifetime > 0 {
+		lootBox.lifetime = utils.LootBoxLifetime
 	}
+	if rand.Float64() < utils.JackpotProbability {
+		lootBox.jackpot = true
+		lootBox.totalLoot *= utils.JackpotMultiplier
+	}
+	// drifting lootboxes move at a constant velocity in a random direction
+	lootBox.velocity = rand.Float64() * utils.LootBoxMaxDriftSpeed
+	lootBox.orientation = utils.GenerateRandomFloat(-1, 1)
+	return lootBox
 }
 
 // returns the total loot of the object
@@ -34,3 +75,22 @@ func (lb *LootBox) GetTotalResources() float64 {
 func (lb *LootBox) GetColour() utils.Colour {
 	return lb.colour
 }
+
+func (lb *LootBox) GetRemainingLifetime() int {
+	return lb.lifetime
+}
+
+func (lb *LootBox) IsJackpot() bool {
+	return lb.jackpot
+}
+
+func (lb *LootBox) Age() {
+	lb.totalLoot *= 1 - utils.LootBoxDecayRate
+	if lb.lifetime > 0 {
+		lb.lifetime--
+	}
+}
+
+func (lb *LootBox) MultiplyResources(multiplier float64) {
+	lb.totalLoot *= multiplier
+}
@@ -0,0 +1,44 @@
+package objects
+
+import (
+	"SOMAS2023/internal/common/utils"
+
+	"github.com/google/uuid"
+)
+
+type AssetType int
+
+const (
+	Points      AssetType = iota // points of the agent
+	ColourRight                  // the right to score points from the lootboxes of Colour for a number of rounds
+)
+
+// Asset is something traded on the points market, paid for in energy
+type Asset struct {
+	Type   AssetType    `json:"type"`
+	Colour utils.Colour `json:"colour"` // used by ColourRight, agents can only sell the right of their own colour
+}
+
+type OrderSide int
+
+const (
+	Bid OrderSide = iota // buy the asset
+	Ask                  // sell the asset
+)
+
+// Order is a limit order on the points market: at most Quantity units of the asset are bought (sold) at no more (less) than Price energy per unit
+type Order struct {
+	Asset    Asset     `json:"asset"`
+	Side     OrderSide `json:"side"`
+	Quantity int       `json:"quantity"` // number of points, or of rights to sell (bids for a colour right are for a single right)
+	Price    float64   `json:"price"`    // energy per unit
+}
+
+// Trade is a match between a bid and an ask made when the market is cleared
+type Trade struct {
+	Asset    Asset     `json:"asset"`
+	Buyer    uuid.UUID `json:"buyer"`
+	Seller   uuid.UUID `json:"seller"`
+	Quantity int       `json:"quantity"`
+	Price    float64   `json:"price"` // energy per unit paid by the buyer
+}
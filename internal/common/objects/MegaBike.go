@@ -14,10 +14,16 @@ type IMegaBike interface {
 	GetAgents() []IBaseBiker
 	UpdateMass()
 	KickOutAgent(weights map[uuid.UUID]float64) []uuid.UUID
+	GetKickedOutCount() int                           // number of agents kicked out of the bike by vote
+	GetKickoutVotes() map[uuid.UUID]map[uuid.UUID]int // votes cast by each rider in the last kickout vote
 	GetGovernance() utils.Governance
 	GetRuler() uuid.UUID
 	SetGovernance(governance utils.Governance)
 	SetRuler(ruler uuid.UUID)
+	GetTreasury() float64 // energy held in common by the riders of the bike
+	GetTaxRate() float64  // fraction of the loot of every rider paid into the treasury
+	UpdateTreasury(energy float64)
+	SetTaxRate(taxRate float64)
 }
 
 // MegaBike will have the following forces
@@ -25,8 +31,11 @@ type MegaBike struct {
 	*PhysicsObject
 	agents         []IBaseBiker
 	kickedOutCount int
+	kickoutVotes   map[uuid.UUID]map[uuid.UUID]int
 	governance     utils.Governance
 	ruler          uuid.UUID
+	treasury       float64
+	taxRate        float64
 }
 
 // GetMegaBike is a constructor for MegaBike that initializes it with a new UUID and default position.
@@ -130,9 +139,11 @@ func (mb *MegaBike) GetKickedOutCount() int {
 // only called for level 0 and level 1
 func (mb *MegaBike) KickOutAgent(weights map[uuid.UUID]float64) []uuid.UUID {
 	voteCount := make(map[uuid.UUID]float64)
+	mb.kickoutVotes = make(map[uuid.UUID]map[uuid.UUID]int, len(mb.agents))
 	// Count votes for each agent
 	for _, agent := range mb.agents {
 		agentVotes := agent.VoteForKickout() // Assuming this now returns map[uuid.UUID]int
+		mb.kickoutVotes[agent.GetID()] = agentVotes
 		for agentID, votes := range agentVotes {
 			agentWeight := weights[agentID]
 			if val, ok := voteCount[agentID]; ok {
@@ -156,6 +167,10 @@ func (mb *MegaBike) KickOutAgent(weights map[uuid.UUID]float64) []uuid.UUID {
 	return agentsToKickOut
 }
 
+func (mb *MegaBike) GetKickoutVotes() map[uuid.UUID]map[uuid.UUID]int {
+	return mb.kickoutVotes
+}
+
 func (mb *MegaBike) GetGovernance() utils.Governance {
 	return mb.governance
 }
@@ -171,3 +186,20 @@ func (mb *MegaBike) SetGovernance(governance utils.Governance) {
 func (mb *MegaBike) SetRuler(ruler uuid.UUID) {
 	mb.ruler = ruler
 }
+
+func (mb *MegaBike) GetTreasury() float64 {
+	return mb.treasury
+}
+
+func (mb *MegaBike) GetTaxRate() float64 {
+	return mb.taxRate
+}
+
+// adds energy to (or, if negative, spends energy from) the treasury
+func (mb *MegaBike) UpdateTreasury(energy float64) {
+	mb.treasury += energy
+}
+
+func (mb *MegaBike) SetTaxRate(taxRate float64) {
+	mb.taxRate = taxRate
+}
@@ -80,6 +80,38 @@ type VoteAllocationMessage struct {
 	VoteMap voting.IdVoteMap // the vote map that you voted for (if you are telling the truth)
 }
 
+// "I propose the following deal", starts a negotiation that the recipients can answer within the same messaging session
+type ProposalMessage struct {
+	messaging.BaseMessage[IBaseBiker]
+	CorrelationID uuid.UUID             // identifies the negotiation, must be repeated in all the messages answering it
+	Topic         string                // what the proposal is about, as agreed between the agents
+	Terms         map[uuid.UUID]float64 // the content of the proposal (e.g. shares of an allocation or votes)
+}
+
+// "I accept (or reject) your proposal"
+type AcknowledgementMessage struct {
+	messaging.BaseMessage[IBaseBiker]
+	CorrelationID uuid.UUID // the negotiation being answered
+	Accepted      bool
+}
+
+type RejectionReason int
+
+const (
+	OutOfRange           RejectionReason = iota // the recipients were too far away from the sender
+	InsufficientEnergy                          // the sender couldn't afford the cost of the message
+	MessageCapReached                           // the sender already sent as many messages as allowed this round
+	ExchangeLimitReached                        // the message was enqueued during the last exchange of the messaging session
+)
+
+// Sent back by the server to the sender of a message that couldn't be delivered to some (or all) of its recipients.
+// Messages lost because of a noisy channel are not reported
+type MessageRejection struct {
+	Message    messaging.IMessage[IBaseBiker]
+	Recipients []uuid.UUID // the recipients the message didn't reach
+	Reason     RejectionReason
+}
+
 func (msg ReputationOfAgentMessage) InvokeMessageHandler(agent IBaseBiker) {
 	agent.HandleReputationMessage(msg)
 }
@@ -123,3 +155,11 @@ func (msg VoteKickoutMessage) InvokeMessageHandler(agent IBaseBiker) {
 func (msg VoteAllocationMessage) InvokeMessageHandler(agent IBaseBiker) {
 	agent.HandleVoteAllocationMessage(msg)
 }
+
+func (msg ProposalMessage) InvokeMessageHandler(agent IBaseBiker) {
+	agent.HandleProposalMessage(msg)
+}
+
+func (msg AcknowledgementMessage) InvokeMessageHandler(agent IBaseBiker) {
+	agent.HandleAcknowledgementMessage(msg)
+}
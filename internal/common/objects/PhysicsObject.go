@@ -8,6 +8,7 @@ The Bikers/Agents will not need to implement this interface
 */
 
 import (
+	phy "SOMAS2023/internal/common/physics"
 	utils "SOMAS2023/internal/common/utils"
 
 	"math"
@@ -88,8 +89,7 @@ func (po *PhysicsObject) SetPhysicalState(state utils.PhysicalState) {
 
 // this will be used to check if a MegaBike has looted a LootBok or if the Audi has collided with a MegaBike
 func (po *PhysicsObject) CheckForCollision(otherObject IPhysicsObject) bool {
-	otherPos := otherObject.GetPosition()
-	distance := math.Sqrt(math.Pow(otherPos.X-po.coordinates.X, 2) + math.Pow(otherPos.Y-po.coordinates.Y, 2))
+	distance := math.Sqrt(phy.ComputeDistance(po.coordinates, otherObject.GetPosition()))
 	if distance < utils.CollisionThreshold {
 		return true
 	} else {
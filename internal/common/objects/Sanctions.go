@@ -0,0 +1,31 @@
+package objects
+
+import (
+	"github.com/google/uuid"
+)
+
+type Offence int
+
+const (
+	Freeriding Offence = iota // not pedalling enough while riding the bike
+	Lying                     // sending messages that the server knows to be false
+)
+
+// Accusation is filed by a rider against another rider of the same bike, and ruled on through the governance of the bike
+type Accusation struct {
+	ID       uuid.UUID `json:"id"` // set by the server when the accusation is filed
+	Accuser  uuid.UUID `json:"accuser"`
+	Accused  uuid.UUID `json:"accused"`
+	Offence  Offence   `json:"offence"`
+	Evidence float64   `json:"evidence"` // set by the server: the pedal force of the accused for freeriding, the number of lies it sent in the last messaging session for lying
+}
+
+// Sanction is the penalty of a conviction, which escalates with the number of previous convictions of the agent
+type Sanction int
+
+const (
+	Warning Sanction = iota
+	EnergyFine
+	PointsFine
+	Expulsion // the agent is removed from its bike
+)
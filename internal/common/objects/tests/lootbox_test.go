@@ -0,0 +1,74 @@
+package objects
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"testing"
+)
+
+func setLootBoxLifecycle(t *testing.T, lifetime int, decayRate float64, driftSpeed float64, jackpotProbability float64) {
+	oldLifetime, oldDecayRate, oldDriftSpeed, oldJackpotProbability := utils.LootBoxLifetime, utils.LootBoxDecayRate, utils.LootBoxMaxDriftSpeed, utils.JackpotProbability
+	utils.LootBoxLifetime, utils.LootBoxDecayRate, utils.LootBoxMaxDriftSpeed, utils.JackpotProbability = lifetime, decayRate, driftSpeed, jackpotProbability
+	t.Cleanup(func() {
+		utils.LootBoxLifetime, utils.LootBoxDecayRate, utils.LootBoxMaxDriftSpeed, utils.JackpotProbability = oldLifetime, oldDecayRate, oldDriftSpeed, oldJackpotProbability
+	})
+}
+
+func TestDefaultLootBoxIsEternal(t *testing.T) {
+	lootBox := objects.GetLootBox()
+	resources := lootBox.GetTotalResources()
+	for i := 0; i < 100; i++ {
+		lootBox.Age()
+	}
+	if lootBox.GetRemainingLifetime() != -1 {
+		t.Errorf("Expected lootbox to never expire, got lifetime %d", lootBox.GetRemainingLifetime())
+	}
+	if lootBox.GetTotalResources() != resources || lootBox.IsJackpot() || lootBox.GetVelocity() != 0 {
+		t.Error("Expected default lootbox to be static, non decaying and not a jackpot")
+	}
+}
+
+func TestLootBoxExpiryAndDecay(t *testing.T) {
+	setLootBoxLifecycle(t, 3, 0.5, 0, 0)
+	lootBox := objects.NewLootBox(utils.Coordinates{}, utils.Red, 4)
+
+	expectedLifetimes := []int{2, 1, 0}
+	expectedResources := []float64{2, 1, 0.5}
+	for i := range expectedLifetimes {
+		lootBox.Age()
+		if lootBox.GetRemainingLifetime() != expectedLifetimes[i] {
+			t.Errorf("Round %d: expected lifetime %d, got %d", i, expectedLifetimes[i], lootBox.GetRemainingLifetime())
+		}
+		if math.Abs(lootBox.GetTotalResources()-expectedResources[i]) > 1e-9 {
+			t.Errorf("Round %d: expected resources %f, got %f", i, expectedResources[i], lootBox.GetTotalResources())
+		}
+	}
+}
+
+func TestJackpotLootBox(t *testing.T) {
+	setLootBoxLifecycle(t, 0, 0, 0, 1)
+	lootBox := objects.NewLootBox(utils.Coordinates{}, utils.Red, 3)
+	if !lootBox.IsJackpot() || lootBox.GetTotalResources() != 3*utils.JackpotMultiplier {
+		t.Errorf("Expected a jackpot with %f resources, got %f", 3*utils.JackpotMultiplier, lootBox.GetTotalResources())
+	}
+}
+
+func TestDriftingLootBox(t *testing.T) {
+	setLootBoxLifecycle(t, 0, 0, 2, 0)
+	lootBox := objects.NewLootBox(utils.Coordinates{X: 100, Y: 100}, utils.Red, 3)
+	if lootBox.GetVelocity() < 0 || lootBox.GetVelocity() > 2 {
+		t.Errorf("Drift speed %f outside of [0, 2]", lootBox.GetVelocity())
+	}
+
+	engine := physics.NewEngine(1, 4, utils.SemiImplicitEuler, nil)
+	state := lootBox.GetPhysicalState()
+	for i := 0; i < engine.SubSteps; i++ {
+		state = engine.Drift(state, lootBox.GetOrientation())
+	}
+	distance := math.Sqrt(physics.ComputeDistance(lootBox.GetPosition(), state.Position))
+	if math.Abs(distance-lootBox.GetVelocity()) > 1e-9 || state.Velocity != lootBox.GetVelocity() {
+		t.Errorf("Expected lootbox to drift %f in a round without slowing down, drifted %f", lootBox.GetVelocity(), distance)
+	}
+}
@@ -1,6 +1,7 @@
 package physics
 
 import (
+	"SOMAS2023/internal/common/terrain"
 	utils "SOMAS2023/internal/common/utils"
 	"math"
 )
@@ -10,10 +11,15 @@ The Engine is responsible for calculating physics for the environment
 */
 
 func CalcAcceleration(f float64, m float64, v float64) float64 {
+	return calcAcceleration(f, m, v, 1.0)
+}
+
+// same as CalcAcceleration, with the drag scaled by the roughness of the ground
+func calcAcceleration(f float64, m float64, v float64, dragMultiplier float64) float64 {
 	if m == 0 {
 		panic("zero mass")
 	}
-	return (f - CalcDrag(v)) / m
+	return (f - dragMultiplier*CalcDrag(v)) / m
 }
 
 func CalcDrag(velocity float64) float64 {
@@ -21,39 +27,161 @@ func CalcDrag(velocity float64) float64 {
 }
 
 func CalcVelocity(acc float64, currVelocity float64) float64 {
-	var newVelocity float64
 	// dt is equal to one
-	if (currVelocity + (acc * 1)) < 0 {
+	return calcVelocity(acc, currVelocity, 1.0)
+}
+
+// same as CalcVelocity, over a time step of dt
+func calcVelocity(acc float64, currVelocity float64, dt float64) float64 {
+	var newVelocity float64
+	if (currVelocity + (acc * dt)) < 0 {
 		newVelocity = 0.0
 	} else {
-		newVelocity = (acc * 1) + currVelocity
+		newVelocity = (acc * dt) + currVelocity
 	}
 	return newVelocity
 }
 
 func GetNewPosition(coordinates utils.Coordinates, velocity float64, orientation float64) utils.Coordinates {
-	coordinates.X += velocity * float64(math.Cos(float64(math.Pi*orientation)))
-	coordinates.Y += velocity * float64(math.Sin(float64(math.Pi*orientation)))
+	coordinates, _ = ApplyBoundary(displace(coordinates, velocity, orientation))
 	return coordinates
 }
 
-// ComputeOrientation is to compute the orientation from source coordinate to target coordinate
-func ComputeOrientation(src utils.Coordinates, target utils.Coordinates) float64 {
+// moves the coordinates by the given distance along the orientation, without applying the world boundary
+func displace(coordinates utils.Coordinates, distance float64, orientation float64) utils.Coordinates {
+	coordinates.X += distance * float64(math.Cos(float64(math.Pi*orientation)))
+	coordinates.Y += distance * float64(math.Sin(float64(math.Pi*orientation)))
+	return coordinates
+}
+
+// ApplyBoundary maps a position back onto the grid according to utils.WorldBoundary.
+// With a walled boundary the position is clamped and the returned flag reports whether a wall was hit,
+// with a toroidal boundary the position is wrapped around, and with an open boundary it is left untouched.
+func ApplyBoundary(coordinates utils.Coordinates) (utils.Coordinates, bool) {
+	switch utils.WorldBoundary {
+	case utils.WalledBoundary:
+		clamped := utils.Coordinates{
+			X: math.Max(0, math.Min(coordinates.X, utils.GridWidth)),
+			Y: math.Max(0, math.Min(coordinates.Y, utils.GridHeight)),
+		}
+		return clamped, clamped != coordinates
+	case utils.ToroidalBoundary:
+		return utils.Coordinates{
+			X: wrap(coordinates.X, utils.GridWidth),
+			Y: wrap(coordinates.Y, utils.GridHeight),
+		}, false
+	default:
+		return coordinates, false
+	}
+}
+
+// wraps value into [0, size)
+func wrap(value float64, size float64) float64 {
+	value = math.Mod(value, size)
+	if value < 0 {
+		value += size
+	}
+	return value
+}
+
+// ComputeDisplacement returns the (x, y) vector pointing from source to target.
+// With a toroidal boundary the shortest vector across the wrapped edges is returned.
+func ComputeDisplacement(src utils.Coordinates, target utils.Coordinates) (float64, float64) {
 	xDiff := target.X - src.X
 	yDiff := target.Y - src.Y
+	if utils.WorldBoundary == utils.ToroidalBoundary {
+		xDiff -= utils.GridWidth * math.Round(xDiff/utils.GridWidth)
+		yDiff -= utils.GridHeight * math.Round(yDiff/utils.GridHeight)
+	}
+	return xDiff, yDiff
+}
+
+// ComputeOrientation is to compute the orientation from source coordinate to target coordinate
+func ComputeOrientation(src utils.Coordinates, target utils.Coordinates) float64 {
+	xDiff, yDiff := ComputeDisplacement(src, target)
 	return math.Atan2(yDiff, xDiff) / math.Pi
 }
 
-// ComputeDistance is to compute the L2 distance from source to target
+// ComputeDistance is to compute the squared L2 distance from source to target
 func ComputeDistance(src utils.Coordinates, target utils.Coordinates) float64 {
-	return math.Pow(src.X-target.X, 2) + math.Pow(src.Y-target.Y, 2)
+	xDiff, yDiff := ComputeDisplacement(src, target)
+	return math.Pow(xDiff, 2) + math.Pow(yDiff, 2)
+}
+
+// GenerateNewState moves an object by one round with the default engine (dt = 1, a single semi-implicit Euler step).
+// The terrain map can be nil, in which case the ground is flat and free of obstacles
+func GenerateNewState(initialState utils.PhysicalState, force float64, orientation float64, terrainMap terrain.ITerrain) utils.PhysicalState {
+	engine := NewEngine(1.0, 1, utils.SemiImplicitEuler, terrainMap)
+	return engine.GenerateNewState(initialState, force, orientation)
+}
+
+// Engine moves physics objects over a round lasting Dt, split into SubSteps integration steps.
+// The force and orientation of an object are held constant during the whole round
+type Engine struct {
+	Dt         float64
+	SubSteps   int
+	Integrator utils.Integrator
+	Terrain    terrain.ITerrain // can be nil, in which case the ground is flat and free of obstacles
+}
+
+func NewEngine(dt float64, subSteps int, integrator utils.Integrator, terrainMap terrain.ITerrain) *Engine {
+	if dt <= 0 {
+		panic("time step must be positive")
+	}
+	if subSteps < 1 {
+		panic("at least one sub-step per round is needed")
+	}
+	return &Engine{
+		Dt:         dt,
+		SubSteps:   subSteps,
+		Integrator: integrator,
+		Terrain:    terrainMap,
+	}
 }
 
-// This function is to be called from the server only
-func GenerateNewState(initialState utils.PhysicalState, force float64, orientation float64) utils.PhysicalState {
-	acceleration := CalcAcceleration(force, initialState.Mass, initialState.Velocity)
-	velocity := CalcVelocity(acceleration, initialState.Velocity)
-	coordinates := GetNewPosition(initialState.Position, velocity, orientation)
+// StepDuration returns the duration of a single sub-step
+func (e *Engine) StepDuration() float64 {
+	return e.Dt / float64(e.SubSteps)
+}
+
+// This function is to be called from the server only.
+// GenerateNewState moves an object through all the sub-steps of a round
+func (e *Engine) GenerateNewState(initialState utils.PhysicalState, force float64, orientation float64) utils.PhysicalState {
+	state := initialState
+	for i := 0; i < e.SubSteps; i++ {
+		state = e.Step(state, force, orientation)
+	}
+	return state
+}
+
+// This function is to be called from the server only.
+// Step moves an object by a single sub-step, so that the server can check for collisions in between
+func (e *Engine) Step(initialState utils.PhysicalState, force float64, orientation float64) utils.PhysicalState {
+	dt := e.StepDuration()
+	dragMultiplier := 1.0
+	if e.Terrain != nil {
+		force += e.Terrain.GetSlopeForce(initialState.Position, orientation)
+		dragMultiplier = e.Terrain.GetDragMultiplier(initialState.Position)
+	}
+
+	var acceleration, velocity, distance float64
+	switch e.Integrator {
+	case utils.RungeKutta4:
+		velocity, distance = rungeKutta4(force, initialState.Mass, initialState.Velocity, dragMultiplier, dt)
+		acceleration = (velocity - initialState.Velocity) / dt
+	default:
+		// semi-implicit Euler: the updated velocity is used to move the object
+		acceleration = calcAcceleration(force, initialState.Mass, initialState.Velocity, dragMultiplier)
+		velocity = calcVelocity(acceleration, initialState.Velocity, dt)
+		distance = velocity * dt
+	}
+
+	coordinates, stopped := e.moveAlong(initialState.Position, distance, orientation)
+	if stopped {
+		// walls and obstacles absorb all of the object's momentum
+		acceleration = 0.0
+		velocity = 0.0
+	}
 
 	finalState := utils.PhysicalState{
 		Position:     coordinates,
@@ -64,3 +192,113 @@ func GenerateNewState(initialState utils.PhysicalState, force float64, orientati
 
 	return finalState
 }
+
+// This function is to be called from the server only.
+// Drift moves an object at constant velocity by a single sub-step, regardless of forces and drag (e.g. drifting lootboxes)
+func (e *Engine) Drift(initialState utils.PhysicalState, orientation float64) utils.PhysicalState {
+	coordinates, stopped := e.moveAlong(initialState.Position, initialState.Velocity*e.StepDuration(), orientation)
+	finalState := initialState
+	finalState.Position = coordinates
+	if stopped {
+		finalState.Velocity = 0.0
+	}
+	return finalState
+}
+
+// moves the position by the given distance along the orientation, as allowed by the terrain and the world boundary.
+// The returned flag is true if the object was stopped by an obstacle or a wall
+func (e *Engine) moveAlong(position utils.Coordinates, distance float64, orientation float64) (utils.Coordinates, bool) {
+	if e.Terrain == nil {
+		return ApplyBoundary(displace(position, distance, orientation))
+	}
+	// with a toroidal boundary the path is checked piece by piece, each piece ending where it leaves the grid and
+	// the next one starting on the opposite edge
+	for {
+		piece := distance
+		if utils.WorldBoundary == utils.ToroidalBoundary {
+			position = enterGrid(position, orientation)
+			piece = math.Min(distance, distanceToEdge(position, orientation))
+		}
+		target := displace(position, piece, orientation)
+		if e.Terrain.IsPathBlocked(position, target) {
+			// obstacles can't be crossed, the object stops in front of the first one on its path
+			stop, _ := ApplyBoundary(displace(position, e.distanceToObstacle(position, piece, orientation), orientation))
+			return stop, true
+		}
+		distance -= piece
+		if distance <= 0 {
+			return ApplyBoundary(target)
+		}
+		position = target
+	}
+}
+
+// how far the object can go along the path before the terrain blocks it, found by bisection
+func (e *Engine) distanceToObstacle(position utils.Coordinates, distance float64, orientation float64) float64 {
+	free, blocked := 0.0, distance
+	for i := 0; i < 32; i++ {
+		middle := (free + blocked) / 2
+		if e.Terrain.IsPathBlocked(position, displace(position, middle, orientation)) {
+			blocked = middle
+		} else {
+			free = middle
+		}
+	}
+	return free
+}
+
+// positions closer than this to an edge of the grid are on it, so that rounding errors can't stall a path on an edge
+const edgeTolerance = 1e-9
+
+// moves a position on an edge of the grid to the opposite edge if the orientation leaves the grid through it
+func enterGrid(position utils.Coordinates, orientation float64) utils.Coordinates {
+	dx, dy := math.Cos(math.Pi*orientation), math.Sin(math.Pi*orientation)
+	if position.X >= utils.GridWidth-edgeTolerance && dx > 0 {
+		position.X = 0
+	} else if position.X <= edgeTolerance && dx < 0 {
+		position.X = utils.GridWidth
+	}
+	if position.Y >= utils.GridHeight-edgeTolerance && dy > 0 {
+		position.Y = 0
+	} else if position.Y <= edgeTolerance && dy < 0 {
+		position.Y = utils.GridHeight
+	}
+	return position
+}
+
+// distance along the orientation from a position on the grid to the edge of the grid
+func distanceToEdge(position utils.Coordinates, orientation float64) float64 {
+	dx, dy := math.Cos(math.Pi*orientation), math.Sin(math.Pi*orientation)
+	distance := math.Inf(1)
+	if dx > 0 {
+		distance = math.Min(distance, (utils.GridWidth-position.X)/dx)
+	} else if dx < 0 {
+		distance = math.Min(distance, position.X/-dx)
+	}
+	if dy > 0 {
+		distance = math.Min(distance, (utils.GridHeight-position.Y)/dy)
+	} else if dy < 0 {
+		distance = math.Min(distance, position.Y/-dy)
+	}
+	return distance
+}
+
+// integrates the velocity and the travelled distance over dt with the classic 4th order Runge-Kutta method.
+// Velocities are clamped at zero at every stage as bikes can't go backwards
+func rungeKutta4(force float64, mass float64, velocity float64, dragMultiplier float64, dt float64) (float64, float64) {
+	acc := func(v float64) float64 {
+		return calcAcceleration(force, mass, v, dragMultiplier)
+	}
+	v1 := velocity
+	k1 := acc(v1)
+	v2 := math.Max(0, velocity+k1*dt/2)
+	k2 := acc(v2)
+	v3 := math.Max(0, velocity+k2*dt/2)
+	k3 := acc(v3)
+	v4 := math.Max(0, velocity+k3*dt)
+	k4 := acc(v4)
+
+	newVelocity := math.Max(0, velocity+dt/6*(k1+2*k2+2*k3+k4))
+	distance := dt / 6 * (v1 + 2*v2 + 2*v3 + v4)
+	return newVelocity, distance
+}
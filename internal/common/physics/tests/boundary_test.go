@@ -0,0 +1,87 @@
+package physics
+
+import (
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"testing"
+)
+
+func setBoundary(t *testing.T, mode utils.BoundaryMode) {
+	oldMode := utils.WorldBoundary
+	utils.WorldBoundary = mode
+	t.Cleanup(func() {
+		utils.WorldBoundary = oldMode
+	})
+}
+
+func TestOpenBoundaryLeavesGrid(t *testing.T) {
+	setBoundary(t, utils.OpenBoundary)
+	state := utils.PhysicalState{
+		Position: utils.Coordinates{X: utils.GridWidth - 1, Y: 10},
+		Velocity: 5,
+		Mass:     1,
+	}
+	newState := physics.GenerateNewState(state, physics.CalcDrag(state.Velocity), 0, nil)
+	if newState.Position.X <= utils.GridWidth {
+		t.Errorf("Expected the object to leave the grid, got x = %f", newState.Position.X)
+	}
+}
+
+func TestWalledBoundaryStopsObject(t *testing.T) {
+	setBoundary(t, utils.WalledBoundary)
+	state := utils.PhysicalState{
+		Position: utils.Coordinates{X: utils.GridWidth - 1, Y: 10},
+		Velocity: 5,
+		Mass:     1,
+	}
+	newState := physics.GenerateNewState(state, physics.CalcDrag(state.Velocity), 0, nil)
+	if newState.Position.X != utils.GridWidth {
+		t.Errorf("Expected the object to be clamped to the wall, got x = %f", newState.Position.X)
+	}
+	if newState.Velocity != 0 {
+		t.Errorf("Expected the wall to stop the object, got velocity %f", newState.Velocity)
+	}
+
+	// moving inside the grid should not be affected
+	state.Position.X = 10
+	newState = physics.GenerateNewState(state, physics.CalcDrag(state.Velocity), 0, nil)
+	if newState.Velocity == 0 {
+		t.Error("Object was stopped without touching a wall")
+	}
+}
+
+func TestToroidalBoundaryWraps(t *testing.T) {
+	setBoundary(t, utils.ToroidalBoundary)
+	position := physics.GetNewPosition(utils.Coordinates{X: utils.GridWidth - 1, Y: 1}, 3, -0.5)
+	if math.Abs(position.X-(utils.GridWidth-1)) > 1e-9 || math.Abs(position.Y-(utils.GridHeight-2)) > 1e-9 {
+		t.Errorf("Expected the object to wrap to the top edge, got %+v", position)
+	}
+
+	position = physics.GetNewPosition(utils.Coordinates{X: utils.GridWidth - 1, Y: 1}, 3, 0)
+	if math.Abs(position.X-2) > 1e-9 {
+		t.Errorf("Expected the object to wrap to the left edge, got %+v", position)
+	}
+}
+
+func TestToroidalDistanceAndOrientation(t *testing.T) {
+	src := utils.Coordinates{X: utils.GridWidth - 2, Y: 5}
+	target := utils.Coordinates{X: 2, Y: 5}
+
+	setBoundary(t, utils.OpenBoundary)
+	if physics.ComputeDistance(src, target) != math.Pow(utils.GridWidth-4, 2) {
+		t.Error("Open boundary distance should not wrap")
+	}
+
+	utils.WorldBoundary = utils.ToroidalBoundary
+	if distance := physics.ComputeDistance(src, target); math.Abs(distance-16) > 1e-9 {
+		t.Errorf("Expected the wrapped squared distance to be 16, got %f", distance)
+	}
+	// the shortest way to the target is across the right edge
+	if orientation := physics.ComputeOrientation(src, target); math.Abs(orientation) > 1e-9 {
+		t.Errorf("Expected orientation 0 across the edge, got %f", orientation)
+	}
+	if orientation := physics.ComputeOrientation(target, src); math.Abs(math.Abs(orientation)-1) > 1e-9 {
+		t.Errorf("Expected orientation ±1 across the edge, got %f", orientation)
+	}
+}
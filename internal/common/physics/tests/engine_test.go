@@ -0,0 +1,109 @@
+package physics
+
+import (
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"testing"
+)
+
+// analytical solution for an object coasting (no force) against quadratic drag:
+// v(t) = v0 / (1 + c*v0*t/m) and x(t) = m/c * ln(1 + c*v0*t/m)
+func coastingSolution(v0 float64, mass float64, t float64) (float64, float64) {
+	k := utils.DragCoefficient * v0 * t / mass
+	return v0 / (1 + k), mass / utils.DragCoefficient * math.Log(1+k)
+}
+
+func kineticEnergy(state utils.PhysicalState) float64 {
+	return 0.5 * state.Mass * math.Pow(state.Velocity, 2)
+}
+
+func TestDefaultEngineMatchesLegacyStep(t *testing.T) {
+	state := utils.PhysicalState{Position: utils.Coordinates{X: 10, Y: 20}, Velocity: 0.7, Mass: 3}
+	force, orientation := 2.4, 0.25
+
+	// legacy explicit formula with dt = 1
+	acceleration := (force - utils.DragCoefficient*math.Pow(state.Velocity, 2)) / state.Mass
+	velocity := state.Velocity + acceleration
+	expectedX := state.Position.X + velocity*math.Cos(math.Pi*orientation)
+	expectedY := state.Position.Y + velocity*math.Sin(math.Pi*orientation)
+
+	engine := physics.NewEngine(1, 1, utils.SemiImplicitEuler, nil)
+	for _, newState := range []utils.PhysicalState{
+		engine.GenerateNewState(state, force, orientation),
+		physics.GenerateNewState(state, force, orientation, nil),
+	} {
+		if newState.Velocity != velocity || newState.Acceleration != acceleration ||
+			newState.Position.X != expectedX || newState.Position.Y != expectedY {
+			t.Errorf("Default engine drifted from the legacy integration: got %+v", newState)
+		}
+	}
+}
+
+func TestEngineAgainstCoastingSolution(t *testing.T) {
+	v0, mass, rounds := 3.0, 2.0, 20
+	expectedVelocity, expectedDistance := coastingSolution(v0, mass, float64(rounds))
+
+	tests := []struct {
+		integrator utils.Integrator
+		subSteps   int
+		tolerance  float64
+	}{
+		{utils.SemiImplicitEuler, 1000, 1e-2},
+		{utils.RungeKutta4, 10, 1e-4},
+		{utils.RungeKutta4, 100, 1e-7},
+	}
+	for _, test := range tests {
+		engine := physics.NewEngine(1, test.subSteps, test.integrator, nil)
+		state := utils.PhysicalState{Velocity: v0, Mass: mass}
+		for i := 0; i < rounds; i++ {
+			state = engine.GenerateNewState(state, 0, 0)
+		}
+		if math.Abs(state.Velocity-expectedVelocity) > test.tolerance {
+			t.Errorf("Integrator %d with %d sub-steps: velocity %f, expected %f", test.integrator, test.subSteps, state.Velocity, expectedVelocity)
+		}
+		if math.Abs(state.Position.X-expectedDistance) > test.tolerance*expectedDistance {
+			t.Errorf("Integrator %d with %d sub-steps: position drifted to %f, expected %f", test.integrator, test.subSteps, state.Position.X, expectedDistance)
+		}
+	}
+}
+
+func TestCoastingNeverGainsEnergy(t *testing.T) {
+	for _, integrator := range []utils.Integrator{utils.SemiImplicitEuler, utils.RungeKutta4} {
+		engine := physics.NewEngine(1, 4, integrator, nil)
+		state := utils.PhysicalState{Velocity: 5, Mass: 1}
+		for i := 0; i < 100; i++ {
+			newState := engine.Step(state, 0, 0.3)
+			if kineticEnergy(newState) > kineticEnergy(state) || newState.Velocity < 0 {
+				t.Fatalf("Integrator %d gained energy or went backwards at step %d: %+v -> %+v", integrator, i, state, newState)
+			}
+			state = newState
+		}
+	}
+}
+
+func TestTerminalVelocity(t *testing.T) {
+	force, mass := 2.0, 9.0
+	terminalVelocity := math.Sqrt(force / utils.DragCoefficient)
+	for _, integrator := range []utils.Integrator{utils.SemiImplicitEuler, utils.RungeKutta4} {
+		engine := physics.NewEngine(1, 8, integrator, nil)
+		state := utils.PhysicalState{Mass: mass}
+		for i := 0; i < 500; i++ {
+			state = engine.GenerateNewState(state, force, 0)
+		}
+		if math.Abs(state.Velocity-terminalVelocity) > 1e-6 {
+			t.Errorf("Integrator %d settled at %f, expected terminal velocity %f", integrator, state.Velocity, terminalVelocity)
+		}
+	}
+}
+
+func TestTimeStepScalesRound(t *testing.T) {
+	// a round of dt = 2 is the same as two rounds of dt = 1
+	state := utils.PhysicalState{Velocity: 1, Mass: 2}
+	long := physics.NewEngine(2, 2, utils.RungeKutta4, nil).GenerateNewState(state, 1, 0)
+	short := physics.NewEngine(1, 1, utils.RungeKutta4, nil)
+	twice := short.GenerateNewState(short.GenerateNewState(state, 1, 0), 1, 0)
+	if math.Abs(long.Velocity-twice.Velocity) > 1e-12 || math.Abs(long.Position.X-twice.Position.X) > 1e-12 {
+		t.Errorf("Expected equal states, got %+v and %+v", long, twice)
+	}
+}
@@ -0,0 +1,160 @@
+package terrain
+
+/*
+
+The terrain describes the static features of the map: impassable obstacles, rough zones which slow bikes down
+and slopes which help or hinder bikes depending on their heading.
+It is loaded once by the server and exposed read-only to the agents through IGameState.
+
+*/
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"encoding/json"
+	"math"
+	"os"
+	"slices"
+)
+
+type ITerrain interface {
+	GetObstacles() []Region
+	GetRoughZones() []RoughZone
+	GetSlopes() []Slope
+	// returns false if the position is inside an obstacle
+	IsPassable(position utils.Coordinates) bool
+	// returns true if the straight path between the two positions goes through an obstacle
+	IsPathBlocked(from utils.Coordinates, to utils.Coordinates) bool
+	// returns the factor by which the drag is multiplied at the given position (1 on normal ground)
+	GetDragMultiplier(position utils.Coordinates) float64
+	// returns the extra force applied to an object at the given position moving with the given orientation.
+	// It is positive when going downhill and negative when going uphill
+	GetSlopeForce(position utils.Coordinates, orientation float64) float64
+}
+
+// Region is an axis aligned rectangle spanning from Min to Max
+type Region struct {
+	Min utils.Coordinates `json:"min"`
+	Max utils.Coordinates `json:"max"`
+}
+
+type RoughZone struct {
+	Region         Region  `json:"region"`
+	DragMultiplier float64 `json:"drag_multiplier"`
+}
+
+type Slope struct {
+	Region Region `json:"region"`
+	// orientation (between -1 and 1, as for the bikes) pointing downhill
+	Downhill float64 `json:"downhill"`
+	// force added when going straight downhill (and removed when going straight uphill)
+	Steepness float64 `json:"steepness"`
+}
+
+type Terrain struct {
+	Obstacles  []Region    `json:"obstacles"`
+	RoughZones []RoughZone `json:"rough_zones"`
+	Slopes     []Slope     `json:"slopes"`
+}
+
+// GetFlatTerrain returns a terrain without any features
+func GetFlatTerrain() *Terrain {
+	return &Terrain{}
+}
+
+// LoadTerrain reads a terrain from a JSON file. An empty path gives a flat terrain
+func LoadTerrain(path string) (*Terrain, error) {
+	if path == "" {
+		return GetFlatTerrain(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	terrain := GetFlatTerrain()
+	if err := json.Unmarshal(data, terrain); err != nil {
+		return nil, err
+	}
+	return terrain, nil
+}
+
+// Copy returns the features of the terrain as a Terrain, which can be serialised whatever the terrain implementation
+func Copy(t ITerrain) *Terrain {
+	return &Terrain{Obstacles: t.GetObstacles(), RoughZones: t.GetRoughZones(), Slopes: t.GetSlopes()}
+}
+
+func (r Region) Contains(position utils.Coordinates) bool {
+	return position.X >= r.Min.X && position.X <= r.Max.X && position.Y >= r.Min.Y && position.Y <= r.Max.Y
+}
+
+// IntersectsSegment checks whether the segment from -> to crosses the region (Liang-Barsky clipping)
+func (r Region) IntersectsSegment(from utils.Coordinates, to utils.Coordinates) bool {
+	dx, dy := to.X-from.X, to.Y-from.Y
+	tMin, tMax := 0.0, 1.0
+	clip := func(p float64, q float64) bool {
+		if p == 0 {
+			// segment parallel to this edge, it is either fully inside or fully outside
+			return q >= 0
+		}
+		t := q / p
+		if p < 0 {
+			tMin = math.Max(tMin, t)
+		} else {
+			tMax = math.Min(tMax, t)
+		}
+		return tMin <= tMax
+	}
+	return clip(-dx, from.X-r.Min.X) && clip(dx, r.Max.X-from.X) &&
+		clip(-dy, from.Y-r.Min.Y) && clip(dy, r.Max.Y-from.Y)
+}
+
+func (t *Terrain) GetObstacles() []Region {
+	return slices.Clone(t.Obstacles)
+}
+
+func (t *Terrain) GetRoughZones() []RoughZone {
+	return slices.Clone(t.RoughZones)
+}
+
+func (t *Terrain) GetSlopes() []Slope {
+	return slices.Clone(t.Slopes)
+}
+
+func (t *Terrain) IsPassable(position utils.Coordinates) bool {
+	for _, obstacle := range t.Obstacles {
+		if obstacle.Contains(position) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Terrain) IsPathBlocked(from utils.Coordinates, to utils.Coordinates) bool {
+	for _, obstacle := range t.Obstacles {
+		if obstacle.IntersectsSegment(from, to) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlapping rough zones multiply their effect
+func (t *Terrain) GetDragMultiplier(position utils.Coordinates) float64 {
+	multiplier := 1.0
+	for _, zone := range t.RoughZones {
+		if zone.Region.Contains(position) {
+			multiplier *= zone.DragMultiplier
+		}
+	}
+	return multiplier
+}
+
+// overlapping slopes add up their effect
+func (t *Terrain) GetSlopeForce(position utils.Coordinates, orientation float64) float64 {
+	force := 0.0
+	for _, slope := range t.Slopes {
+		if slope.Region.Contains(position) {
+			force += slope.Steepness * math.Cos(math.Pi*(orientation-slope.Downhill))
+		}
+	}
+	return force
+}
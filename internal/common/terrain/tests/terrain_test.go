@@ -0,0 +1,130 @@
+package terrain
+
+import (
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/terrain"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const terrainJson = `{
+	"obstacles": [{"min": {"x": 10, "y": 0}, "max": {"x": 12, "y": 20}}],
+	"rough_zones": [{"region": {"min": {"x": 50, "y": 50}, "max": {"x": 60, "y": 60}}, "drag_multiplier": 3}],
+	"slopes": [{"region": {"min": {"x": 100, "y": 100}, "max": {"x": 120, "y": 120}}, "downhill": 0, "steepness": 0.5}]
+}`
+
+func loadTestTerrain(t *testing.T) *terrain.Terrain {
+	path := filepath.Join(t.TempDir(), "terrain.json")
+	if err := os.WriteFile(path, []byte(terrainJson), 0644); err != nil {
+		t.Fatal(err)
+	}
+	terrainMap, err := terrain.LoadTerrain(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return terrainMap
+}
+
+func TestLoadTerrain(t *testing.T) {
+	terrainMap := loadTestTerrain(t)
+	if len(terrainMap.GetObstacles()) != 1 || len(terrainMap.GetRoughZones()) != 1 || len(terrainMap.GetSlopes()) != 1 {
+		t.Errorf("Terrain not loaded correctly: %+v", terrainMap)
+	}
+
+	flat, err := terrain.LoadTerrain("")
+	if err != nil || len(flat.GetObstacles()) != 0 {
+		t.Error("Empty path should give a flat terrain")
+	}
+
+	if _, err := terrain.LoadTerrain(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error when loading a missing file")
+	}
+}
+
+func TestTerrainQueries(t *testing.T) {
+	terrainMap := loadTestTerrain(t)
+
+	if terrainMap.IsPassable(utils.Coordinates{X: 11, Y: 5}) {
+		t.Error("Position inside an obstacle should not be passable")
+	}
+	if !terrainMap.IsPassable(utils.Coordinates{X: 15, Y: 5}) {
+		t.Error("Position outside obstacles should be passable")
+	}
+	if !terrainMap.IsPathBlocked(utils.Coordinates{X: 5, Y: 5}, utils.Coordinates{X: 15, Y: 5}) {
+		t.Error("Path through an obstacle should be blocked")
+	}
+	if terrainMap.IsPathBlocked(utils.Coordinates{X: 5, Y: 25}, utils.Coordinates{X: 15, Y: 25}) {
+		t.Error("Path around an obstacle should not be blocked")
+	}
+
+	if terrainMap.GetDragMultiplier(utils.Coordinates{X: 55, Y: 55}) != 3 {
+		t.Error("Expected the rough zone to triple the drag")
+	}
+	if terrainMap.GetDragMultiplier(utils.Coordinates{X: 5, Y: 55}) != 1 {
+		t.Error("Expected normal drag outside rough zones")
+	}
+
+	slopePosition := utils.Coordinates{X: 110, Y: 110}
+	if force := terrainMap.GetSlopeForce(slopePosition, 0); math.Abs(force-0.5) > 1e-9 {
+		t.Errorf("Expected downhill force 0.5, got %f", force)
+	}
+	if force := terrainMap.GetSlopeForce(slopePosition, 1); math.Abs(force+0.5) > 1e-9 {
+		t.Errorf("Expected uphill force -0.5, got %f", force)
+	}
+	if force := terrainMap.GetSlopeForce(slopePosition, 0.5); math.Abs(force) > 1e-9 {
+		t.Errorf("Expected no force across the slope, got %f", force)
+	}
+}
+
+func TestPhysicsOnTerrain(t *testing.T) {
+	terrainMap := loadTestTerrain(t)
+
+	// a bike heading into the obstacle is stopped at its edge
+	state := utils.PhysicalState{Position: utils.Coordinates{X: 9, Y: 5}, Velocity: 3, Mass: 1}
+	newState := physics.GenerateNewState(state, physics.CalcDrag(state.Velocity), 0, terrainMap)
+	if newState.Position.X < 10-1e-6 || newState.Position.X > 10 || newState.Position.Y != 5 || newState.Velocity != 0 {
+		t.Errorf("Expected the obstacle to stop the bike at its edge, got %+v", newState)
+	}
+
+	// rough ground slows bikes down more than flat ground
+	state = utils.PhysicalState{Position: utils.Coordinates{X: 55, Y: 55}, Velocity: 1, Mass: 1}
+	rough := physics.GenerateNewState(state, 1, 0.5, terrainMap)
+	flat := physics.GenerateNewState(state, 1, 0.5, nil)
+	if rough.Velocity >= flat.Velocity {
+		t.Errorf("Expected rough ground to slow the bike down: rough %f, flat %f", rough.Velocity, flat.Velocity)
+	}
+
+	// going downhill is faster than going uphill
+	state.Position = utils.Coordinates{X: 110, Y: 110}
+	downhill := physics.GenerateNewState(state, 1, 0, terrainMap)
+	uphill := physics.GenerateNewState(state, 1, 1, terrainMap)
+	if downhill.Velocity <= uphill.Velocity {
+		t.Errorf("Expected downhill to be faster than uphill: downhill %f, uphill %f", downhill.Velocity, uphill.Velocity)
+	}
+}
+
+func TestObstacleAcrossToroidalEdge(t *testing.T) {
+	oldMode := utils.WorldBoundary
+	utils.WorldBoundary = utils.ToroidalBoundary
+	t.Cleanup(func() {
+		utils.WorldBoundary = oldMode
+	})
+	terrainMap := &terrain.Terrain{Obstacles: []terrain.Region{{Min: utils.Coordinates{X: 1, Y: 0}, Max: utils.Coordinates{X: 2, Y: 20}}}}
+
+	// the path wraps around the right edge of the grid into the obstacle
+	state := utils.PhysicalState{Position: utils.Coordinates{X: utils.GridWidth - 1, Y: 5}, Velocity: 5, Mass: 1}
+	newState := physics.GenerateNewState(state, physics.CalcDrag(state.Velocity), 0, terrainMap)
+	if newState.Position.X < 1-1e-6 || newState.Position.X > 1 || newState.Velocity != 0 {
+		t.Errorf("Expected the obstacle to stop the bike at its edge after wrapping, got %+v", newState)
+	}
+
+	// paths that wrap around without meeting an obstacle are free
+	state.Position.Y = 50
+	newState = physics.GenerateNewState(state, physics.CalcDrag(state.Velocity), 0, terrainMap)
+	if newState.Velocity == 0 || newState.Position.X < 2 || newState.Position.X > 5 {
+		t.Errorf("Expected the bike to wrap around freely, got %+v", newState)
+	}
+}
@@ -13,6 +13,12 @@ const ResetPointsEveryRound = true
 const RespawnEveryRound = true
 const RoundIterations = 100
 
+// WorldBoundary determines how the edges of the grid behave (see utils.BoundaryMode)
+var WorldBoundary BoundaryMode = OpenBoundary
+
+// TerrainFile is the path of the JSON file describing the terrain (see terrain.Terrain), empty for a flat map
+var TerrainFile string = ""
+
 /*
 Server Parameters
 */
@@ -31,6 +37,11 @@ const AudiMaxForce float64 = 1.0  // The audi's force is equivalent to that of o
 
 const DragCoefficient float64 = 0.5 // Drag coefficient can be optimised in experimentation
 
+// the physics engine is configured when the server is initialised
+var PhysicsTimeStep float64 = 1.0                    // duration of a round
+var PhysicsSubSteps = 1                              // integration steps per round, collisions are checked after each of them
+var PhysicsIntegrator Integrator = SemiImplicitEuler // integration method used by the physics engine
+
 const MovingDepletion float64 = 0.01 // proportionality of energy loss
 
 const LimboEnergyPenalty float64 = -0.05 // amount of energy lost per round when off a bike
@@ -42,6 +53,17 @@ const LeadershipDemocracyPenalty float64 = 0.025  // amount of energy lost per v
 Resources - Points and Energy
 */
 const PointsFromSameColouredLootBox = 5.0
+const MinLootBoxResources float64 = 2.0 // lootboxes are spawned with resources uniformly distributed between min and max
+const MaxLootBoxResources float64 = 4.0
+
+/*
+Lootbox Lifecycle (all disabled by default)
+*/
+var LootBoxLifetime = 0                // rounds before a lootbox expires, 0 if lootboxes never expire
+var LootBoxDecayRate float64 = 0.0     // fraction of its resources a lootbox loses every round
+var LootBoxMaxDriftSpeed float64 = 0.0 // lootboxes drift in a random direction at a speed up to this value
+var JackpotProbability float64 = 0.0   // probability of a lootbox spawning as a jackpot
+var JackpotMultiplier float64 = 10.0   // jackpots hold this many times more resources than normal lootboxes
 
 /*
 Audi Behavior
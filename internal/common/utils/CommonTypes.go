@@ -78,6 +78,19 @@ const (
 	Invalid
 )
 
+func (g Governance) String() string {
+	switch g {
+	case Democracy:
+		return "democracy"
+	case Leadership:
+		return "leadership"
+	case Dictatorship:
+		return "dictatorship"
+	default:
+		return "invalid"
+	}
+}
+
 type Action int
 
 const (
@@ -85,4 +98,20 @@ const (
 	Joining
 	Direction
 	Allocation
+	Taxation
+)
+
+type Integrator int
+
+const (
+	SemiImplicitEuler Integrator = iota // the velocity is updated first, then used to move the object
+	RungeKutta4
+)
+
+type BoundaryMode int
+
+const (
+	OpenBoundary     BoundaryMode = iota // objects can leave the grid freely
+	WalledBoundary                       // objects are stopped at the grid edges
+	ToroidalBoundary                     // objects leaving one edge re-enter from the opposite one
 )
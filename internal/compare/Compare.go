@@ -0,0 +1,255 @@
+package compare
+
+import (
+	"SOMAS2023/internal/server"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Set is a group of runs made with the same configuration, e.g. the baseline or a treatment
+type Set struct {
+	Name  string
+	Files []string
+	// statistics of every file of the set
+	Statistics []server.GameStatistics
+}
+
+// Samples gives the value of every metric in each iteration of the runs of the set
+func (s *Set) Samples() map[string][]float64 {
+	samples := make(map[string][]float64, len(metrics))
+	for _, metric := range metrics {
+		samples[metric.name] = make([]float64, 0)
+		for i := range s.Statistics {
+			for iteration := range s.Statistics[i].PerRound {
+				if value, ok := metric.value(&s.Statistics[i], iteration); ok {
+					samples[metric.name] = append(samples[metric.name], value)
+				}
+			}
+		}
+	}
+	return samples
+}
+
+type Test string
+
+const (
+	Welch       Test = "welch"
+	MannWhitney Test = "mann-whitney"
+)
+
+// Report compares every set with the baseline, the first set
+type Report struct {
+	Test        Test         `json:"test"`
+	Alpha       float64      `json:"alpha"`
+	Baseline    string       `json:"baseline"`
+	Sets        []SetSummary `json:"sets"`
+	Comparisons []Comparison `json:"comparisons"`
+}
+
+type SetSummary struct {
+	Name       string   `json:"name"`
+	Files      []string `json:"files"`
+	Iterations int      `json:"iterations"`
+}
+
+type Comparison struct {
+	Metric        string  `json:"metric"`
+	Treatment     string  `json:"treatment"`
+	BaselineN     int     `json:"baseline_n"`
+	TreatmentN    int     `json:"treatment_n"`
+	BaselineMean  float64 `json:"baseline_mean"`
+	TreatmentMean float64 `json:"treatment_mean"`
+	Difference    float64 `json:"difference"` // treatment mean minus baseline mean
+	// difference relative to the baseline mean, nil when the baseline mean is 0
+	RelativeDifference *float64 `json:"relative_difference"`
+	TestResult
+	Significant bool `json:"significant"` // whether the p-value is below alpha
+}
+
+// Compare runs the test on every metric of every set against the first set
+func Compare(sets []Set, test Test, alpha float64) (Report, error) {
+	if len(sets) < 2 {
+		return Report{}, errors.New("at least two sets are needed to compare")
+	}
+	var run func(baseline []float64, treatment []float64) TestResult
+	switch test {
+	case Welch:
+		run = WelchTest
+	case MannWhitney:
+		run = MannWhitneyTest
+	default:
+		return Report{}, fmt.Errorf("unknown test %q", test)
+	}
+
+	report := Report{Test: test, Alpha: alpha, Baseline: sets[0].Name, Comparisons: make([]Comparison, 0)}
+	for i := range sets {
+		iterations := 0
+		for _, statistics := range sets[i].Statistics {
+			iterations += len(statistics.PerRound)
+		}
+		report.Sets = append(report.Sets, SetSummary{Name: sets[i].Name, Files: sets[i].Files, Iterations: iterations})
+	}
+
+	baseline := sets[0].Samples()
+	for _, set := range sets[1:] {
+		treatment := set.Samples()
+		for _, metric := range metrics {
+			b, t := baseline[metric.name], treatment[metric.name]
+			comparison := Comparison{
+				Metric:        metric.name,
+				Treatment:     set.Name,
+				BaselineN:     len(b),
+				TreatmentN:    len(t),
+				BaselineMean:  mean(b),
+				TreatmentMean: mean(t),
+				TestResult:    run(b, t),
+			}
+			comparison.Difference = comparison.TreatmentMean - comparison.BaselineMean
+			if comparison.BaselineMean != 0 {
+				relative := comparison.Difference / comparison.BaselineMean
+				comparison.RelativeDifference = &relative
+			}
+			comparison.Significant = comparison.PValue != nil && *comparison.PValue < alpha
+			report.Comparisons = append(report.Comparisons, comparison)
+		}
+	}
+	return report, nil
+}
+
+// LoadSet reads the set given on the command line as [name=]path[,path...]. Paths are game dumps or statistics
+// written at the end of a game, or directories whose JSON files are read, skipping those that are neither. A run
+// writes both its game dump and its statistics, so the game dump of a directory that has statistics is skipped
+// rather than counting the run twice
+func LoadSet(spec string) (Set, error) {
+	name, paths, found := strings.Cut(spec, "=")
+	if !found {
+		name, paths = strings.TrimSuffix(filepath.Base(spec), filepath.Ext(spec)), spec
+	}
+	set := Set{Name: name}
+	for _, path := range strings.Split(paths, ",") {
+		info, err := os.Stat(path)
+		if err != nil {
+			return Set{}, err
+		}
+		if !info.IsDir() {
+			statistics, err := loadStatistics(path)
+			if err != nil {
+				return Set{}, fmt.Errorf("%s: %w", path, err)
+			}
+			set.Files = append(set.Files, path)
+			set.Statistics = append(set.Statistics, statistics)
+			continue
+		}
+
+		files, err := filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return Set{}, err
+		}
+		_, err = os.Stat(filepath.Join(path, "statistics.json"))
+		hasStatistics := err == nil
+		for _, file := range files {
+			if hasStatistics && filepath.Base(file) == "game_dump.json" {
+				continue
+			}
+			if statistics, err := loadStatistics(file); err == nil {
+				set.Files = append(set.Files, file)
+				set.Statistics = append(set.Statistics, statistics)
+			}
+		}
+	}
+	if len(set.Statistics) == 0 {
+		return Set{}, fmt.Errorf("set %s has no game dumps or statistics", name)
+	}
+	return set, nil
+}
+
+var errUnknownFile = errors.New("not a game dump or statistics")
+
+// reads a game dump, from which the statistics are calculated, or the statistics themselves
+func loadStatistics(path string) (server.GameStatistics, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return server.GameStatistics{}, err
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return server.GameStatistics{}, errUnknownFile
+	}
+
+	// game dumps are arrays of iterations, statistics are objects
+	if data[0] == '[' {
+		gameStates, err := server.DecodeGameDump(data)
+		if err != nil {
+			return server.GameStatistics{}, err
+		}
+		return server.CalculateStatistics(gameStates), nil
+	}
+	var statistics server.GameStatistics
+	if err := json.Unmarshal(data, &statistics); err != nil {
+		return server.GameStatistics{}, err
+	}
+	if statistics.PerRound == nil {
+		return server.GameStatistics{}, errUnknownFile
+	}
+	return statistics, nil
+}
+
+// Run is the compare command: it compares sets of runs given as arguments and writes the report
+func Run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("compare", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	test := flags.String("test", string(Welch), "significance test, welch or mann-whitney")
+	format := flags.String("format", "markdown", "format of the report, markdown or json")
+	alpha := flags.Float64("alpha", 0.05, "significance level")
+	output := flags.String("o", "", "file to write the report to, standard output when empty")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: compare [flags] baseline treatment [treatment...]")
+		fmt.Fprintln(flags.Output(), "every set is [name=]path[,path...] where paths are game dumps, statistics or directories of them")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return errors.New("at least two sets are needed to compare")
+	}
+
+	sets := make([]Set, 0, flags.NArg())
+	for _, spec := range flags.Args() {
+		set, err := LoadSet(spec)
+		if err != nil {
+			return err
+		}
+		sets = append(sets, set)
+	}
+	report, err := Compare(sets, Test(*test), *alpha)
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	switch *format {
+	case "markdown":
+		return report.WriteMarkdown(w)
+	case "json":
+		return report.WriteJSON(w)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
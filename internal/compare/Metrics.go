@@ -0,0 +1,113 @@
+package compare
+
+import (
+	"SOMAS2023/internal/server"
+
+	"github.com/google/uuid"
+)
+
+// a metric gives a value for an iteration of a game, or false if the iteration has no value for it
+type metric struct {
+	name  string
+	value func(statistics *server.GameStatistics, iteration int) (float64, bool)
+}
+
+// the metrics that are compared, each computed for every iteration so that the iterations are the samples
+var metrics = []metric{
+	{"mean_lifetime", agentMean(func(s *server.AgentStatistics) map[uuid.UUID]float64 { return s.AgentLifetime })},
+	{"mean_energy", agentMean(func(s *server.AgentStatistics) map[uuid.UUID]float64 { return s.AgentEnergyAverage })},
+	{"mean_points", agentMean(func(s *server.AgentStatistics) map[uuid.UUID]float64 { return s.AgentPointsAverage })},
+	{"mean_lying_rate", agentMean(func(s *server.AgentStatistics) map[uuid.UUID]float64 { return s.AgentLyingRate })},
+	{"final_survival_rate", finalWelfare(func(w *server.WelfareStatistics) []float64 { return w.SurvivalRate })},
+	{"final_energy_gini", finalWelfare(func(w *server.WelfareStatistics) []float64 { return w.EnergyGini })},
+	{"final_points_gini", finalWelfare(func(w *server.WelfareStatistics) []float64 { return w.PointsGini })},
+	{"mean_utilitarian_welfare", meanWelfare(func(w *server.WelfareStatistics) []float64 { return w.UtilitarianWelfare })},
+	{"mean_egalitarian_welfare", meanWelfare(func(w *server.WelfareStatistics) []float64 { return w.EgalitarianWelfare })},
+	{"mean_nash_welfare", meanWelfare(func(w *server.WelfareStatistics) []float64 { return w.NashWelfare })},
+	{"mean_allocation_fairness", func(statistics *server.GameStatistics, iteration int) (float64, bool) {
+		if iteration >= len(statistics.Welfare) || len(statistics.Welfare[iteration].AllocationFairness) == 0 {
+			return 0, false
+		}
+		values := make([]float64, 0, len(statistics.Welfare[iteration].AllocationFairness))
+		for _, value := range statistics.Welfare[iteration].AllocationFairness {
+			values = append(values, value)
+		}
+		return mean(values), true
+	}},
+	{"deaths", deaths("")},
+	{"audi_deaths", deaths(server.AudiCollision)},
+	{"starvation_deaths", deaths(server.Starvation)},
+	{"final_reciprocity", finalNetwork(func(n *server.NetworkMetrics) float64 { return n.Reciprocity })},
+	{"final_clustering", finalNetwork(func(n *server.NetworkMetrics) float64 { return n.Clustering })},
+	{"final_modularity", finalNetwork(func(n *server.NetworkMetrics) float64 { return n.Modularity })},
+}
+
+// mean over the agents of a statistic of the iteration
+func agentMean(accessor func(statistics *server.AgentStatistics) map[uuid.UUID]float64) func(*server.GameStatistics, int) (float64, bool) {
+	return func(statistics *server.GameStatistics, iteration int) (float64, bool) {
+		if iteration >= len(statistics.PerRound) {
+			return 0, false
+		}
+		values := accessor(&statistics.PerRound[iteration])
+		if len(values) == 0 {
+			return 0, false
+		}
+		total := 0.0
+		for _, value := range values {
+			total += value
+		}
+		return total / float64(len(values)), true
+	}
+}
+
+// value of a welfare statistic in the last round of the iteration
+func finalWelfare(accessor func(welfare *server.WelfareStatistics) []float64) func(*server.GameStatistics, int) (float64, bool) {
+	return func(statistics *server.GameStatistics, iteration int) (float64, bool) {
+		if iteration >= len(statistics.Welfare) {
+			return 0, false
+		}
+		values := accessor(&statistics.Welfare[iteration])
+		if len(values) == 0 {
+			return 0, false
+		}
+		return values[len(values)-1], true
+	}
+}
+
+// mean of a welfare statistic over the rounds of the iteration
+func meanWelfare(accessor func(welfare *server.WelfareStatistics) []float64) func(*server.GameStatistics, int) (float64, bool) {
+	return func(statistics *server.GameStatistics, iteration int) (float64, bool) {
+		if iteration >= len(statistics.Welfare) {
+			return 0, false
+		}
+		values := accessor(&statistics.Welfare[iteration])
+		return mean(values), len(values) > 0
+	}
+}
+
+// number of deaths of the given cause in the iteration, of any cause when empty
+func deaths(cause server.DeathCause) func(*server.GameStatistics, int) (float64, bool) {
+	return func(statistics *server.GameStatistics, iteration int) (float64, bool) {
+		if iteration >= len(statistics.PerRound) {
+			return 0, false
+		}
+		count := 0
+		for _, death := range statistics.Survival.Deaths {
+			if death.Iteration == iteration && (cause == "" || death.Cause == cause) {
+				count++
+			}
+		}
+		return float64(count), true
+	}
+}
+
+// value of a metric of the trust network in the last round of the iteration
+func finalNetwork(accessor func(network *server.NetworkMetrics) float64) func(*server.GameStatistics, int) (float64, bool) {
+	return func(statistics *server.GameStatistics, iteration int) (float64, bool) {
+		if iteration >= len(statistics.Network) || len(statistics.Network[iteration]) == 0 {
+			return 0, false
+		}
+		rounds := statistics.Network[iteration]
+		return accessor(&rounds[len(rounds)-1]), true
+	}
+}
@@ -0,0 +1,71 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "    ")
+	return encoder.Encode(r)
+}
+
+// writes the report as a Markdown table for every treatment, significant differences are in bold
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	effectSize := "Hedges' g"
+	statistic := "t"
+	if r.Test == MannWhitney {
+		effectSize = "Rank-biserial r"
+		statistic = "U"
+	}
+
+	var err error
+	printf := func(format string, args ...any) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("# Run comparison\n\n")
+	printf("Test: %s, significance level: %s, baseline: %s\n\n", r.Test, formatValue(r.Alpha), r.Baseline)
+	printf("| Set | Files | Iterations |\n|---|---|---|\n")
+	for _, set := range r.Sets {
+		printf("| %s | %d | %d |\n", set.Name, len(set.Files), set.Iterations)
+	}
+
+	treatment := ""
+	for _, comparison := range r.Comparisons {
+		if comparison.Treatment != treatment {
+			treatment = comparison.Treatment
+			printf("\n## %s vs %s\n\n", treatment, r.Baseline)
+			printf("| Metric | Baseline | Treatment | Difference | Relative | %s | p | %s |\n", statistic, effectSize)
+			printf("|---|---|---|---|---|---|---|---|\n")
+		}
+		metric := comparison.Metric
+		if comparison.Significant {
+			metric = "**" + metric + "**"
+		}
+		relative := "n/a"
+		if comparison.RelativeDifference != nil {
+			relative = strconv.FormatFloat(*comparison.RelativeDifference*100, 'f', 1, 64) + "%"
+		}
+		printf("| %s | %s (n=%d) | %s (n=%d) | %s | %s | %s | %s | %s |\n", metric,
+			formatValue(comparison.BaselineMean), comparison.BaselineN, formatValue(comparison.TreatmentMean), comparison.TreatmentN,
+			formatValue(comparison.Difference), relative, formatOptional(comparison.Statistic), formatOptional(comparison.PValue),
+			formatOptional(comparison.EffectSize))
+	}
+	return err
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'g', 4, 64)
+}
+
+func formatOptional(value *float64) string {
+	if value == nil {
+		return "n/a"
+	}
+	return formatValue(*value)
+}
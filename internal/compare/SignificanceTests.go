@@ -0,0 +1,176 @@
+package compare
+
+import (
+	"math"
+	"slices"
+)
+
+// TestResult is the outcome of a two-sample test of a treatment sample against a baseline sample.
+// Values that the test can't give for the samples (e.g. with fewer than 2 values in a sample) are nil
+type TestResult struct {
+	Statistic  *float64 `json:"statistic"`
+	PValue     *float64 `json:"p_value"`
+	EffectSize *float64 `json:"effect_size"` // positive when the treatment tends to be larger
+}
+
+// WelchTest compares the means of the samples with Welch's unequal variances t-test.
+// The statistic is t and the effect size Hedges' g
+func WelchTest(baseline []float64, treatment []float64) TestResult {
+	n1, n2 := float64(len(baseline)), float64(len(treatment))
+	if n1 < 2 || n2 < 2 {
+		return TestResult{}
+	}
+	m1, m2 := mean(baseline), mean(treatment)
+	v1, v2 := variance(baseline), variance(treatment)
+	if v1 == 0 && v2 == 0 {
+		return TestResult{}
+	}
+
+	result := TestResult{}
+	se1, se2 := v1/n1, v2/n2
+	t := (m2 - m1) / math.Sqrt(se1+se2)
+	df := (se1 + se2) * (se1 + se2) / (se1*se1/(n1-1) + se2*se2/(n2-1))
+	p := studentTTwoSided(t, df)
+	result.Statistic, result.PValue = &t, &p
+
+	pooled := math.Sqrt(((n1-1)*v1 + (n2-1)*v2) / (n1 + n2 - 2))
+	g := (m2 - m1) / pooled * (1 - 3/(4*(n1+n2)-9))
+	result.EffectSize = &g
+	return result
+}
+
+// MannWhitneyTest compares the samples with the Mann-Whitney U test, using the normal approximation with tie and
+// continuity corrections. The statistic is the U of the treatment (the number of pairs in which the treatment is
+// larger, counting ties as half) and the effect size the rank-biserial correlation
+func MannWhitneyTest(baseline []float64, treatment []float64) TestResult {
+	n1, n2 := float64(len(baseline)), float64(len(treatment))
+	if n1 == 0 || n2 == 0 {
+		return TestResult{}
+	}
+
+	type value struct {
+		value     float64
+		treatment bool
+	}
+	values := make([]value, 0, len(baseline)+len(treatment))
+	for _, x := range baseline {
+		values = append(values, value{value: x})
+	}
+	for _, x := range treatment {
+		values = append(values, value{value: x, treatment: true})
+	}
+	slices.SortFunc(values, func(a, b value) int {
+		if a.value < b.value {
+			return -1
+		} else if a.value > b.value {
+			return 1
+		}
+		return 0
+	})
+
+	// tied values share the mean of their ranks
+	rankSum, ties := 0.0, 0.0
+	for i := 0; i < len(values); {
+		j := i + 1
+		for ; j < len(values) && values[j].value == values[i].value; j++ {
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if values[k].treatment {
+				rankSum += rank
+			}
+		}
+		tied := float64(j - i)
+		ties += tied*tied*tied - tied
+		i = j
+	}
+
+	u := rankSum - n2*(n2+1)/2
+	n := n1 + n2
+	mu := n1 * n2 / 2
+	sigma := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	p := 1.0
+	if sigma > 0 {
+		z := (math.Abs(u-mu) - 0.5) / sigma
+		p = math.Min(1, math.Erfc(math.Max(z, 0)/math.Sqrt2))
+	}
+	r := 2*u/(n1*n2) - 1
+	return TestResult{Statistic: &u, PValue: &p, EffectSize: &r}
+}
+
+// two-sided p-value of the t statistic with the given degrees of freedom
+func studentTTwoSided(t float64, df float64) float64 {
+	return regularisedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// I_x(a, b), evaluated with the continued fraction of Numerical Recipes (betacf) with Lentz's method
+func regularisedIncompleteBeta(x float64, a float64, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	lgammaA, _ := math.Lgamma(a)
+	lgammaB, _ := math.Lgamma(b)
+	lgammaAB, _ := math.Lgamma(a + b)
+	front := math.Exp(lgammaAB - lgammaA - lgammaB + a*math.Log(x) + b*math.Log(1-x))
+	// the continued fraction converges quickly for x < (a+1)/(a+b+2), otherwise use the symmetry I_x(a, b) = 1 - I_1-x(b, a)
+	if x < (a+1)/(a+b+2) {
+		return front * betaContinuedFraction(x, a, b) / a
+	}
+	return 1 - front*betaContinuedFraction(1-x, b, a)/b
+}
+
+func betaContinuedFraction(x float64, a float64, b float64) float64 {
+	const epsilon, tiny = 1e-14, 1e-300
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	result := d
+	for m := 1.0; m <= 300; m++ {
+		// even and odd steps of the fraction
+		for _, numerator := range []float64{
+			m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m)),
+			-(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1)),
+		} {
+			d = 1 + numerator*d
+			if math.Abs(d) < tiny {
+				d = tiny
+			}
+			c = 1 + numerator/c
+			if math.Abs(c) < tiny {
+				c = tiny
+			}
+			d = 1 / d
+			result *= d * c
+		}
+		if math.Abs(d*c-1) < epsilon {
+			break
+		}
+	}
+	return result
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+// unbiased variance of the sample
+func variance(values []float64) float64 {
+	m := mean(values)
+	total := 0.0
+	for _, value := range values {
+		total += (value - m) * (value - m)
+	}
+	return total / float64(len(values)-1)
+}
@@ -0,0 +1,149 @@
+package compare_test
+
+import (
+	"SOMAS2023/internal/compare"
+	"SOMAS2023/internal/server"
+	"bytes"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func assertClose(t *testing.T, name string, value *float64, expected float64) {
+	t.Helper()
+	if value == nil || math.Abs(*value-expected) > 1e-6 {
+		t.Errorf("Expected %s to be %f, got %v", name, expected, value)
+	}
+}
+
+func TestWelchTest(t *testing.T) {
+	result := compare.WelchTest([]float64{1, 2, 3, 4, 5}, []float64{2, 4, 6, 8, 10})
+	assertClose(t, "t", result.Statistic, 1.8973666)
+	assertClose(t, "p", result.PValue, 0.1075312)
+	assertClose(t, "Hedges' g", result.EffectSize, 1.0838710)
+
+	// the test is symmetric
+	reversed := compare.WelchTest([]float64{2, 4, 6, 8, 10}, []float64{1, 2, 3, 4, 5})
+	assertClose(t, "p", reversed.PValue, 0.1075312)
+	assertClose(t, "t", reversed.Statistic, -1.8973666)
+
+	if result := compare.WelchTest([]float64{1}, []float64{2, 3}); result.PValue != nil {
+		t.Error("Expected no result with a single value in a sample")
+	}
+	if result := compare.WelchTest([]float64{1, 1}, []float64{2, 2}); result.PValue != nil {
+		t.Error("Expected no result for samples without variance")
+	}
+}
+
+func TestMannWhitneyTest(t *testing.T) {
+	result := compare.MannWhitneyTest([]float64{1, 2, 3, 4, 5}, []float64{2, 4, 6, 8, 10})
+	assertClose(t, "U", result.Statistic, 20)
+	assertClose(t, "p", result.PValue, 0.1412382)
+	assertClose(t, "rank-biserial r", result.EffectSize, 0.6)
+
+	// identical samples are not different at all
+	same := compare.MannWhitneyTest([]float64{1, 1, 1}, []float64{1, 1, 1})
+	assertClose(t, "p", same.PValue, 1)
+	assertClose(t, "rank-biserial r", same.EffectSize, 0)
+}
+
+// writes a game dump with an iteration for each number of survivors out of 4 agents
+func writeDump(t *testing.T, path string, survivors ...int) {
+	gameStates := make([][]server.GameStateDump, 0)
+	for _, n := range survivors {
+		ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New(), uuid.New()}
+		initial := map[uuid.UUID]server.AgentDump{}
+		final := map[uuid.UUID]server.AgentDump{}
+		for i, id := range ids {
+			initial[id] = server.AgentDump{Class: "objects.BaseBiker", EnergyLevel: 1}
+			if i < n {
+				final[id] = server.AgentDump{Class: "objects.BaseBiker", EnergyLevel: 0.5}
+			}
+		}
+		gameStates = append(gameStates, []server.GameStateDump{{Iteration: -1, Agents: initial}, {Iteration: 0, Agents: final}})
+	}
+	data, err := json.Marshal(gameStates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompareCommand(t *testing.T) {
+	dir := t.TempDir()
+	baseline := filepath.Join(dir, "baseline.json")
+	writeDump(t, baseline, 1, 1, 2, 1)
+	treatmentDir := filepath.Join(dir, "treatment")
+	if err := os.Mkdir(treatmentDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeDump(t, filepath.Join(treatmentDir, "game_dump.json"), 4, 3, 4, 4)
+	// the statistics of the same run, which is only counted once
+	data, err := os.ReadFile(filepath.Join(treatmentDir, "game_dump.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gameStates, err := server.DecodeGameDump(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err = json.Marshal(server.CalculateStatistics(gameStates)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(treatmentDir, "statistics.json"), data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// files that are neither dumps nor statistics are skipped in directories
+	if err := os.WriteFile(filepath.Join(treatmentDir, "survival.json"), []byte(`{"deaths": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	if err := compare.Run([]string{"-format", "json", baseline, "treated=" + treatmentDir}, &output); err != nil {
+		t.Fatal(err)
+	}
+	var report compare.Report
+	if err := json.Unmarshal(output.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Sets) != 2 || report.Sets[0].Name != "baseline" || report.Sets[1].Iterations != 4 || len(report.Sets[1].Files) != 1 ||
+		filepath.Base(report.Sets[1].Files[0]) != "statistics.json" {
+		t.Fatalf("Unexpected sets: %+v", report.Sets)
+	}
+	for _, comparison := range report.Comparisons {
+		if comparison.Metric != "final_survival_rate" {
+			continue
+		}
+		if comparison.Treatment != "treated" || comparison.BaselineMean != 0.3125 || comparison.TreatmentMean != 0.9375 || !comparison.Significant {
+			t.Errorf("Expected a significant increase of the survival rate, got %+v", comparison)
+		}
+		return
+	}
+	t.Error("Expected the survival rate to be compared")
+}
+
+func TestCompareMarkdown(t *testing.T) {
+	dir := t.TempDir()
+	baseline, treatment := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+	writeDump(t, baseline, 1, 2)
+	writeDump(t, treatment, 3, 4)
+
+	var output bytes.Buffer
+	if err := compare.Run([]string{"-test", "mann-whitney", baseline, treatment}, &output); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output.String(), "## b vs a") || !strings.Contains(output.String(), "Rank-biserial r") {
+		t.Errorf("Unexpected report:\n%s", output.String())
+	}
+
+	if err := compare.Run([]string{baseline}, &output); err == nil {
+		t.Error("Expected an error when comparing a single set")
+	}
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"image"
+	"math"
+)
+
+// glyphs of the bitmap font of the raster images, 5 pixels wide and 7 high, of the characters of the labels of a
+// scene: the group IDs of the agents, "Lootbox" and "owdi". Each row is a bit mask whose highest bit is the leftmost
+// pixel, and other characters are left blank
+var glyphs = map[rune][glyphHeight]uint8{
+	'0': {0b01110, 0b10001, 0b10011, 0b10101, 0b11001, 0b10001, 0b01110},
+	'1': {0b00100, 0b01100, 0b00100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'2': {0b01110, 0b10001, 0b00001, 0b00010, 0b00100, 0b01000, 0b11111},
+	'3': {0b11111, 0b00010, 0b00100, 0b00010, 0b00001, 0b10001, 0b01110},
+	'4': {0b00010, 0b00110, 0b01010, 0b10010, 0b11111, 0b00010, 0b00010},
+	'5': {0b11111, 0b10000, 0b11110, 0b00001, 0b00001, 0b10001, 0b01110},
+	'6': {0b00110, 0b01000, 0b10000, 0b11110, 0b10001, 0b10001, 0b01110},
+	'7': {0b11111, 0b00001, 0b00010, 0b00100, 0b01000, 0b01000, 0b01000},
+	'8': {0b01110, 0b10001, 0b10001, 0b01110, 0b10001, 0b10001, 0b01110},
+	'9': {0b01110, 0b10001, 0b10001, 0b01111, 0b00001, 0b00010, 0b01100},
+	'-': {0b00000, 0b00000, 0b00000, 0b11111, 0b00000, 0b00000, 0b00000},
+	'L': {0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b10000, 0b11111},
+	'b': {0b10000, 0b10000, 0b10110, 0b11001, 0b10001, 0b10001, 0b11110},
+	'd': {0b00001, 0b00001, 0b01101, 0b10011, 0b10001, 0b10001, 0b01111},
+	'i': {0b00100, 0b00000, 0b01100, 0b00100, 0b00100, 0b00100, 0b01110},
+	'o': {0b00000, 0b00000, 0b01110, 0b10001, 0b10001, 0b10001, 0b01110},
+	't': {0b01000, 0b01000, 0b11100, 0b01000, 0b01000, 0b01001, 0b00110},
+	'w': {0b00000, 0b00000, 0b10001, 0b10001, 0b10101, 0b10101, 0b01010},
+	'x': {0b00000, 0b00000, 0b10001, 0b01010, 0b00100, 0b01010, 0b10001},
+}
+
+const (
+	glyphWidth  = 5
+	glyphHeight = 7
+	// smallest size of the images in which the labels of the agents and lootboxes are drawn, below which their
+	// glyphs would be less than a pixel per dot
+	labelledSize = int(glyphHeight * utils.GridWidth * coordinateScale / agentFontSize)
+)
+
+// draws the text centred on its position, with a glyph as high as its font size in whole pixels. Text too small for
+// a glyph to have a pixel per dot is left out
+func drawText(img *image.RGBA, t text) {
+	scale := math.Floor(t.size / glyphHeight)
+	if scale < 1 {
+		return
+	}
+	characters := []rune(t.text)
+	// glyphs are a dot apart
+	width := float64(len(characters)*(glyphWidth+1)-1) * scale
+	left, top := math.Round(t.x-width/2), math.Round(t.y-glyphHeight*scale/2)
+	for i, character := range characters {
+		glyph := glyphs[character]
+		x := left + float64(i*(glyphWidth+1))*scale
+		for row, mask := range glyph {
+			for column := 0; column < glyphWidth; column++ {
+				if mask&(1<<(glyphWidth-1-column)) != 0 {
+					dotX, dotY := x+float64(column)*scale, top+float64(row)*scale
+					fillRect(img, dotX, dotY, dotX+scale, dotY+scale, t.colour)
+				}
+			}
+		}
+	}
+}
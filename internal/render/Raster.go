@@ -0,0 +1,109 @@
+package render
+
+import (
+	"SOMAS2023/internal/server"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+)
+
+// Rasterise draws the game state as an image. Raster images have no fonts, so the labels of the agents, lootboxes
+// and Audi are drawn with a bitmap font, and left out of images smaller than labelledSize in which they would be
+// too small to read (the Audi's label is larger). They are still identified by their shape and colour
+func Rasterise(gameState server.GameStateDump, options Options) *image.RGBA {
+	scene := newScene(gameState, options)
+	img := image.NewRGBA(image.Rect(0, 0, options.Size, options.Size))
+	for _, shape := range scene.shapes {
+		switch shape := shape.(type) {
+		case rect:
+			fillRect(img, shape.x, shape.y, shape.x+shape.width, shape.y+shape.height, shape.fill)
+		case circle:
+			fillCircle(img, shape.x, shape.y, shape.radius, shape.fill)
+		case line:
+			drawLine(img, shape)
+		case text:
+			drawText(img, shape)
+		}
+	}
+	return img
+}
+
+// WritePNG renders the game state as a PNG image
+func WritePNG(w io.Writer, gameState server.GameStateDump, options Options) error {
+	return png.Encode(w, Rasterise(gameState, options))
+}
+
+// WriteGIF renders the game states as the frames of an animated GIF, each shown for delay hundredths of a second
+func WriteGIF(w io.Writer, gameStates []server.GameStateDump, options Options, delay int) error {
+	animation := &gif.GIF{}
+	for _, gameState := range gameStates {
+		animation.Image = append(animation.Image, paletted(Rasterise(gameState, options)))
+		animation.Delay = append(animation.Delay, delay)
+	}
+	return gif.EncodeAll(w, animation)
+}
+
+// converts the frame to its own palette, which holds every colour of the frame unless there are more than a GIF
+// can hold, in which case the closest colours of a standard palette are used
+func paletted(img *image.RGBA) *image.Paletted {
+	colours := make(color.Palette, 0, 256)
+	seen := make(map[color.RGBA]bool)
+	for i := 0; i < len(img.Pix) && len(colours) <= 256; i += 4 {
+		colour := color.RGBA{R: img.Pix[i], G: img.Pix[i+1], B: img.Pix[i+2], A: img.Pix[i+3]}
+		if !seen[colour] {
+			seen[colour] = true
+			colours = append(colours, colour)
+		}
+	}
+	if len(colours) > 256 {
+		colours = palette.Plan9
+	}
+	result := image.NewPaletted(img.Bounds(), colours)
+	draw.Draw(result, result.Bounds(), img, image.Point{}, draw.Src)
+	return result
+}
+
+// pixels whose centre is inside the rectangle are filled, blending translucent colours
+func fillRect(img *image.RGBA, x1 float64, y1 float64, x2 float64, y2 float64, colour color.NRGBA) {
+	bounds := image.Rect(int(math.Round(x1)), int(math.Round(y1)), int(math.Round(x2)), int(math.Round(y2)))
+	draw.Draw(img, bounds.Intersect(img.Bounds()), image.NewUniform(colour), image.Point{}, draw.Over)
+}
+
+func fillCircle(img *image.RGBA, x float64, y float64, radius float64, colour color.NRGBA) {
+	bounds := image.Rect(int(math.Floor(x-radius)), int(math.Floor(y-radius)), int(math.Ceil(x+radius)), int(math.Ceil(y+radius))).
+		Intersect(img.Bounds())
+	source := image.NewUniform(colour)
+	for py := bounds.Min.Y; py < bounds.Max.Y; py++ {
+		for px := bounds.Min.X; px < bounds.Max.X; px++ {
+			dx, dy := float64(px)+0.5-x, float64(py)+0.5-y
+			if dx*dx+dy*dy <= radius*radius {
+				draw.Draw(img, image.Rect(px, py, px+1, py+1), source, image.Point{}, draw.Over)
+			}
+		}
+	}
+}
+
+// draws the line as squares of its width along it, leaving out every other stretch of a dashed line
+func drawLine(img *image.RGBA, l line) {
+	const dash, gap = 8.0, 6.0
+	length := math.Hypot(l.x2-l.x1, l.y2-l.y1)
+	half := l.width / 2
+	for distance := 0.0; distance <= length; distance += 0.5 {
+		if l.dashed && math.Mod(distance, dash+gap) >= dash {
+			continue
+		}
+		t := 0.0
+		if length > 0 {
+			t = distance / length
+		}
+		x, y := l.x1+t*(l.x2-l.x1), l.y1+t*(l.y2-l.y1)
+		bounds := image.Rect(int(math.Floor(x-half)), int(math.Floor(y-half)), int(math.Floor(x-half))+max(1, int(math.Round(l.width))),
+			int(math.Floor(y-half))+max(1, int(math.Round(l.width))))
+		draw.Draw(img, bounds.Intersect(img.Bounds()), image.NewUniform(l.colour), image.Point{}, draw.Over)
+	}
+}
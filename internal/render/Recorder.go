@@ -0,0 +1,100 @@
+package render
+
+import (
+	"SOMAS2023/internal/server"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+type Format string
+
+const (
+	SVG Format = "svg"
+	PNG Format = "png"
+	GIF Format = "gif"
+)
+
+// Recorder is a RoundObserver rendering the game into a directory while it is played: an SVG or PNG image of every
+// round, or an animated GIF of every iteration, which is written once the iteration is over or the recorder closed
+type Recorder struct {
+	dir     string
+	format  Format
+	options Options
+	// hundredths of a second each round of a GIF is shown for
+	delay int
+	// iteration being recorded and, for GIFs, its rounds
+	iteration int
+	rounds    []server.GameStateDump
+	err       error
+}
+
+func NewRecorder(dir string, format Format, options Options, delay int) (*Recorder, error) {
+	if format != SVG && format != PNG && format != GIF {
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Recorder{dir: dir, format: format, options: options, delay: delay}, nil
+}
+
+// ObserveRound renders the round, errors are kept until the recorder is closed so that the game isn't stopped
+func (r *Recorder) ObserveRound(iteration int, gameState server.GameStateDump) {
+	if r.err != nil {
+		return
+	}
+	if r.format != GIF {
+		r.err = writeFile(filepath.Join(r.dir, fmt.Sprintf("iteration_%d_round_%d.%s", iteration, gameState.Iteration, r.format)),
+			func(w io.Writer) error { return writeFrame(w, r.format, gameState, r.options) })
+		return
+	}
+	if iteration != r.iteration {
+		r.err = r.writeIteration()
+		r.iteration, r.rounds = iteration, nil
+	}
+	r.rounds = append(r.rounds, gameState)
+}
+
+// Close writes the GIF of the last iteration, and returns the first error met while recording
+func (r *Recorder) Close() error {
+	if r.err == nil && r.format == GIF {
+		r.err = r.writeIteration()
+		r.rounds = nil
+	}
+	return r.err
+}
+
+func (r *Recorder) writeIteration() error {
+	if len(r.rounds) == 0 {
+		return nil
+	}
+	return writeFile(filepath.Join(r.dir, fmt.Sprintf("iteration_%d.gif", r.iteration)),
+		func(w io.Writer) error { return WriteGIF(w, r.rounds, r.options, r.delay) })
+}
+
+func writeFrame(w io.Writer, format Format, gameState server.GameStateDump, options Options) error {
+	switch format {
+	case SVG:
+		return WriteSVG(w, gameState, options)
+	case PNG:
+		return WritePNG(w, gameState, options)
+	case GIF:
+		return WriteGIF(w, []server.GameStateDump{gameState}, options, 0)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
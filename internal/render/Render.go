@@ -0,0 +1,151 @@
+package render
+
+import (
+	"SOMAS2023/internal/server"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Run is the render command: it renders rounds of a game dump as SVG or PNG images or as an animated GIF, or plays a
+// game with play and renders all of it while it is played
+func Run(args []string, stdout io.Writer, play func(observers ...server.RoundObserver)) error {
+	flags := flag.NewFlagSet("render", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	dump := flags.String("dump", "game_dump.json", "game dump to render")
+	iteration := flags.Int("iteration", 0, "iteration of the game dump to render")
+	rounds := flags.String("rounds", "", "round or range of rounds to render as from:to, all of them when empty (-1 is the initial state)")
+	format := flags.String("format", "", "svg, png or gif, from the extension of the output when empty, png otherwise")
+	output := flags.String("o", "", "file to write a single image or GIF to (render.<format> by default), or directory to write "+
+		"several images or the rendering of a game played to (frames by default)")
+	size := flags.Int("size", DefaultOptions.Size, fmt.Sprintf("width and height of the images in pixels, PNG images and GIFs "+
+		"only have the labels of the agents and lootboxes from a size of %d", labelledSize))
+	delay := flags.Int("delay", 10, "hundredths of a second each round of a GIF is shown for")
+	playGame := flags.Bool("play", false, "play a game and render every round of it, or every iteration as a GIF, instead of reading a dump")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: render [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("render takes no arguments")
+	}
+	if *size <= 0 {
+		return fmt.Errorf("invalid size %d", *size)
+	}
+	options := Options{Size: *size}
+	imageFormat := Format(*format)
+	if imageFormat == "" {
+		imageFormat = PNG
+		if extension := Format(strings.TrimPrefix(filepath.Ext(*output), ".")); extension == SVG || extension == GIF {
+			imageFormat = extension
+		}
+	}
+
+	if *playGame {
+		dir := *output
+		if dir == "" {
+			dir = "frames"
+		}
+		recorder, err := NewRecorder(dir, imageFormat, options, *delay)
+		if err != nil {
+			return err
+		}
+		play(recorder)
+		if err := recorder.Close(); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "Rendered the game in %s\n", dir)
+		return nil
+	}
+
+	gameStates, err := loadRounds(*dump, *iteration, *rounds)
+	if err != nil {
+		return err
+	}
+	if imageFormat == GIF || len(gameStates) == 1 {
+		path := *output
+		if path == "" {
+			path = "render." + string(imageFormat)
+		}
+		err = writeFile(path, func(w io.Writer) error {
+			if imageFormat == GIF {
+				return WriteGIF(w, gameStates, options, *delay)
+			}
+			return writeFrame(w, imageFormat, gameStates[0], options)
+		})
+		if err == nil {
+			fmt.Fprintf(stdout, "Rendered %d rounds in %s\n", len(gameStates), path)
+		}
+		return err
+	}
+
+	dir := *output
+	if dir == "" {
+		dir = "frames"
+	}
+	recorder, err := NewRecorder(dir, imageFormat, options, *delay)
+	if err != nil {
+		return err
+	}
+	for _, gameState := range gameStates {
+		recorder.ObserveRound(*iteration, gameState)
+	}
+	if err := recorder.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Rendered %d rounds in %s\n", len(gameStates), dir)
+	return nil
+}
+
+// reads the rounds of the iteration of the dump in the range given as round or from:to, where either end can be left out
+func loadRounds(path string, iteration int, rounds string) ([]server.GameStateDump, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	gameStates, err := server.DecodeGameDump(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if iteration < 0 || iteration >= len(gameStates) {
+		return nil, fmt.Errorf("%s has no iteration %d", path, iteration)
+	}
+
+	from, to := math.MinInt, math.MaxInt
+	if rounds != "" {
+		first, last, isRange := strings.Cut(rounds, ":")
+		if !isRange {
+			last = first
+		}
+		if first != "" {
+			if from, err = strconv.Atoi(first); err != nil {
+				return nil, fmt.Errorf("invalid rounds %q", rounds)
+			}
+		}
+		if last != "" {
+			if to, err = strconv.Atoi(last); err != nil {
+				return nil, fmt.Errorf("invalid rounds %q", rounds)
+			}
+		}
+	}
+	selected := make([]server.GameStateDump, 0)
+	for _, gameState := range gameStates[iteration] {
+		if gameState.Iteration >= from && gameState.Iteration <= to {
+			selected = append(selected, gameState)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, fmt.Errorf("iteration %d of %s has no rounds %s", iteration, path, rounds)
+	}
+	return selected, nil
+}
@@ -0,0 +1,61 @@
+package render
+
+import (
+	"SOMAS2023/internal/server"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WriteSVG renders the game state as an SVG image
+func WriteSVG(w io.Writer, gameState server.GameStateDump, options Options) error {
+	scene := newScene(gameState, options)
+	writer := bufio.NewWriter(w)
+	fmt.Fprintf(writer, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		options.Size, options.Size, options.Size, options.Size)
+	for _, shape := range scene.shapes {
+		switch shape := shape.(type) {
+		case rect:
+			fmt.Fprintf(writer, `<rect x="%s" y="%s" width="%s" height="%s"%s/>`+"\n",
+				number(shape.x), number(shape.y), number(shape.width), number(shape.height), fill(shape.fill))
+		case circle:
+			fmt.Fprintf(writer, `<circle cx="%s" cy="%s" r="%s"%s/>`+"\n",
+				number(shape.x), number(shape.y), number(shape.radius), fill(shape.fill))
+		case line:
+			dash := ""
+			if shape.dashed {
+				dash = ` stroke-dasharray="8 6"`
+			}
+			fmt.Fprintf(writer, `<line x1="%s" y1="%s" x2="%s" y2="%s" stroke="%s" stroke-width="%s"%s/>`+"\n",
+				number(shape.x1), number(shape.y1), number(shape.x2), number(shape.y2), rgb(shape.colour), number(shape.width), dash)
+		case text:
+			var escaped strings.Builder
+			if err := xml.EscapeText(&escaped, []byte(shape.text)); err != nil {
+				return err
+			}
+			fmt.Fprintf(writer, `<text x="%s" y="%s" font-family="Arial" font-size="%s" fill="%s" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n",
+				number(shape.x), number(shape.y), number(shape.size), rgb(shape.colour), escaped.String())
+		}
+	}
+	fmt.Fprintln(writer, "</svg>")
+	return writer.Flush()
+}
+
+func number(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func rgb(colour color.NRGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", colour.R, colour.G, colour.B)
+}
+
+func fill(colour color.NRGBA) string {
+	if colour.A == 255 {
+		return fmt.Sprintf(` fill="%s"`, rgb(colour))
+	}
+	return fmt.Sprintf(` fill="%s" fill-opacity="%s"`, rgb(colour), number(float64(colour.A)/255))
+}
@@ -0,0 +1,284 @@
+package render
+
+import (
+	"SOMAS2023/internal/common/terrain"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	"cmp"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// visual encoding of visualiser/util/Constants.py, shared with the web viewer
+const (
+	coordinateScale = 20
+	gridSpacing     = 20
+	// transparency of the bikes, out of 255
+	bikeTransparency = 150
+	bikeLineWidth    = 1
+	agentSize        = 10
+	agentLineWidth   = 2
+	agentFontSize    = 20
+	agentPadding     = 2
+	lootboxWidth     = 120
+	lootboxHeight    = 60
+	lootboxLineWidth = 2
+	lootboxFontSize  = 20
+	audiSize         = 140
+	audiLineWidth    = 2
+	audiFontSize     = 30
+	energyBarHeight  = 4
+	// transparency of the rough zones and slopes, out of 255, so that overlapping ones are told apart
+	terrainTransparency = 70
+	slopeLineWidth      = 4
+)
+
+var (
+	background = hex(0xF0F0F0)
+	gridColour = hex(0xE5E5E5)
+	black      = hex(0x000000)
+	white      = hex(0xFFFFFF)
+	audiColour = hex(0x0F0F0F)
+	// obstacles are opaque, rough zones and slopes are drawn with terrainTransparency
+	obstacleColour = hex(0x8C8C8C)
+	roughColour    = hex(0xAC6223)
+	slopeColour    = hex(0x5E82FD)
+	colours        = map[string]color.NRGBA{
+		"red":    hex(0xE05558),
+		"orange": hex(0xD57901),
+		"yellow": hex(0xD5C801),
+		"green":  hex(0x7BBD01),
+		"blue":   hex(0x5E82FD),
+		"purple": hex(0xA575ED),
+		"pink":   hex(0xDE82C3),
+		"brown":  hex(0xAC6223),
+		"gray":   hex(0x666666),
+		"white":  white,
+	}
+	governanceColours = map[utils.Governance]color.NRGBA{
+		utils.Democracy:    colours["blue"],
+		utils.Leadership:   colours["green"],
+		utils.Dictatorship: colours["red"],
+		utils.Invalid:      black,
+	}
+	energyFull  = colours["green"]
+	energyEmpty = colours["red"]
+)
+
+func hex(rgb uint32) color.NRGBA {
+	return color.NRGBA{R: uint8(rgb >> 16), G: uint8(rgb >> 8), B: uint8(rgb), A: 255}
+}
+
+func colourOf(name string) color.NRGBA {
+	if colour, ok := colours[name]; ok {
+		return colour
+	}
+	return black
+}
+
+// colour of the text drawn over a background of the given colour
+func textColour(background color.NRGBA) color.NRGBA {
+	if background == white {
+		return black
+	}
+	return white
+}
+
+// the shapes a frame is made of, in image coordinates, which are drawn in order by every format
+type rect struct {
+	x, y, width, height float64
+	fill                color.NRGBA
+}
+
+type circle struct {
+	x, y, radius float64
+	fill         color.NRGBA
+}
+
+type line struct {
+	x1, y1, x2, y2, width float64
+	colour                color.NRGBA
+	dashed                bool
+}
+
+type text struct {
+	x, y, size float64
+	text       string
+	colour     color.NRGBA
+}
+
+// Options of the frames rendered
+type Options struct {
+	// width and height of the frames in pixels, which show the whole grid
+	Size int
+}
+
+var DefaultOptions = Options{Size: 1000}
+
+// number of pixels of the frame for each pixel of the visualiser at zoom 1
+func (o Options) zoom() float64 {
+	return float64(o.Size) / (utils.GridWidth * coordinateScale)
+}
+
+type scene struct {
+	zoom   float64
+	shapes []any
+}
+
+func (s *scene) toImage(position utils.Coordinates) (float64, float64) {
+	return position.X * coordinateScale * s.zoom, position.Y * coordinateScale * s.zoom
+}
+
+// sorted keys of a map, so that frames of the same game state are identical
+func sortedIDs[T any](values map[uuid.UUID]T) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(values))
+	for id := range values {
+		ids = append(ids, id)
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return cmp.Compare(a.String(), b.String()) })
+	return ids
+}
+
+// newScene lays out the game state as the visualiser draws it: the terrain, the lootboxes, then the bikes with their
+// agents, the agents that aren't on a bike and the Audi with a line to the bike it is chasing. Outlines are at least a
+// pixel wide so that they are still seen in small images
+func newScene(gameState server.GameStateDump, options Options) *scene {
+	s := &scene{zoom: options.zoom()}
+	size := float64(options.Size)
+	s.shapes = append(s.shapes, rect{0, 0, size, size, background})
+	spacing := gridSpacing * coordinateScale * s.zoom
+	for offset := spacing; offset < size; offset += spacing {
+		s.shapes = append(s.shapes, line{offset, 0, offset, size, 1, gridColour, false}, line{0, offset, size, offset, 1, gridColour, false})
+	}
+	if terrainMap := gameState.GetTerrain(); terrainMap != nil {
+		s.addTerrain(terrainMap)
+	}
+
+	for _, id := range sortedIDs(gameState.LootBoxes) {
+		s.addLootbox(gameState.LootBoxes[id])
+	}
+	for _, id := range sortedIDs(gameState.Bikes) {
+		s.addBike(gameState.Bikes[id], gameState.Agents)
+	}
+	for _, id := range sortedIDs(gameState.Agents) {
+		agent := gameState.Agents[id]
+		if _, onBike := gameState.Bikes[agent.BikeID]; !agent.OnBike || !onBike {
+			x, y := s.toImage(agent.Location)
+			s.addAgent(agent, x, y)
+		}
+	}
+	for _, audi := range gameState.Audis {
+		s.addAudi(audi, gameState.Bikes)
+	}
+	return s
+}
+
+// rough zones and slopes are translucent, and slopes have a line from their centre pointing downhill. Obstacles are
+// drawn last as nothing goes through them
+func (s *scene) addTerrain(terrainMap terrain.ITerrain) {
+	region := func(r terrain.Region, colour color.NRGBA) (rect, float64, float64) {
+		x1, y1 := s.toImage(r.Min)
+		x2, y2 := s.toImage(r.Max)
+		return rect{x1, y1, x2 - x1, y2 - y1, colour}, (x1 + x2) / 2, (y1 + y2) / 2
+	}
+	rough, slope := roughColour, slopeColour
+	rough.A, slope.A = terrainTransparency, terrainTransparency
+	for _, zone := range terrainMap.GetRoughZones() {
+		area, _, _ := region(zone.Region, rough)
+		s.shapes = append(s.shapes, area)
+	}
+	for _, zone := range terrainMap.GetSlopes() {
+		area, x, y := region(zone.Region, slope)
+		length := math.Min(area.width, area.height) / 2
+		angle := zone.Downhill * math.Pi
+		s.shapes = append(s.shapes, area,
+			line{x, y, x + length*math.Cos(angle), y + length*math.Sin(angle), math.Max(slopeLineWidth*s.zoom, 1), slopeColour, false})
+	}
+	for _, obstacle := range terrainMap.GetObstacles() {
+		area, _, _ := region(obstacle, obstacleColour)
+		s.shapes = append(s.shapes, area)
+	}
+}
+
+func (s *scene) addLootbox(lootbox server.LootBoxDump) {
+	x, y := s.toImage(lootbox.PhysicalState.Position)
+	width, height, border := lootboxWidth*s.zoom, lootboxHeight*s.zoom, math.Max(lootboxLineWidth*s.zoom, 1)
+	colour := colourOf(lootbox.ColourString)
+	s.shapes = append(s.shapes,
+		rect{x - width/2 - border, y - height/2 - border, width + 2*border, height + 2*border, black},
+		rect{x - width/2, y - height/2, width, height, colour},
+		text{x, y, lootboxFontSize * s.zoom, "Lootbox", textColour(colour)},
+	)
+}
+
+// the agent is a circle of its colour with its group ID, and its energy level is shown by a bar under it
+func (s *scene) addAgent(agent server.AgentDump, x float64, y float64) {
+	radius := agentSize * s.zoom
+	colour := colourOf(agent.ColourString)
+	groupID := "-"
+	if agent.GroupID != 0 {
+		groupID = strconv.Itoa(agent.GroupID)
+	}
+	energy := math.Max(0, math.Min(1, agent.EnergyLevel))
+	barY, barHeight := y+radius+agentLineWidth*s.zoom, math.Max(energyBarHeight*s.zoom, 1)
+	s.shapes = append(s.shapes,
+		circle{x, y, radius + math.Max(agentLineWidth*s.zoom, 1), black},
+		circle{x, y, radius, colour},
+		text{x, y, agentFontSize * s.zoom, groupID, textColour(colour)},
+		rect{x - radius, barY, 2 * radius, barHeight, energyEmpty},
+		rect{x - radius, barY, 2 * radius * energy, barHeight, energyFull},
+	)
+}
+
+// the bike is a translucent square of the colour of its governance holding its agents in a grid of at most 3 columns
+func (s *scene) addBike(bike server.BikeDump, agents map[uuid.UUID]server.AgentDump) {
+	riders := make([]server.AgentDump, 0, len(bike.AgentIDs))
+	for _, id := range bike.AgentIDs {
+		if agent, ok := agents[id]; ok {
+			riders = append(riders, agent)
+		}
+	}
+	gridSize := min(3, max(1, int(math.Ceil(math.Sqrt(float64(len(riders)))))))
+	rows := max(1, (len(riders)+gridSize-1)/gridSize)
+	padding, cell := agentPadding*s.zoom, (agentSize+agentLineWidth)*2*s.zoom
+	side := float64(gridSize)*cell + float64(gridSize+1)*padding
+	height := math.Max(side, float64(rows)*cell+float64(rows+1)*padding)
+	centreX, centreY := s.toImage(bike.PhysicalState.Position)
+	x, y, border := centreX-side/2, centreY-side/2, math.Max(bikeLineWidth*s.zoom, 1)
+
+	outline, fill := black, black
+	if colour, ok := governanceColours[bike.Governance]; ok {
+		fill = colour
+	}
+	outline.A, fill.A = bikeTransparency, bikeTransparency
+	// the outline surrounds the square without overlapping it, as both are translucent
+	s.shapes = append(s.shapes,
+		rect{x, y, side, height, fill},
+		rect{x - border, y - border, side + 2*border, border, outline},
+		rect{x - border, y + height, side + 2*border, border, outline},
+		rect{x - border, y, border, height, outline},
+		rect{x + side, y, border, height, outline},
+	)
+	for i, agent := range riders {
+		row, column := float64(i/gridSize), float64(i%gridSize)
+		s.addAgent(agent, x+cell/2+cell*column+padding*(column+1), y+cell/2+cell*row+padding*(row+1))
+	}
+}
+
+func (s *scene) addAudi(audi server.AudiDump, bikes map[uuid.UUID]server.BikeDump) {
+	x, y := s.toImage(audi.PhysicalState.Position)
+	size, border := audiSize*s.zoom, math.Max(audiLineWidth*s.zoom, 1)
+	if target, ok := bikes[audi.TargetBike]; ok {
+		targetX, targetY := s.toImage(target.PhysicalState.Position)
+		s.shapes = append(s.shapes, line{x, y, targetX, targetY, math.Max(2*audiLineWidth*s.zoom, 1), audiColour, true})
+	}
+	s.shapes = append(s.shapes,
+		rect{x - size/2 - border, y - size/2 - border, size + 2*border, size + 2*border, black},
+		rect{x - size/2, y - size/2, size, size, audiColour},
+		text{x, y, audiFontSize * s.zoom, "owdi", white},
+	)
+}
@@ -0,0 +1,294 @@
+package render_test
+
+import (
+	"SOMAS2023/internal/common/terrain"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/render"
+	"SOMAS2023/internal/server"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"image/color"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+var options = render.Options{Size: 500}
+
+// a game state with a bike of two agents chased by the Audi and a red lootbox, at the positions of the image given
+func gameState() server.GameStateDump {
+	bikeID, lootboxID := uuid.New(), uuid.New()
+	// the image shows the whole grid, so a pixel is half a unit of the grid
+	at := func(x float64, y float64) utils.PhysicalState {
+		return utils.PhysicalState{Position: utils.Coordinates{X: x / 2, Y: y / 2}}
+	}
+	agents := make(map[uuid.UUID]server.AgentDump)
+	for _, colour := range []string{"green", "white"} {
+		id := uuid.New()
+		agents[id] = server.AgentDump{ColourString: colour, EnergyLevel: 0.5, OnBike: true, BikeID: bikeID}
+	}
+	bike := server.BikeDump{Governance: utils.Democracy, AgentIDs: make([]uuid.UUID, 0)}
+	bike.PhysicalState = at(100, 100)
+	for id := range agents {
+		bike.AgentIDs = append(bike.AgentIDs, id)
+	}
+	lootbox := server.LootBoxDump{ColourString: "red"}
+	lootbox.PhysicalState = at(300, 300)
+	audi := server.AudiDump{TargetBike: bikeID}
+	audi.PhysicalState = at(400, 100)
+	return server.GameStateDump{
+		Iteration: 3,
+		Agents:    agents,
+		Bikes:     map[uuid.UUID]server.BikeDump{bikeID: bike},
+		LootBoxes: map[uuid.UUID]server.LootBoxDump{lootboxID: lootbox},
+		Audis:     []server.AudiDump{audi},
+	}
+}
+
+func TestSVG(t *testing.T) {
+	var buffer bytes.Buffer
+	if err := render.WriteSVG(&buffer, gameState(), options); err != nil {
+		t.Fatal(err)
+	}
+	svg := buffer.String()
+	for _, element := range []string{
+		`width="500" height="500"`,
+		`fill="#5E82FD" fill-opacity="0.5882352941176471"`, // democracy
+		`fill="#E05558"`,                                   // red lootbox
+		`stroke-dasharray="8 6"`,                           // the Audi's target
+		`fill="#000000" text-anchor`,                       // label of the white agent
+		`>Lootbox</text>`, `>owdi</text>`, `>-</text>`,
+	} {
+		if !strings.Contains(svg, element) {
+			t.Errorf("Expected the SVG to contain %s", element)
+		}
+	}
+	decoder := xml.NewDecoder(&buffer)
+	for {
+		if _, err := decoder.Token(); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Expected the SVG to be valid XML: %v", err)
+		}
+	}
+}
+
+func TestRasterise(t *testing.T) {
+	img := render.Rasterise(gameState(), options)
+	colourAt := func(x int, y int) color.RGBA {
+		return img.RGBAAt(x, y)
+	}
+	if background := colourAt(5, 5); background != (color.RGBA{0xF0, 0xF0, 0xF0, 0xFF}) {
+		t.Errorf("Expected the background, got %v", background)
+	}
+	if lootbox := colourAt(300, 300); lootbox != (color.RGBA{0xE0, 0x55, 0x58, 0xFF}) {
+		t.Errorf("Expected the red lootbox, got %v", lootbox)
+	}
+	if audi := colourAt(400, 100); audi != (color.RGBA{0x0F, 0x0F, 0x0F, 0xFF}) {
+		t.Errorf("Expected the Audi, got %v", audi)
+	}
+	// the bike is translucent blue, under its agents which fill its first row
+	if bike := colourAt(101, 101); bike.B <= bike.R || bike.R == 0x5E || bike.R == 0xF0 {
+		t.Errorf("Expected the bike to be blended with the background, got %v", bike)
+	}
+	// the line to the target is dashed
+	onLine := 0
+	for x := 110; x < 380; x++ {
+		if colourAt(x, 99) == (color.RGBA{0x0F, 0x0F, 0x0F, 0xFF}) || colourAt(x, 100) == (color.RGBA{0x0F, 0x0F, 0x0F, 0xFF}) {
+			onLine++
+		}
+	}
+	if onLine == 0 || onLine == 270 {
+		t.Errorf("Expected a dashed line to the target, got %d pixels of 270", onLine)
+	}
+
+	// frames of the same game state are identical, whatever the order of its maps
+	state := gameState()
+	if !bytes.Equal(render.Rasterise(state, options).Pix, render.Rasterise(state, options).Pix) {
+		t.Error("Expected rendering to be deterministic")
+	}
+}
+
+func TestLabels(t *testing.T) {
+	white := color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+	// white pixels of the red lootbox, which is 48 by 24 pixels in images of 2000 pixels
+	countWhite := func(size int) int {
+		img := render.Rasterise(gameState(), render.Options{Size: size})
+		centre, halfWidth, halfHeight := size*300/500, size*24/2000, size*12/2000
+		count := 0
+		for y := centre - halfHeight; y < centre+halfHeight; y++ {
+			for x := centre - halfWidth; x < centre+halfWidth; x++ {
+				if img.RGBAAt(x, y) == white {
+					count++
+				}
+			}
+		}
+		return count
+	}
+	// the labels are too small to be drawn in the images of the other tests
+	if count := countWhite(500); count != 0 {
+		t.Errorf("Expected no label in small images, got %d white pixels", count)
+	}
+	// "Lootbox" has 83 dots of a pixel
+	if count := countWhite(2000); count != 83 {
+		t.Errorf("Expected the label of the lootbox, got %d white pixels", count)
+	}
+}
+
+func TestTerrain(t *testing.T) {
+	state := gameState()
+	// a pixel is half a unit of the grid
+	state.Terrain = &terrain.Terrain{
+		Obstacles:  []terrain.Region{{Min: utils.Coordinates{X: 10, Y: 200}, Max: utils.Coordinates{X: 20, Y: 220}}},
+		RoughZones: []terrain.RoughZone{{Region: terrain.Region{Min: utils.Coordinates{X: 100, Y: 200}, Max: utils.Coordinates{X: 120, Y: 220}}, DragMultiplier: 2}},
+	}
+	img := render.Rasterise(state, options)
+	if obstacle := img.RGBAAt(30, 420); obstacle != (color.RGBA{0x8C, 0x8C, 0x8C, 0xFF}) {
+		t.Errorf("Expected the obstacle, got %v", obstacle)
+	}
+	if rough := img.RGBAAt(220, 420); rough.R <= rough.B || rough == (color.RGBA{0xF0, 0xF0, 0xF0, 0xFF}) {
+		t.Errorf("Expected the rough zone to be blended with the background, got %v", rough)
+	}
+
+	var buffer bytes.Buffer
+	if err := render.WriteSVG(&buffer, state, options); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buffer.String(), `<rect x="20" y="400" width="20" height="40" fill="#8C8C8C"/>`) {
+		t.Error("Expected the SVG to have the obstacle")
+	}
+}
+
+func TestGIF(t *testing.T) {
+	var buffer bytes.Buffer
+	states := []server.GameStateDump{gameState(), gameState(), {Iteration: 4}}
+	if err := render.WriteGIF(&buffer, states, options, 20); err != nil {
+		t.Fatal(err)
+	}
+	animation, err := gif.DecodeAll(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(animation.Image) != 3 || !slices.Equal(animation.Delay, []int{20, 20, 20}) {
+		t.Errorf("Expected 3 frames of 20, got %d frames of %v", len(animation.Image), animation.Delay)
+	}
+	// the frames keep their exact colours
+	if r, g, b, _ := animation.Image[0].At(300, 300).RGBA(); r>>8 != 0xE0 || g>>8 != 0x55 || b>>8 != 0x58 {
+		t.Errorf("Expected the red lootbox in the GIF, got %x %x %x", r>>8, g>>8, b>>8)
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	dir := t.TempDir()
+	recorder, err := render.NewRecorder(dir, render.PNG, options, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder.ObserveRound(0, server.GameStateDump{Iteration: -1})
+	recorder.ObserveRound(0, gameState())
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"iteration_0_round_-1.png", "iteration_0_round_3.png"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to be rendered", name)
+		}
+	}
+
+	// GIFs are written at the end of every iteration
+	recorder, err = render.NewRecorder(dir, render.GIF, options, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for iteration := 0; iteration < 2; iteration++ {
+		recorder.ObserveRound(iteration, server.GameStateDump{Iteration: -1})
+		recorder.ObserveRound(iteration, gameState())
+	}
+	if _, err := os.Stat(filepath.Join(dir, "iteration_1.gif")); err == nil {
+		t.Error("Expected the last iteration to be written when the recorder is closed")
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"iteration_0.gif", "iteration_1.gif"} {
+		file, err := os.Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected %s to be rendered", name)
+		}
+		animation, err := gif.DecodeAll(file)
+		file.Close()
+		if err != nil || len(animation.Image) != 2 {
+			t.Errorf("Expected %s to have a frame for each round, got %v", name, err)
+		}
+	}
+
+	if _, err := render.NewRecorder(dir, "bmp", options, 10); err == nil {
+		t.Error("Expected unknown formats to be rejected")
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	dump := filepath.Join(dir, "game_dump.json")
+	rounds := []server.GameStateDump{{Iteration: -1}, gameState(), {Iteration: 4}}
+	rounds[1].Iteration = 0
+	data, err := json.Marshal([][]server.GameStateDump{rounds})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dump, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	noGame := func(...server.RoundObserver) { t.Error("Expected no game to be played") }
+
+	frames := filepath.Join(dir, "frames")
+	if err := render.Run([]string{"-dump", dump, "-rounds", "-1:0", "-format", "svg", "-o", frames}, io.Discard, noGame); err != nil {
+		t.Fatal(err)
+	}
+	if entries, _ := os.ReadDir(frames); len(entries) != 2 {
+		t.Errorf("Expected 2 frames, got %d", len(entries))
+	}
+	animation := filepath.Join(dir, "game.gif")
+	if err := render.Run([]string{"-dump", dump, "-o", animation}, io.Discard, noGame); err != nil {
+		t.Fatal(err)
+	}
+	if file, err := os.Open(animation); err != nil {
+		t.Error("Expected the GIF to be rendered")
+	} else {
+		if decoded, err := gif.DecodeAll(file); err != nil || len(decoded.Image) != 3 {
+			t.Errorf("Expected every round in the GIF, got %v", err)
+		}
+		file.Close()
+	}
+
+	for _, args := range [][]string{
+		{"-dump", dump, "-rounds", "7"},
+		{"-dump", dump, "-rounds", "a:b"},
+		{"-dump", dump, "-iteration", "1"},
+		{"-dump", filepath.Join(dir, "missing.json")},
+	} {
+		if err := render.Run(args, io.Discard, noGame); err == nil {
+			t.Errorf("Expected %v to fail", args)
+		}
+	}
+
+	played := filepath.Join(dir, "played")
+	err = render.Run([]string{"-play", "-o", played}, io.Discard, func(observers ...server.RoundObserver) {
+		for _, observer := range observers {
+			observer.ObserveRound(0, gameState())
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(played, "iteration_0_round_3.png")); err != nil {
+		t.Error("Expected the game played to be rendered")
+	}
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// AggregateStatistics are the outcomes of a group of agents (a team or a governance type): the value of every statistic is
+// computed for each iteration in which the group had agents, and summarised across iterations with a 95% confidence interval
+type AggregateStatistics struct {
+	Iterations  int                `json:"iterations"` // number of iterations in which the group had agents
+	Lifetime    ConfidenceInterval `json:"lifetime"`
+	FinalPoints ConfidenceInterval `json:"final_points"` // points of the agents in the last round they were alive
+	FinalEnergy ConfidenceInterval `json:"final_energy"` // energy of the agents in the last round they were alive
+	WinRate     ConfidenceInterval `json:"win_rate"`     // fraction of the iterations won by an agent of the group
+}
+
+// ConfidenceInterval is the mean of a sample with its 95% confidence interval, from the t-distribution.
+// With a single value the interval collapses to the mean
+type ConfidenceInterval struct {
+	Mean  float64 `json:"mean"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+// the team of an agent is its group ID set by the spawner (e.g. "team3"), as the package of its class isn't always
+// named after the team. Base bikers are the "base" team
+func teamName(agent AgentDump) string {
+	if agent.GroupID == 0 {
+		return "base"
+	}
+	return fmt.Sprintf("team%d", agent.GroupID)
+}
+
+func agentTeam(agent *agentAccumulator) (string, bool) {
+	return agent.team, true
+}
+
+// the governance of an agent in an iteration is the governance of the bike it rode in the most rounds,
+// agents that never rode a bike have none
+func agentGovernance(agent *agentAccumulator) (string, bool) {
+	result, most := "", 0
+	for governance, n := range agent.governances {
+		if n > most || (n == most && governance < result) {
+			result = governance
+			most = n
+		}
+	}
+	return result, most > 0
+}
+
+// the winners of a round are the agents alive at the end of it with the most points, appended to winners
+func roundWinners(gameState GameStateDump, winners []uuid.UUID) []uuid.UUID {
+	mostPoints := math.MinInt
+	for id, agent := range gameState.Agents {
+		if agent.Points > mostPoints {
+			winners = winners[:0]
+			mostPoints = agent.Points
+		}
+		if agent.Points == mostPoints {
+			winners = append(winners, id)
+		}
+	}
+	return winners
+}
+
+// aggregates the outcomes of the agents of every iteration over the groups given by groupOf, agents without a group are left out.
+// The winners of an iteration are the winners of its last round
+func aggregateStatistics(iterations []*iterationAccumulator, groupOf func(agent *agentAccumulator) (string, bool)) map[string]AggregateStatistics {
+	lifetime := make(map[string][]float64)
+	finalPoints := make(map[string][]float64)
+	finalEnergy := make(map[string][]float64)
+	wins := make(map[string][]float64)
+
+	for _, iteration := range iterations {
+		members := make(map[string][]*agentAccumulator)
+		for _, agent := range iteration.agents {
+			if group, ok := groupOf(agent); ok {
+				members[group] = append(members[group], agent)
+			}
+		}
+		winners := make(map[string]bool)
+		for _, id := range iteration.winners {
+			if group, ok := groupOf(iteration.agents[id]); ok {
+				winners[group] = true
+			}
+		}
+
+		for group, agents := range members {
+			groupLifetime, groupPoints, groupEnergy := 0.0, 0.0, 0.0
+			for _, agent := range agents {
+				groupLifetime += float64(agent.lifetime)
+				groupPoints += float64(agent.finalPoints)
+				groupEnergy += agent.finalEnergy
+			}
+			n := float64(len(agents))
+			lifetime[group] = append(lifetime[group], groupLifetime/n)
+			finalPoints[group] = append(finalPoints[group], groupPoints/n)
+			finalEnergy[group] = append(finalEnergy[group], groupEnergy/n)
+			if winners[group] {
+				wins[group] = append(wins[group], 1)
+			} else {
+				wins[group] = append(wins[group], 0)
+			}
+		}
+	}
+
+	result := make(map[string]AggregateStatistics, len(lifetime))
+	for group := range lifetime {
+		result[group] = AggregateStatistics{
+			Iterations:  len(lifetime[group]),
+			Lifetime:    confidenceInterval(lifetime[group]),
+			FinalPoints: confidenceInterval(finalPoints[group]),
+			FinalEnergy: confidenceInterval(finalEnergy[group]),
+			WinRate:     confidenceInterval(wins[group]),
+		}
+	}
+	return result
+}
+
+func confidenceInterval(sample []float64) ConfidenceInterval {
+	m := mean(sample)
+	if len(sample) < 2 {
+		return ConfidenceInterval{Mean: m, Lower: m, Upper: m}
+	}
+	halfWidth := tCritical(len(sample)-1) * math.Sqrt(sampleVariance(sample)/float64(len(sample)))
+	return ConfidenceInterval{Mean: m, Lower: m - halfWidth, Upper: m + halfWidth}
+}
+
+// unbiased variance of the sample
+func sampleVariance(sample []float64) float64 {
+	m := mean(sample)
+	sum := 0.0
+	for _, value := range sample {
+		sum += (value - m) * (value - m)
+	}
+	return sum / float64(len(sample)-1)
+}
+
+// two-sided 95% critical values of the t-distribution for 1 to 30 degrees of freedom
+var tCriticalValues = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+func tCritical(degreesOfFreedom int) float64 {
+	if degreesOfFreedom > len(tCriticalValues) {
+		// close enough to the normal distribution
+		return 1.960
+	}
+	return tCriticalValues[degreesOfFreedom-1]
+}
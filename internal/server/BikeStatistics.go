@@ -0,0 +1,181 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/physics"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// BikeRound is the state of a bike at the end of a round, and what happened to it during the round
+type BikeRound struct {
+	Round       int       `json:"round"`
+	Occupancy   int       `json:"occupancy"`
+	Governance  string    `json:"governance"`
+	Ruler       uuid.UUID `json:"ruler"`
+	RulerTenure int       `json:"ruler_tenure"` // consecutive rounds the ruler has ruled the bike for, 0 without a ruler
+	Distance    float64   `json:"distance"`     // distance travelled since the previous round
+	BikeEvents
+}
+
+// InstitutionStatistics describe how long the bikes of a governance type lasted and how their membership changed.
+// An institution is a run of consecutive rounds in which a bike had riders and kept the same governance
+type InstitutionStatistics struct {
+	Institutions    int     `json:"institutions"`
+	MeanLifespan    float64 `json:"mean_lifespan"` // rounds
+	RulerTenures    int     `json:"ruler_tenures"`
+	MeanRulerTenure float64 `json:"mean_ruler_tenure"` // rounds
+	BikeRounds      int     `json:"bike_rounds"`
+	RiderRounds     int     `json:"rider_rounds"`
+	Joins           int     `json:"joins"`
+	Leaves          int     `json:"leaves"`
+	Kickouts        int     `json:"kickouts"`
+	// joins, leaves and kickouts per rider per round
+	Churn float64 `json:"churn"`
+	// per bike per round
+	LootBoxRate     float64 `json:"loot_box_rate"`
+	ColourPointRate float64 `json:"colour_point_rate"`
+	AudiKillRate    float64 `json:"audi_kill_rate"`
+	DistanceRate    float64 `json:"distance_rate"`
+}
+
+// the time series of every bike of an iteration, from the first round to the round it was removed, built round by round
+type bikeAccumulator struct {
+	series map[uuid.UUID][]BikeRound
+	// state of every bike in the last game state it was in
+	previous map[uuid.UUID]BikeDump
+}
+
+func (ba *bikeAccumulator) observe(gameState GameStateDump) {
+	for id, bike := range gameState.Bikes {
+		last, seen := ba.previous[id]
+		ba.previous[id] = bike
+		// the initial state of the game only gives the starting positions
+		if gameState.Iteration < 0 {
+			continue
+		}
+
+		round := BikeRound{
+			Round:      gameState.Iteration,
+			Occupancy:  len(bike.AgentIDs),
+			Governance: bike.Governance.String(),
+			BikeEvents: bike.Events,
+		}
+		if seen {
+			round.Distance = math.Sqrt(physics.ComputeDistance(last.PhysicalState.Position, bike.PhysicalState.Position))
+		}
+		if len(bike.AgentIDs) > 0 && bike.Ruler != uuid.Nil {
+			round.Ruler = bike.Ruler
+			round.RulerTenure = 1
+			if series := ba.series[id]; len(series) > 0 && series[len(series)-1].Ruler == bike.Ruler {
+				round.RulerTenure += series[len(series)-1].RulerTenure
+			}
+		}
+		ba.series[id] = append(ba.series[id], round)
+	}
+
+	// bikes removed by the Audi are gone from the game state of the round they were hit in
+	removed := make(map[uuid.UUID]*BikeRound)
+	for _, death := range gameState.Deaths {
+		if _, ok := gameState.Bikes[death.Bike]; ok || death.Cause != AudiCollision || death.Bike == uuid.Nil {
+			continue
+		}
+		if _, ok := removed[death.Bike]; !ok {
+			removed[death.Bike] = &BikeRound{Round: gameState.Iteration, Governance: death.Governance.String()}
+		}
+		removed[death.Bike].AudiKills++
+	}
+	for id, round := range removed {
+		ba.series[id] = append(ba.series[id], *round)
+		delete(ba.previous, id)
+	}
+}
+
+// the institutions of every governance type, from the time series of the bikes of every iteration
+func institutionsByGovernance(bikes []map[uuid.UUID][]BikeRound) map[string]InstitutionStatistics {
+	sums := make(map[string]*InstitutionStatistics)
+	lifespans := make(map[string]int)
+	tenures := make(map[string]int)
+	distances := make(map[string]float64)
+	get := func(governance string) *InstitutionStatistics {
+		if _, ok := sums[governance]; !ok {
+			sums[governance] = &InstitutionStatistics{}
+		}
+		return sums[governance]
+	}
+
+	for _, iteration := range bikes {
+		for _, series := range iteration {
+			for i, round := range series {
+				// events of a round are counted under the governance the bike ended the round with, the rates only over
+				// the rounds in which the bike had riders
+				if round.BikeEvents == (BikeEvents{}) && round.Occupancy == 0 {
+					continue
+				}
+				sum := get(round.Governance)
+				sum.Joins += round.Joins
+				sum.Leaves += round.Leaves
+				sum.Kickouts += round.Kickouts
+				sum.LootBoxRate += float64(round.LootBoxes)
+				sum.ColourPointRate += float64(round.ColourPoints)
+				sum.AudiKillRate += float64(round.AudiKills)
+				if round.Occupancy == 0 {
+					continue
+				}
+				sum.BikeRounds++
+				sum.RiderRounds += round.Occupancy
+				distances[round.Governance] += round.Distance
+
+				// institutions and tenures are counted in their last round
+				next := i + 1
+				if next == len(series) || series[next].Occupancy == 0 || series[next].Governance != round.Governance {
+					sum.Institutions++
+				}
+				lifespans[round.Governance]++
+				if round.RulerTenure > 0 && (next == len(series) || series[next].RulerTenure != round.RulerTenure+1) {
+					sum.RulerTenures++
+					tenures[round.Governance] += round.RulerTenure
+				}
+			}
+		}
+	}
+
+	result := make(map[string]InstitutionStatistics, len(sums))
+	for governance, sum := range sums {
+		if sum.Institutions > 0 {
+			sum.MeanLifespan = float64(lifespans[governance]) / float64(sum.Institutions)
+		}
+		if sum.RulerTenures > 0 {
+			sum.MeanRulerTenure = float64(tenures[governance]) / float64(sum.RulerTenures)
+		}
+		if sum.RiderRounds > 0 {
+			sum.Churn = float64(sum.Joins+sum.Leaves+sum.Kickouts) / float64(sum.RiderRounds)
+		}
+		if sum.BikeRounds > 0 {
+			n := float64(sum.BikeRounds)
+			sum.LootBoxRate /= n
+			sum.ColourPointRate /= n
+			sum.AudiKillRate /= n
+			sum.DistanceRate = distances[governance] / n
+		}
+		result[governance] = *sum
+	}
+	return result
+}
+
+func (s *Server) getBikeEvents(bikeID uuid.UUID) BikeEvents {
+	if events, ok := s.bikeEvents[bikeID]; ok {
+		return *events
+	}
+	return BikeEvents{}
+}
+
+func (s *Server) updateBikeEvents(bikeID uuid.UUID) *BikeEvents {
+	if s.bikeEvents == nil {
+		s.bikeEvents = make(map[uuid.UUID]*BikeEvents)
+	}
+	if _, ok := s.bikeEvents[bikeID]; !ok {
+		s.bikeEvents[bikeID] = &BikeEvents{}
+	}
+	return s.bikeEvents[bikeID]
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// ContractDump is the record of a contract kept by the server, and written to the game dump
+type ContractDump struct {
+	objects.Contract
+	Status     objects.ContractStatus `json:"status"`
+	StartRound int                    `json:"start_round"`
+	EndRound   int                    `json:"end_round"` // -1 while the contract is active
+	Violations []ContractViolation    `json:"violations"`
+	Paid       float64                `json:"paid"` // total energy transferred by the payments of the contract
+}
+
+type ContractViolation struct {
+	Round      int       `json:"round"`
+	Party      uuid.UUID `json:"party"`
+	Obligation int       `json:"obligation"` // index of the violated obligation in the contract
+}
+
+// collects the contracts proposed by the agents and activates those signed by all their parties
+func (s *Server) RunContractNegotiation() {
+	for id, agent := range s.GetAgentMap() {
+		for _, contract := range agent.ProposeContracts() {
+			contract.ID = uuid.New()
+			contract.Proposer = id
+			record := &ContractDump{
+				Contract:   contract,
+				Status:     objects.ContractRejected,
+				StartRound: s.round,
+				EndRound:   s.round,
+			}
+			if s.isValidContract(contract) && s.collectSignatures(contract) {
+				record.Status = objects.ContractActive
+				record.EndRound = -1
+			}
+			s.contracts = append(s.contracts, record)
+			s.notifyParties(record)
+		}
+	}
+}
+
+// checks the obligations of the active contracts for the current round, penalising the violations and making the payments
+func (s *Server) EnforceContracts() {
+	for _, contract := range s.contracts {
+		if contract.Status != objects.ContractActive {
+			continue
+		}
+
+		// contracts can't be enforced on dead agents
+		if slices.ContainsFunc(contract.Parties, func(party uuid.UUID) bool {
+			_, alive := s.GetAgentMap()[party]
+			return !alive
+		}) {
+			contract.Status = objects.ContractVoid
+			contract.EndRound = s.round
+			s.notifyParties(contract)
+			continue
+		}
+
+		for i, obligation := range contract.Obligations {
+			if s.isObligationFulfilled(obligation, contract.Parties) {
+				continue
+			}
+			contract.Violations = append(contract.Violations, ContractViolation{Round: s.round, Party: obligation.Party, Obligation: i})
+			party := s.GetAgentMap()[obligation.Party]
+			party.UpdateEnergyLevel(-contract.EnergyPenalty)
+			party.UpdatePoints(-contract.PointsPenalty)
+			contract.Status = objects.ContractBroken
+		}
+
+		if contract.Status == objects.ContractActive {
+			for _, payment := range contract.Payments {
+				from, to := s.GetAgentMap()[payment.From], s.GetAgentMap()[payment.To]
+				// the payer can't give more energy than it has
+				energy := math.Min(payment.Energy, from.GetEnergyLevel())
+				from.UpdateEnergyLevel(-energy)
+				to.UpdateEnergyLevel(energy)
+				contract.Paid += energy
+			}
+			if s.round-contract.StartRound+1 >= contract.Duration {
+				contract.Status = objects.ContractCompleted
+			}
+		}
+
+		if contract.Status != objects.ContractActive {
+			contract.EndRound = s.round
+			s.notifyParties(contract)
+		}
+	}
+}
+
+// returns the contracts that were active during the current round
+func (s *Server) getRoundContracts() []ContractDump {
+	contracts := make([]ContractDump, 0)
+	for _, contract := range s.contracts {
+		if contract.EndRound == -1 || contract.EndRound == s.round {
+			contracts = append(contracts, *contract)
+		}
+	}
+	return contracts
+}
+
+func (s *Server) isValidContract(contract objects.Contract) bool {
+	if contract.Duration < 1 || !isFiniteAmount(contract.EnergyPenalty) || contract.PointsPenalty < 0 || !slices.Contains(contract.Parties, contract.Proposer) {
+		return false
+	}
+	for i, party := range contract.Parties {
+		if _, alive := s.GetAgentMap()[party]; !alive || slices.Contains(contract.Parties[:i], party) {
+			return false
+		}
+	}
+	for _, obligation := range contract.Obligations {
+		if obligation.Type < objects.VoteForLootbox || obligation.Type > objects.NoKickout || !slices.Contains(contract.Parties, obligation.Party) {
+			return false
+		}
+	}
+	for _, payment := range contract.Payments {
+		if !isFiniteAmount(payment.Energy) || payment.From == payment.To || !slices.Contains(contract.Parties, payment.From) || !slices.Contains(contract.Parties, payment.To) {
+			return false
+		}
+	}
+	return true
+}
+
+// amounts of energy agents give must be positive or zero, NaN and infinities are rejected rather than spread
+func isFiniteAmount(amount float64) bool {
+	return amount >= 0 && !math.IsInf(amount, 1)
+}
+
+func (s *Server) collectSignatures(contract objects.Contract) bool {
+	for _, party := range contract.Parties {
+		if party != contract.Proposer && !s.GetAgentMap()[party].SignContract(contract) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) notifyParties(contract *ContractDump) {
+	for _, party := range contract.Parties {
+		if agent, ok := s.GetAgentMap()[party]; ok {
+			agent.HandleContractStatus(contract.ID, contract.Status)
+		}
+	}
+}
+
+// whether the riders of the bike of the agent voted on the direction in the current round
+func (s *Server) heldDirectionVote(agentID uuid.UUID) bool {
+	bikeID, onBike := s.megaBikeRiders[agentID]
+	if !onBike {
+		return false
+	}
+	for _, rider := range s.megaBikes[bikeID].GetAgents() {
+		if _, voted := s.votes.direction[rider.GetID()]; voted {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Server) isObligationFulfilled(obligation objects.Obligation, parties []uuid.UUID) bool {
+	switch obligation.Type {
+	case objects.VoteForLootbox:
+		vote, voted := s.votes.direction[obligation.Party]
+		if !voted {
+			// the obligation doesn't apply when no vote was held, which is the case of bikeless parties and of the
+			// riders of bikes whose ruler chooses the direction
+			return !s.heldDirectionVote(obligation.Party)
+		}
+		for _, weight := range vote {
+			if weight > vote[obligation.LootboxID] {
+				return false
+			}
+		}
+		_, ok := vote[obligation.LootboxID]
+		return ok
+	case objects.MinimumPedalling:
+		_, onBike := s.megaBikeRiders[obligation.Party]
+		return onBike && s.GetAgentMap()[obligation.Party].GetForces().Pedal >= obligation.Pedal
+	case objects.NoKickout:
+		for _, party := range parties {
+			if party != obligation.Party && s.votes.kickout[obligation.Party][party] > 0 {
+				return false
+			}
+		}
+		return true
+	default:
+		panic("unknown obligation type")
+	}
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// EnergyTransferRadius is the distance between bikes within which agents can transfer energy to each other.
+// When it is 0 agents can only transfer energy to the riders of their own bike
+var EnergyTransferRadius = 0.0
+
+type LoanStatus string
+
+const (
+	LoanActive    LoanStatus = "active"
+	LoanRepaid    LoanStatus = "repaid"
+	LoanDefaulted LoanStatus = "defaulted" // the borrower didn't repay an instalment or died
+	LoanForgiven  LoanStatus = "forgiven"  // the lender died before the loan was repaid
+)
+
+// LoanDump is the record of a loan kept by the server, and written to the game dump
+type LoanDump struct {
+	objects.Loan
+	Status   LoanStatus `json:"status"`
+	EndRound int        `json:"end_round"` // -1 while the loan is active
+}
+
+type TransferType string
+
+const (
+	GiftTransfer      TransferType = "gift"
+	LoanTransfer      TransferType = "loan"
+	RepaymentTransfer TransferType = "repayment"
+)
+
+type TransferDump struct {
+	From   uuid.UUID    `json:"from"`
+	To     uuid.UUID    `json:"to"`
+	Amount float64      `json:"amount"`
+	Type   TransferType `json:"type"`
+	LoanID uuid.UUID    `json:"loan_id"` // uuid.Nil for gifts
+}
+
+// collects the loan repayments due this round, then makes the transfers decided by the agents
+func (s *Server) RunEnergyTransfers() {
+	s.transferLog = make([]TransferDump, 0)
+	s.collectRepayments()
+
+	for id, agent := range s.GetAgentMap() {
+		for _, transfer := range agent.DecideEnergyTransfers() {
+			recipient, ok := s.GetAgentMap()[transfer.To]
+			if !ok || transfer.To == id || transfer.Amount <= 0 || !isFiniteAmount(transfer.Amount) || transfer.Amount > agent.GetEnergyLevel() || !s.canTransferEnergy(id, transfer.To) {
+				continue
+			}
+			if transfer.Loan == nil {
+				s.transferEnergy(agent, recipient, transfer.Amount, GiftTransfer, uuid.Nil)
+				continue
+			}
+			if transfer.Loan.Instalments < 1 || !isFiniteAmount(transfer.Loan.InterestRate) || !recipient.AcceptLoan(id, transfer.Amount, *transfer.Loan) {
+				continue
+			}
+			loan := &LoanDump{
+				Loan: objects.Loan{
+					ID:         uuid.New(),
+					Lender:     id,
+					Borrower:   transfer.To,
+					Amount:     transfer.Amount,
+					LoanTerms:  *transfer.Loan,
+					StartRound: s.round,
+				},
+				Status:   LoanActive,
+				EndRound: -1,
+			}
+			s.loans = append(s.loans, loan)
+			s.transferEnergy(agent, recipient, transfer.Amount, LoanTransfer, loan.ID)
+		}
+	}
+}
+
+func (s *Server) collectRepayments() {
+	for _, loan := range s.loans {
+		if loan.Status != LoanActive {
+			continue
+		}
+		lender, lenderAlive := s.GetAgentMap()[loan.Lender]
+		borrower, borrowerAlive := s.GetAgentMap()[loan.Borrower]
+		instalment := math.Min(loan.GetInstalment(), loan.GetOutstanding())
+		switch {
+		case !lenderAlive:
+			loan.Status = LoanForgiven
+		case borrowerAlive && borrower.GetEnergyLevel() >= instalment && borrower.DecideRepayment(loan.Loan):
+			s.transferEnergy(borrower, lender, instalment, RepaymentTransfer, loan.ID)
+			loan.Repaid += instalment
+			if loan.GetOutstanding() < 1e-9 {
+				loan.Status = LoanRepaid
+			}
+		default:
+			loan.Status = LoanDefaulted
+			lender.HandleLoanDefault(loan.Loan)
+		}
+		if loan.Status != LoanActive {
+			loan.EndRound = s.round
+		}
+	}
+}
+
+func (s *Server) transferEnergy(from objects.IBaseBiker, to objects.IBaseBiker, amount float64, transferType TransferType, loanID uuid.UUID) {
+	from.UpdateEnergyLevel(-amount)
+	to.UpdateEnergyLevel(amount)
+	s.transferLog = append(s.transferLog, TransferDump{
+		From:   from.GetID(),
+		To:     to.GetID(),
+		Amount: amount,
+		Type:   transferType,
+		LoanID: loanID,
+	})
+}
+
+// energy can only be transferred between riders of the same bike, or of bikes within EnergyTransferRadius
+func (s *Server) canTransferEnergy(from uuid.UUID, to uuid.UUID) bool {
+	fromBike, fromOnBike := s.megaBikeRiders[from]
+	toBike, toOnBike := s.megaBikeRiders[to]
+	if !fromOnBike || !toOnBike {
+		return false
+	}
+	if fromBike == toBike {
+		return true
+	}
+	distance := math.Sqrt(physics.ComputeDistance(s.megaBikes[fromBike].GetPosition(), s.megaBikes[toBike].GetPosition()))
+	return distance <= EnergyTransferRadius
+}
+
+// returns the loans that were active during the current round
+func (s *Server) getRoundLoans() []LoanDump {
+	loans := make([]LoanDump, 0)
+	for _, loan := range s.loans {
+		if loan.EndRound == -1 || loan.EndRound == s.round {
+			loans = append(loans, *loan)
+		}
+	}
+	return loans
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/terrain"
 	"math/rand"
 	"slices"
 
@@ -13,13 +14,21 @@ func (s *Server) GetMegaBikes() map[uuid.UUID]objects.IMegaBike {
 }
 
 func (s *Server) GetLootBoxes() map[uuid.UUID]objects.ILootBox {
-	return s.lootBoxes
+	lootBoxes := make(map[uuid.UUID]objects.ILootBox, len(s.lootBoxes))
+	for id, lootBox := range s.lootBoxes {
+		lootBoxes[id] = lootBox
+	}
+	return lootBoxes
 }
 
 func (s *Server) GetAudi() objects.IAudi {
 	return s.audi
 }
 
+func (s *Server) GetTerrain() terrain.ITerrain {
+	return s.terrain
+}
+
 // get a map of megaBikeIDs mapping to the ids of all Bikers that are trying to join it
 func (s *Server) GetJoiningRequests(inLimbo []uuid.UUID) map[uuid.UUID][]uuid.UUID {
 	// iterate over all agents, if their onBike is false add to the map their id in correspondance of that of their desired bike
@@ -2,7 +2,9 @@ package server
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/terrain"
 	"SOMAS2023/internal/common/utils"
+	"encoding/json"
 	"maps"
 	"reflect"
 	"strings"
@@ -16,6 +18,47 @@ type GameStateDump struct {
 	Bikes     map[uuid.UUID]BikeDump    `json:"bikes"`
 	LootBoxes map[uuid.UUID]LootBoxDump `json:"loot_boxes"`
 	Audis     []AudiDump                `json:"audis"`
+	// messages delivered during the messaging session at the end of the round
+	Messages []MessageDump `json:"messages"`
+	// contracts that were active during the round
+	Contracts []ContractDump `json:"contracts"`
+	// energy transfers made during the round and loans that were active during it
+	Transfers []TransferDump `json:"transfers"`
+	Loans     []LoanDump     `json:"loans"`
+	// accusations ruled on during the round
+	Cases []CaseDump `json:"cases"`
+	// agents that died during the round
+	Deaths []DeathDump `json:"deaths"`
+	// lootboxes split between the riders of a bike during the round
+	Allocations []AllocationDump `json:"allocations"`
+	// trades made when the points market was cleared at the end of the round
+	Trades []objects.Trade `json:"trades"`
+	// the terrain doesn't change during the game, so it is only serialised as TerrainFeatures in the initial dump of
+	// every iteration. DecodeGameDump gives it back to every dump decoded
+	Terrain         terrain.ITerrain `json:"-"`
+	TerrainFeatures *terrain.Terrain `json:"terrain,omitempty"`
+}
+
+// DecodeGameDump decodes the iterations of a game dump, and gives every round dump the terrain of its iteration.
+// Iterations dumped without their terrain are given a flat one
+func DecodeGameDump(data []byte) ([][]GameStateDump, error) {
+	var gameStates [][]GameStateDump
+	if err := json.Unmarshal(data, &gameStates); err != nil {
+		return nil, err
+	}
+	for _, rounds := range gameStates {
+		var features terrain.ITerrain = terrain.GetFlatTerrain()
+		for _, gameState := range rounds {
+			if gameState.TerrainFeatures != nil {
+				features = gameState.TerrainFeatures
+				break
+			}
+		}
+		for i := range rounds {
+			rounds[i].Terrain = features
+		}
+	}
+	return gameStates, nil
 }
 
 type PhysicsObjectDump struct {
@@ -31,6 +74,22 @@ type BikeDump struct {
 	AgentIDs   []uuid.UUID      `json:"agent_ids"`
 	Governance utils.Governance `json:"governance"`
 	Ruler      uuid.UUID        `json:"ruler"`
+	Treasury   float64          `json:"treasury"`
+	TaxRate    float64          `json:"tax_rate"`
+	// energy paid into and out of the treasury during the round
+	TreasuryFlows  TreasuryFlows `json:"treasury_flows"`
+	KickedOutCount int           `json:"kicked_out_count"`
+	// what happened to the bike during the round
+	Events BikeEvents `json:"events"`
+}
+
+type BikeEvents struct {
+	Joins        int `json:"joins"`
+	Leaves       int `json:"leaves"`
+	Kickouts     int `json:"kickouts"` // agents kicked out by vote or by the ruler, or expelled by the judiciary
+	LootBoxes    int `json:"loot_boxes"`
+	ColourPoints int `json:"colour_points"` // points scored by the riders from lootboxes of their colour
+	AudiKills    int `json:"audi_kills"`
 }
 
 type AgentDump struct {
@@ -50,9 +109,35 @@ type AgentDump struct {
 
 type LootBoxDump struct {
 	PhysicsObjectDump
-	TotalResources float64      `json:"total_resources"`
-	Colour         utils.Colour `json:"-"`
-	ColourString   string       `json:"colour"`
+	TotalResources    float64      `json:"total_resources"`
+	Colour            utils.Colour `json:"-"`
+	ColourString      string       `json:"colour"`
+	RemainingLifetime int          `json:"remaining_lifetime"`
+	Jackpot           bool         `json:"jackpot"`
+}
+
+type AllocationDump struct {
+	Bike       uuid.UUID             `json:"bike"`
+	LootBox    uuid.UUID             `json:"loot_box"`
+	Governance utils.Governance      `json:"governance"`
+	Loot       float64               `json:"loot"`   // the share of the lootbox resources that went to the bike
+	Shares     map[uuid.UUID]float64 `json:"shares"` // fraction of the loot allocated to each rider
+	Pedals     map[uuid.UUID]float64 `json:"pedals"` // pedalling force of each rider in the round
+}
+
+type DeathCause string
+
+const (
+	AudiCollision DeathCause = "audi"
+	Starvation    DeathCause = "starvation" // the energy of the agent fell below 0
+)
+
+type DeathDump struct {
+	Agent      uuid.UUID        `json:"agent"`
+	Cause      DeathCause       `json:"cause"`
+	Round      int              `json:"round"`
+	Bike       uuid.UUID        `json:"bike"`       // uuid.Nil if the agent wasn't riding a bike
+	Governance utils.Governance `json:"governance"` // governance of the bike, Invalid if the agent wasn't riding a bike
 }
 
 type AudiDump struct {
@@ -109,6 +194,11 @@ func (s *Server) NewGameStateDump(iteration int) GameStateDump {
 			AgentIDs:          agentIDs,
 			Governance:        bike.GetGovernance(),
 			Ruler:             bike.GetRuler(),
+			Treasury:          bike.GetTreasury(),
+			TaxRate:           bike.GetTaxRate(),
+			TreasuryFlows:     s.getTreasuryFlows(id),
+			KickedOutCount:    bike.GetKickedOutCount(),
+			Events:            s.getBikeEvents(id),
 		}
 	}
 
@@ -119,6 +209,8 @@ func (s *Server) NewGameStateDump(iteration int) GameStateDump {
 			TotalResources:    lootBox.GetTotalResources(),
 			Colour:            lootBox.GetColour(),
 			ColourString:      lootBox.GetColour().String(),
+			RemainingLifetime: lootBox.GetRemainingLifetime(),
+			Jackpot:           lootBox.IsJackpot(),
 		}
 	}
 
@@ -132,5 +224,6 @@ func (s *Server) NewGameStateDump(iteration int) GameStateDump {
 			ID:                s.audi.GetID(),
 			TargetBike:        s.audi.GetTargetID(),
 		}},
+		Terrain: s.terrain,
 	}
 }
@@ -169,6 +169,86 @@ func (a AgentDump) HandleVoteAllocationMessage(msg objects.VoteAllocationMessage
 	panic(bannedFunctionErrorMessage)
 }
 
+func (a AgentDump) ProposeContracts() []objects.Contract {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) SignContract(contract objects.Contract) bool {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) HandleContractStatus(contractID uuid.UUID, status objects.ContractStatus) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) DecideEnergyTransfers() []objects.EnergyTransfer {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) AcceptLoan(lender uuid.UUID, amount float64, terms objects.LoanTerms) bool {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) DecideRepayment(loan objects.Loan) bool {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) HandleLoanDefault(loan objects.Loan) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) VoteTaxRate() float64 {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) DecideTaxRate() float64 {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) DecideTreasurySpending() map[uuid.UUID]float64 {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) FileAccusations() []objects.Accusation {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) JudgeAccusation(objects.Accusation) bool {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) HandleVerdict(objects.Accusation, bool, *objects.Sanction) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) DecideMarketOrders() []objects.Order {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) HandleTrade(objects.Trade) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) HandleProposalMessage(msg objects.ProposalMessage) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) HandleAcknowledgementMessage(msg objects.AcknowledgementMessage) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) HandleMessageRejection(rejection objects.MessageRejection) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) GetQueuedMessages() []messaging.IMessage[objects.IBaseBiker] {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (a AgentDump) GetPrivateChannels() []uuid.UUID {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (a AgentDump) DecideDictatorAllocation() voting.IdVoteMap {
 	panic(bannedFunctionErrorMessage)
 }
@@ -201,6 +281,10 @@ func (b BikeDump) KickOutAgent(weights map[uuid.UUID]float64) []uuid.UUID {
 	panic(bannedFunctionErrorMessage)
 }
 
+func (b BikeDump) GetKickoutVotes() map[uuid.UUID]map[uuid.UUID]int {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (b BikeDump) SetGovernance(utils.Governance) {
 	panic(bannedFunctionErrorMessage)
 }
@@ -209,6 +293,14 @@ func (b BikeDump) SetRuler(uuid.UUID) {
 	panic(bannedFunctionErrorMessage)
 }
 
+func (b BikeDump) UpdateTreasury(float64) {
+	panic(bannedFunctionErrorMessage)
+}
+
+func (b BikeDump) SetTaxRate(float64) {
+	panic(bannedFunctionErrorMessage)
+}
+
 func (a AudiDump) UpdateGameState(objects.IGameState) {
 	panic(bannedFunctionErrorMessage)
 }
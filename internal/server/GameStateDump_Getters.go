@@ -2,6 +2,7 @@ package server
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/terrain"
 	"SOMAS2023/internal/common/utils"
 	"maps"
 
@@ -36,6 +37,15 @@ func (gs GameStateDump) GetAudi() objects.IAudi {
 	return gs.Audis[0]
 }
 
+// decoded dumps only have their terrain if they are the initial dump of an iteration or if they were decoded by
+// DecodeGameDump
+func (gs GameStateDump) GetTerrain() terrain.ITerrain {
+	if gs.Terrain == nil && gs.TerrainFeatures != nil {
+		return gs.TerrainFeatures
+	}
+	return gs.Terrain
+}
+
 func (o PhysicsObjectDump) GetID() uuid.UUID {
 	return o.ID
 }
@@ -112,6 +122,18 @@ func (b BikeDump) GetRuler() uuid.UUID {
 	return b.Ruler
 }
 
+func (b BikeDump) GetKickedOutCount() int {
+	return b.KickedOutCount
+}
+
+func (b BikeDump) GetTreasury() float64 {
+	return b.Treasury
+}
+
+func (b BikeDump) GetTaxRate() float64 {
+	return b.TaxRate
+}
+
 func (l LootBoxDump) GetTotalResources() float64 {
 	return l.TotalResources
 }
@@ -120,6 +142,14 @@ func (l LootBoxDump) GetColour() utils.Colour {
 	return l.Colour
 }
 
+func (l LootBoxDump) GetRemainingLifetime() int {
+	return l.RemainingLifetime
+}
+
+func (l LootBoxDump) IsJackpot() bool {
+	return l.Jackpot
+}
+
 func (a AudiDump) GetTargetID() uuid.UUID {
 	return a.TargetBike
 }
@@ -29,6 +29,7 @@ func (s *Server) RulerElection(agents []objects.IBaseBiker, governance utils.Gov
 		case utils.Leadership:
 			votes[agent.GetID()] = agent.VoteLeader()
 		}
+		s.votes.ruler[agent.GetID()] = votes[agent.GetID()]
 	}
 
 	IVotes := make(map[uuid.UUID]voting.IVoter, len(votes))
@@ -62,6 +63,7 @@ func (s *Server) RunDemocraticAction(bike objects.IMegaBike, weights map[uuid.UU
 	for _, agent := range agents {
 		// ---------------------------VOTING ROUTINE - STEP 2 ---------------------
 		finalVotes[agent.GetID()] = agent.FinalDirectionVote(proposedDirections)
+		s.votes.direction[agent.GetID()] = finalVotes[agent.GetID()]
 	}
 
 	// ---------------------------VOTING ROUTINE - STEP 3 --------------
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// how well the reputation each agent holds of the others matches their honesty, measured by the
+// Spearman rank correlation between the final reputation map of the agent and the honesty (1 - lying rate)
+// of the agents in it. 1 means that more honest agents always have a higher reputation, -1 the opposite.
+// Agents whose reputation maps don't rank at least 3 agents with a lying rate have no accuracy
+func reputationAccuracy(lyingRate map[uuid.UUID]float64, finalReputation map[uuid.UUID]map[uuid.UUID]float64) map[uuid.UUID]float64 {
+	result := make(map[uuid.UUID]float64)
+	for id, reputation := range finalReputation {
+		reputations := make([]float64, 0, len(reputation))
+		honesty := make([]float64, 0, len(reputation))
+		for otherID, value := range reputation {
+			rate, ok := lyingRate[otherID]
+			if !ok || otherID == id {
+				continue
+			}
+			reputations = append(reputations, value)
+			honesty = append(honesty, 1-rate)
+		}
+		if len(reputations) < 3 {
+			continue
+		}
+		if correlation := spearmanCorrelation(reputations, honesty); !math.IsNaN(correlation) {
+			result[id] = correlation
+		}
+	}
+	return result
+}
+
+// Spearman rank correlation of the two samples, NaN if either of them is constant
+func spearmanCorrelation(x []float64, y []float64) float64 {
+	return pearsonCorrelation(ranks(x), ranks(y))
+}
+
+func pearsonCorrelation(x []float64, y []float64) float64 {
+	n := float64(len(x))
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i] / n
+		meanY += y[i] / n
+	}
+	covariance, varianceX, varianceY := 0.0, 0.0, 0.0
+	for i := range x {
+		covariance += (x[i] - meanX) * (y[i] - meanY)
+		varianceX += (x[i] - meanX) * (x[i] - meanX)
+		varianceY += (y[i] - meanY) * (y[i] - meanY)
+	}
+	if varianceX == 0 || varianceY == 0 {
+		return math.NaN()
+	}
+	return covariance / math.Sqrt(varianceX*varianceY)
+}
+
+// returns the (1-based) rank of each value in the sample, tied values get the average of their ranks
+func ranks(values []float64) []float64 {
+	order := make([]int, len(values))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		if values[a] < values[b] {
+			return -1
+		} else if values[a] > values[b] {
+			return 1
+		}
+		return 0
+	})
+
+	result := make([]float64, len(values))
+	for start := 0; start < len(order); {
+		end := start
+		for end+1 < len(order) && values[order[end+1]] == values[order[start]] {
+			end++
+		}
+		rank := float64(start+end)/2 + 1
+		for i := start; i <= end; i++ {
+			result[order[i]] = rank
+		}
+		start = end + 1
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"fmt"
+	"math/rand"
+
+	"github.com/google/uuid"
+)
+
+// JudiciaryModel describes the graduated sanctions of the riders. The zero value disables it, in which case kickouts are the only punishment
+type JudiciaryModel struct {
+	Enabled    bool
+	JurySize   int     // number of riders judging an accusation when there is no ruler to do it, 3 when 0
+	EnergyFine float64 // energy taken from the agent by an EnergyFine sanction
+	PointsFine int     // points taken from the agent by a PointsFine sanction
+}
+
+var Judiciary = JudiciaryModel{}
+
+// CaseDump is the record of an accusation and its verdict, written to the game dump
+type CaseDump struct {
+	objects.Accusation
+	Bike        uuid.UUID         `json:"bike"`
+	Judges      []uuid.UUID       `json:"judges"` // the ruler, or the jurors
+	GuiltyVotes int               `json:"guilty_votes"`
+	Guilty      bool              `json:"guilty"`
+	Sanction    *objects.Sanction `json:"sanction"` // nil when the accused is acquitted
+}
+
+// collects the accusations of the riders and rules on them: the ruler of a leadership or dictatorship judges alone
+// (unless it is a party of the case), otherwise a jury of riders decides by majority.
+// Convictions are sanctioned with a warning, then an energy fine, then a points fine, then expulsion from the bike
+func (s *Server) RunJudiciary() {
+	s.caseLog = make([]CaseDump, 0)
+	if !Judiciary.Enabled {
+		return
+	}
+
+	filed := make(map[objects.Accusation]bool)
+	for id, agent := range s.GetAgentMap() {
+		for _, accusation := range agent.FileAccusations() {
+			accusation.ID = uuid.Nil
+			accusation.Accuser = id
+			accusation.Evidence = 0
+			// riders can only accuse other riders of their bike, once per offence every round
+			if !s.isValidAccusation(accusation) || filed[accusation] {
+				continue
+			}
+			filed[accusation] = true
+
+			accusation.ID = uuid.New()
+			accusation.Evidence = s.getEvidence(accusation)
+			s.ruleOnAccusation(accusation)
+		}
+	}
+}
+
+func (s *Server) isValidAccusation(accusation objects.Accusation) bool {
+	if accusation.Accused == accusation.Accuser || (accusation.Offence != objects.Freeriding && accusation.Offence != objects.Lying) {
+		return false
+	}
+	accuserBike, accuserOnBike := s.megaBikeRiders[accusation.Accuser]
+	accusedBike, accusedOnBike := s.megaBikeRiders[accusation.Accused]
+	return accuserOnBike && accusedOnBike && accuserBike == accusedBike
+}
+
+func (s *Server) getEvidence(accusation objects.Accusation) float64 {
+	switch accusation.Offence {
+	case objects.Freeriding:
+		return s.GetAgentMap()[accusation.Accused].GetForces().Pedal
+	case objects.Lying:
+		lies := 0
+		for _, msg := range s.messageLog {
+			if msg.Sender == accusation.Accused && msg.Truthful != nil && !*msg.Truthful {
+				lies++
+			}
+		}
+		return float64(lies)
+	default:
+		panic("unknown offence")
+	}
+}
+
+func (s *Server) ruleOnAccusation(accusation objects.Accusation) {
+	bike := s.megaBikes[s.megaBikeRiders[accusation.Accused]]
+	record := CaseDump{
+		Accusation: accusation,
+		Bike:       bike.GetID(),
+		Judges:     s.getJudges(bike, accusation),
+	}
+	for _, judge := range record.Judges {
+		if s.GetAgentMap()[judge].JudgeAccusation(accusation) {
+			record.GuiltyVotes++
+		}
+	}
+	record.Guilty = record.GuiltyVotes*2 > len(record.Judges)
+
+	if record.Guilty {
+		sanction := objects.Sanction(min(s.convictions[accusation.Accused], int(objects.Expulsion)))
+		s.convictions[accusation.Accused]++
+		record.Sanction = &sanction
+		s.applySanction(bike, s.GetAgentMap()[accusation.Accused], sanction)
+	}
+	s.caseLog = append(s.caseLog, record)
+
+	for _, party := range []uuid.UUID{accusation.Accuser, accusation.Accused} {
+		s.GetAgentMap()[party].HandleVerdict(accusation, record.Guilty, record.Sanction)
+	}
+}
+
+// the ruler judges alone unless it is a party of the case, otherwise a jury is drawn from the other riders of the bike
+func (s *Server) getJudges(bike objects.IMegaBike, accusation objects.Accusation) []uuid.UUID {
+	ruler := bike.GetRuler()
+	if _, alive := s.GetAgentMap()[ruler]; alive && bike.GetGovernance() != utils.Democracy && ruler != accusation.Accuser && ruler != accusation.Accused {
+		return []uuid.UUID{ruler}
+	}
+
+	candidates := make([]uuid.UUID, 0)
+	for _, agent := range bike.GetAgents() {
+		if agent.GetID() != accusation.Accuser && agent.GetID() != accusation.Accused {
+			candidates = append(candidates, agent.GetID())
+		}
+	}
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	return candidates[:min(len(candidates), Judiciary.getJurySize())]
+}
+
+func (s *Server) applySanction(bike objects.IMegaBike, agent objects.IBaseBiker, sanction objects.Sanction) {
+	switch sanction {
+	case objects.Warning:
+	case objects.EnergyFine:
+		agent.UpdateEnergyLevel(-Judiciary.EnergyFine)
+	case objects.PointsFine:
+		agent.UpdatePoints(-Judiciary.PointsFine)
+	case objects.Expulsion:
+		fmt.Printf("expelling agent %s\n", agent.GetID())
+		s.updateBikeEvents(bike.GetID()).Kickouts++
+		s.RemoveAgentFromBike(agent)
+		// if the ruler was expelled choose a new one
+		if agent.GetID() == bike.GetRuler() && len(bike.GetAgents()) != 0 && bike.GetGovernance() != utils.Democracy {
+			bike.SetRuler(s.RulerElection(bike.GetAgents(), bike.GetGovernance()))
+		}
+	}
+}
+
+func (j JudiciaryModel) getJurySize() int {
+	if j.JurySize == 0 {
+		return 3
+	}
+	return j.JurySize
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"math/rand"
+)
+
+// ILootBoxSpawner decides how many lootboxes are on the map and where, with which colour and how much loot they spawn
+type ILootBoxSpawner interface {
+	// returns the number of lootboxes that should be on the map in the given round
+	GetTargetCount(round int) int
+	// creates a new lootbox for the given round
+	SpawnLootBox(round int, gameState objects.IGameState) objects.IServerLootBox
+}
+
+// LootBoxSpawner is the spawn model used by the server. Override it to study different resource distributions
+var LootBoxSpawner ILootBoxSpawner = UniformLootBoxSpawner{}
+
+// UniformLootBoxSpawner keeps LootBoxCount lootboxes on the map, spawned uniformly at random
+type UniformLootBoxSpawner struct{}
+
+func (UniformLootBoxSpawner) GetTargetCount(round int) int {
+	return LootBoxCount
+}
+
+func (UniformLootBoxSpawner) SpawnLootBox(round int, gameState objects.IGameState) objects.IServerLootBox {
+	return objects.GetLootBox()
+}
+
+// Hotspot is a gaussian cluster of lootboxes
+type Hotspot struct {
+	Centre utils.Coordinates
+	Spread float64 // standard deviation of the distance from the centre
+	Weight float64 // relative probability of a lootbox spawning in this hotspot
+	// loot of the lootboxes spawned in this hotspot is multiplied by this value, 0 leaves it unchanged
+	LootMultiplier float64
+	// colours the lootboxes in this hotspot can have, any colour if empty
+	Colours []utils.Colour
+}
+
+// HotspotLootBoxSpawner keeps LootBoxCount lootboxes on the map, spawned in gaussian clusters around the hotspots
+type HotspotLootBoxSpawner struct {
+	Hotspots []Hotspot
+}
+
+func (h HotspotLootBoxSpawner) GetTargetCount(round int) int {
+	return LootBoxCount
+}
+
+func (h HotspotLootBoxSpawner) SpawnLootBox(round int, gameState objects.IGameState) objects.IServerLootBox {
+	hotspot := h.pickHotspot()
+	position := clampToGrid(utils.Coordinates{
+		X: hotspot.Centre.X + rand.NormFloat64()*hotspot.Spread,
+		Y: hotspot.Centre.Y + rand.NormFloat64()*hotspot.Spread,
+	})
+	colour := utils.GenerateRandomColour()
+	if len(hotspot.Colours) > 0 {
+		colour = hotspot.Colours[rand.Intn(len(hotspot.Colours))]
+	}
+	return objects.NewLootBox(position, colour, randomLoot()*hotspot.getLootMultiplier())
+}
+
+func (h Hotspot) getLootMultiplier() float64 {
+	if h.LootMultiplier == 0 {
+		return 1.0
+	}
+	return h.LootMultiplier
+}
+
+func (h HotspotLootBoxSpawner) pickHotspot() Hotspot {
+	if len(h.Hotspots) == 0 {
+		panic("hotspot spawner has no hotspots")
+	}
+	totalWeight := 0.0
+	for _, hotspot := range h.Hotspots {
+		totalWeight += hotspot.Weight
+	}
+	target := rand.Float64() * totalWeight
+	for _, hotspot := range h.Hotspots {
+		target -= hotspot.Weight
+		if target < 0 {
+			return hotspot
+		}
+	}
+	return h.Hotspots[len(h.Hotspots)-1]
+}
+
+// ColourRegionLootBoxSpawner keeps LootBoxCount lootboxes on the map, each colour only spawning in its own vertical strip of the grid
+type ColourRegionLootBoxSpawner struct{}
+
+func (ColourRegionLootBoxSpawner) GetTargetCount(round int) int {
+	return LootBoxCount
+}
+
+func (ColourRegionLootBoxSpawner) SpawnLootBox(round int, gameState objects.IGameState) objects.IServerLootBox {
+	colour := utils.GenerateRandomColour()
+	minX, maxX := GetColourRegion(colour)
+	position := utils.Coordinates{
+		X: utils.GenerateRandomFloat(minX, maxX),
+		Y: rand.Float64() * utils.GridHeight,
+	}
+	return objects.NewLootBox(position, colour, randomLoot())
+}
+
+// GetColourRegion returns the x range in which lootboxes of the given colour spawn with the ColourRegionLootBoxSpawner
+func GetColourRegion(colour utils.Colour) (float64, float64) {
+	stripWidth := utils.GridWidth / float64(utils.NumOfColours)
+	return float64(colour) * stripWidth, float64(colour+1) * stripWidth
+}
+
+// AudiBonusLootBoxSpawner wraps another spawner, making lootboxes that spawn close to the audi richer.
+// The bonus decreases linearly from MaxBonus (loot multiplied by 1+MaxBonus) at the audi to nothing at Radius
+type AudiBonusLootBoxSpawner struct {
+	Base     ILootBoxSpawner
+	Radius   float64
+	MaxBonus float64
+}
+
+func (a AudiBonusLootBoxSpawner) GetTargetCount(round int) int {
+	return a.Base.GetTargetCount(round)
+}
+
+func (a AudiBonusLootBoxSpawner) SpawnLootBox(round int, gameState objects.IGameState) objects.IServerLootBox {
+	lootBox := a.Base.SpawnLootBox(round, gameState)
+	distance := math.Sqrt(physics.ComputeDistance(lootBox.GetPosition(), gameState.GetAudi().GetPosition()))
+	bonus := a.MaxBonus * math.Max(0, 1-distance/a.Radius)
+	lootBox.MultiplyResources(1 + bonus)
+	return lootBox
+}
+
+// ScarcityPhase changes the abundance of resources from its start round until the next phase
+type ScarcityPhase struct {
+	StartRound      int
+	CountMultiplier float64 // multiplies the number of lootboxes on the map, 0 leaves it unchanged
+	LootMultiplier  float64 // multiplies the loot of newly spawned lootboxes, 0 leaves it unchanged
+}
+
+// ScheduledLootBoxSpawner wraps another spawner, scaling it over time according to a famine/boom schedule.
+// Phases must be sorted by start round, rounds before the first phase are unaffected
+type ScheduledLootBoxSpawner struct {
+	Base   ILootBoxSpawner
+	Phases []ScarcityPhase
+}
+
+func (s ScheduledLootBoxSpawner) GetTargetCount(round int) int {
+	phase := s.getPhase(round)
+	return int(math.Round(float64(s.Base.GetTargetCount(round)) * phase.getCountMultiplier()))
+}
+
+func (s ScheduledLootBoxSpawner) SpawnLootBox(round int, gameState objects.IGameState) objects.IServerLootBox {
+	lootBox := s.Base.SpawnLootBox(round, gameState)
+	lootBox.MultiplyResources(s.getPhase(round).getLootMultiplier())
+	return lootBox
+}
+
+func (s ScheduledLootBoxSpawner) getPhase(round int) ScarcityPhase {
+	current := ScarcityPhase{}
+	for _, phase := range s.Phases {
+		if phase.StartRound > round {
+			break
+		}
+		current = phase
+	}
+	return current
+}
+
+func (p ScarcityPhase) getCountMultiplier() float64 {
+	if p.CountMultiplier == 0 {
+		return 1.0
+	}
+	return p.CountMultiplier
+}
+
+func (p ScarcityPhase) getLootMultiplier() float64 {
+	if p.LootMultiplier == 0 {
+		return 1.0
+	}
+	return p.LootMultiplier
+}
+
+func randomLoot() float64 {
+	return utils.GenerateRandomFloat(utils.MinLootBoxResources, utils.MaxLootBoxResources)
+}
+
+func isOnGrid(position utils.Coordinates) bool {
+	return position.X >= 0 && position.X <= utils.GridWidth && position.Y >= 0 && position.Y <= utils.GridHeight
+}
+
+func clampToGrid(position utils.Coordinates) utils.Coordinates {
+	return utils.Coordinates{
+		X: math.Max(0, math.Min(position.X, utils.GridWidth)),
+		Y: math.Max(0, math.Min(position.Y, utils.GridHeight)),
+	}
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
+	"github.com/google/uuid"
+)
+
+type MessageRange int
+
+const (
+	AnyDistance  MessageRange = iota // messages reach every recipient
+	SameBike                         // messages only reach recipients riding the same bike as the sender
+	WithinRadius                     // messages only reach recipients on bikes within MessageChannelModel.Radius of the sender's bike
+)
+
+// MessageChannelModel describes the constraints on the communication between agents.
+// The zero value is a free, unlimited and lossless channel
+type MessageChannelModel struct {
+	// energy paid by the sender for every message sent
+	MessageCost float64
+	// energy paid by the sender for every recipient in range of a message
+	RecipientCost float64
+	// which recipients can be reached. Agents that aren't on a bike can't reach anyone unless the range is AnyDistance.
+	// Agents with a private channel between them can always reach each other
+	Range  MessageRange
+	Radius float64
+	// maximum number of messages an agent can send per round, 0 for no limit
+	MaxMessagesPerRound int
+	// probability that a message is lost on its way to each recipient (the sender is still charged)
+	DropProbability float64
+	// maximum number of exchanges in a messaging session: the messages enqueued by the handlers during an exchange
+	// (e.g. replies) are sent in the next one. 0 or 1 for a single exchange
+	MaxExchanges int
+}
+
+// MessageChannel is the channel model used by the server in RunMessagingSession
+var MessageChannel = MessageChannelModel{}
+
+// had to override to address the fact that agents only have access to the game dump
+// version of agents, so if the recipients are set to be those it will panic as they
+// can't call the handler functions
+func (s *Server) RunMessagingSession() {
+	agentArray := s.GenerateAgentArrayFromMap()
+	s.messageLog = make([]MessageDump, 0)
+	privateChannels := s.getPrivateChannels()
+	sent := make(map[uuid.UUID]int)
+
+	// messages enqueued by the handlers during an exchange are sent in the next one
+	for exchange := 0; exchange < max(1, MessageChannel.MaxExchanges); exchange++ {
+		outboxes := make(map[uuid.UUID][]messaging.IMessage[objects.IBaseBiker])
+		for id, agent := range s.GetAgentMap() {
+			msgs := agent.GetQueuedMessages()
+			if exchange == 0 {
+				msgs = append(agent.GetAllMessages(agentArray), msgs...)
+			}
+			if len(msgs) > 0 {
+				outboxes[id] = msgs
+			}
+		}
+		if len(outboxes) == 0 {
+			break
+		}
+		for id, msgs := range outboxes {
+			agent := s.GetAgentMap()[id]
+			for _, msg := range msgs {
+				if s.sendMessage(agent, msg, exchange, sent[id], privateChannels) {
+					sent[id]++
+				}
+			}
+		}
+	}
+
+	// replies enqueued during the last exchange can't be answered within the session anymore
+	for _, agent := range s.GetAgentMap() {
+		for _, msg := range agent.GetQueuedMessages() {
+			agent.HandleMessageRejection(objects.MessageRejection{Message: msg, Recipients: getAgentIDs(msg.GetRecipients()), Reason: objects.ExchangeLimitReached})
+		}
+	}
+}
+
+// delivers the message through the channel, reporting the recipients it can't reach to the sender.
+// Returns whether the message was sent (even if it was then dropped)
+func (s *Server) sendMessage(agent objects.IBaseBiker, msg messaging.IMessage[objects.IBaseBiker], exchange int, alreadySent int, privateChannels map[uuid.UUID]map[uuid.UUID]bool) bool {
+	if MessageChannel.MaxMessagesPerRound > 0 && alreadySent >= MessageChannel.MaxMessagesPerRound {
+		agent.HandleMessageRejection(objects.MessageRejection{Message: msg, Recipients: getAgentIDs(msg.GetRecipients()), Reason: objects.MessageCapReached})
+		return false
+	}
+
+	// make recipient list with actual agents
+	reachable := make([]objects.IBaseBiker, 0, len(msg.GetRecipients()))
+	outOfRange := make([]uuid.UUID, 0)
+	private := true
+	for _, recipient := range msg.GetRecipients() {
+		recip, ok := s.GetAgentMap()[recipient.GetID()]
+		if !ok || agent.GetID() == recip.GetID() {
+			continue
+		}
+		hasPrivateChannel := privateChannels[agent.GetID()][recip.GetID()]
+		private = private && hasPrivateChannel
+		if hasPrivateChannel || s.inMessageRange(agent, recip) {
+			reachable = append(reachable, recip)
+		} else {
+			outOfRange = append(outOfRange, recip.GetID())
+		}
+	}
+	if len(outOfRange) > 0 {
+		agent.HandleMessageRejection(objects.MessageRejection{Message: msg, Recipients: outOfRange, Reason: objects.OutOfRange})
+	}
+	if len(reachable) == 0 {
+		return false
+	}
+
+	cost := MessageChannel.MessageCost + MessageChannel.RecipientCost*float64(len(reachable))
+	if cost > agent.GetEnergyLevel() {
+		agent.HandleMessageRejection(objects.MessageRejection{Message: msg, Recipients: getAgentIDs(reachable), Reason: objects.InsufficientEnergy})
+		return false
+	}
+	if cost > 0 {
+		agent.UpdateEnergyLevel(-cost)
+	}
+
+	delivered := make([]objects.IBaseBiker, 0, len(reachable))
+	for _, recip := range reachable {
+		if rand.Float64() < MessageChannel.DropProbability {
+			continue
+		}
+		msg.InvokeMessageHandler(recip)
+		delivered = append(delivered, recip)
+	}
+	if len(delivered) > 0 {
+		dump := s.newMessageDump(msg, delivered)
+		dump.Exchange = exchange
+		dump.Private = private
+		s.messageLog = append(s.messageLog, dump)
+	}
+	return true
+}
+
+// a private channel is open between two agents when both of them ask for it
+func (s *Server) getPrivateChannels() map[uuid.UUID]map[uuid.UUID]bool {
+	requested := make(map[uuid.UUID][]uuid.UUID)
+	for id, agent := range s.GetAgentMap() {
+		requested[id] = agent.GetPrivateChannels()
+	}
+	channels := make(map[uuid.UUID]map[uuid.UUID]bool)
+	for id, others := range requested {
+		for _, otherID := range others {
+			if otherID != id && slices.Contains(requested[otherID], id) {
+				if _, ok := channels[id]; !ok {
+					channels[id] = make(map[uuid.UUID]bool)
+				}
+				channels[id][otherID] = true
+			}
+		}
+	}
+	return channels
+}
+
+func (s *Server) inMessageRange(sender objects.IBaseBiker, recipient objects.IBaseBiker) bool {
+	if MessageChannel.Range == AnyDistance {
+		return true
+	}
+	senderBike, senderOnBike := s.megaBikeRiders[sender.GetID()]
+	recipientBike, recipientOnBike := s.megaBikeRiders[recipient.GetID()]
+	if !senderOnBike || !recipientOnBike {
+		return false
+	}
+	switch MessageChannel.Range {
+	case SameBike:
+		return senderBike == recipientBike
+	case WithinRadius:
+		distance := math.Sqrt(physics.ComputeDistance(s.megaBikes[senderBike].GetPosition(), s.megaBikes[recipientBike].GetPosition()))
+		return distance <= MessageChannel.Radius
+	default:
+		panic("unknown message range")
+	}
+}
+
+func getAgentIDs(agents []objects.IBaseBiker) []uuid.UUID {
+	ids := make([]uuid.UUID, len(agents))
+	for i, agent := range agents {
+		ids[i] = agent.GetID()
+	}
+	return ids
+}
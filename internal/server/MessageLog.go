@@ -0,0 +1,217 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/voting"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
+	"github.com/google/uuid"
+)
+
+// MessageDump is the record of a delivered message, kept in the game dump so that communication can be analysed after the game
+type MessageDump struct {
+	Type       string      `json:"type"`
+	Sender     uuid.UUID   `json:"sender"`
+	Recipients []uuid.UUID `json:"recipients"` // recipients the message was actually delivered to
+	// the content of the message, keyed by field name
+	Payload map[string]any `json:"payload"`
+	// fields of the payload holding numbers JSON can't represent (NaN and infinities), which are replaced by null
+	NonFiniteFields []string `json:"non_finite_fields,omitempty"`
+	// whether the claim made in the message is true, nil if the server can't verify it
+	Truthful *bool `json:"truthful"`
+	// exchange of the messaging session in which the message was sent, replies come after the messages they answer
+	Exchange int `json:"exchange"`
+	// whether the message only went through private channels
+	Private bool `json:"private"`
+}
+
+// votes cast by each agent in the current round, kept to check the claims made in the vote messages
+type castVotes struct {
+	direction  map[uuid.UUID]voting.LootboxVoteMap
+	ruler      map[uuid.UUID]voting.IdVoteMap
+	allocation map[uuid.UUID]voting.IdVoteMap
+	kickout    map[uuid.UUID]map[uuid.UUID]int
+}
+
+func newCastVotes() castVotes {
+	return castVotes{
+		direction:  make(map[uuid.UUID]voting.LootboxVoteMap),
+		ruler:      make(map[uuid.UUID]voting.IdVoteMap),
+		allocation: make(map[uuid.UUID]voting.IdVoteMap),
+		kickout:    make(map[uuid.UUID]map[uuid.UUID]int),
+	}
+}
+
+func (s *Server) newMessageDump(msg messaging.IMessage[objects.IBaseBiker], recipients []objects.IBaseBiker) MessageDump {
+	value := reflect.Indirect(reflect.ValueOf(msg))
+	payload := make(map[string]any)
+	var nonFinite []string
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if field.Anonymous {
+			// skip the embedded BaseMessage, sender and recipients are recorded separately
+			continue
+		}
+		content := value.Field(i).Interface()
+		if _, err := json.Marshal(content); err != nil {
+			// agents can send numbers JSON doesn't support (e.g. NaN forces), which are recorded as null instead of
+			// breaking the dump. Other values it doesn't support are kept readable
+			finite, replaced := finiteContent(value.Field(i))
+			if _, err := json.Marshal(finite); replaced && err == nil {
+				content = finite
+				nonFinite = append(nonFinite, field.Name)
+			} else {
+				content = fmt.Sprintf("%v", content)
+			}
+		}
+		payload[field.Name] = content
+	}
+	return MessageDump{
+		Type:            value.Type().Name(),
+		Sender:          msg.GetSender().GetID(),
+		Recipients:      getAgentIDs(recipients),
+		Payload:         payload,
+		NonFiniteFields: nonFinite,
+		Truthful:        s.verifyMessage(msg),
+	}
+}
+
+// returns the content as JSON would encode it, with its NaN and infinite numbers replaced by nil, and whether any was
+// replaced. Structs and maps become maps keyed as JSON keys them, so the content is encoded the same way
+func finiteContent(value reflect.Value) (any, bool) {
+	switch value.Kind() {
+	case reflect.Float32, reflect.Float64:
+		if number := value.Float(); math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, true
+		}
+	case reflect.Pointer, reflect.Interface:
+		if !value.IsNil() {
+			return finiteContent(value.Elem())
+		}
+	case reflect.Struct:
+		content, replaced := make(map[string]any), false
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if !field.IsExported() || name == "-" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			fieldContent, fieldReplaced := finiteContent(value.Field(i))
+			content[name], replaced = fieldContent, replaced || fieldReplaced
+		}
+		return content, replaced
+	case reflect.Map:
+		content, replaced := make(map[string]any, value.Len()), false
+		for iterator := value.MapRange(); iterator.Next(); {
+			key := fmt.Sprint(iterator.Key().Interface())
+			if marshaler, ok := iterator.Key().Interface().(encoding.TextMarshaler); ok {
+				if text, err := marshaler.MarshalText(); err == nil {
+					key = string(text)
+				}
+			}
+			elementContent, elementReplaced := finiteContent(iterator.Value())
+			content[key], replaced = elementContent, replaced || elementReplaced
+		}
+		return content, replaced
+	case reflect.Slice, reflect.Array:
+		content, replaced := make([]any, value.Len()), false
+		for i := range content {
+			elementContent, elementReplaced := finiteContent(value.Index(i))
+			content[i], replaced = elementContent, replaced || elementReplaced
+		}
+		return content, replaced
+	}
+	return value.Interface(), false
+}
+
+// checks the claim made in the message against the state of the server.
+// Messages stating intentions or opinions the server doesn't know about can't be verified
+func (s *Server) verifyMessage(msg messaging.IMessage[objects.IBaseBiker]) *bool {
+	var truthful bool
+	senderID := msg.GetSender().GetID()
+	switch msg := msg.(type) {
+	case objects.ForcesMessage:
+		agent, ok := s.GetAgentMap()[msg.AgentId]
+		if !ok {
+			return nil
+		}
+		truthful = agent.GetForces() == msg.AgentForces
+	case objects.ReputationOfAgentMessage:
+		reputation, ok := msg.GetSender().GetReputation()[msg.AgentId]
+		if !ok {
+			return nil
+		}
+		truthful = math.Abs(reputation-msg.Reputation) < 1e-9
+	case objects.VoteLootboxDirectionMessage:
+		vote, ok := s.votes.direction[senderID]
+		if !ok {
+			return nil
+		}
+		truthful = sameVotes(vote, msg.VoteMap)
+	case objects.VoteRulerMessage:
+		vote, ok := s.votes.ruler[senderID]
+		if !ok {
+			return nil
+		}
+		truthful = sameVotes(vote, msg.VoteMap)
+	case objects.VoteAllocationMessage:
+		vote, ok := s.votes.allocation[senderID]
+		if !ok {
+			return nil
+		}
+		truthful = sameVotes(vote, msg.VoteMap)
+	case objects.VoteGoveranceMessage:
+		// the governance is voted for when the institutions are founded, and the vote map gives weight to the bikes
+		// whose governance the sender claims to have voted for
+		choice, ok := s.foundingChoices[senderID]
+		if !ok || len(msg.VoteMap) == 0 {
+			return nil
+		}
+		truthful = true
+		for bikeID, weight := range msg.VoteMap {
+			bike, ok := s.megaBikes[bikeID]
+			if !ok {
+				return nil
+			}
+			if weight > 0 && bike.GetGovernance() != choice {
+				truthful = false
+			}
+		}
+	case objects.VoteKickoutMessage:
+		vote, ok := s.votes.kickout[senderID]
+		if !ok {
+			return nil
+		}
+		truthful = maps.Equal(vote, msg.VoteMap)
+	default:
+		return nil
+	}
+	return &truthful
+}
+
+func sameVotes(cast map[uuid.UUID]float64, claimed map[uuid.UUID]float64) bool {
+	if len(cast) != len(claimed) {
+		return false
+	}
+	for id, vote := range cast {
+		if claim, ok := claimed[id]; !ok || math.Abs(vote-claim) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+// GetMessageLog returns the messages delivered during the last messaging session
+func (s *Server) GetMessageLog() []MessageDump {
+	return s.messageLog
+}
@@ -0,0 +1,186 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"math/rand"
+
+	"github.com/google/uuid"
+)
+
+// HiddenValue replaces the energy level and points of agents whose private state can't be observed
+const HiddenValue = -1
+
+// IObservationPolicy decides which part of the game state each agent can see.
+// The same policy is applied to every agent so that all teams play under the same information constraints
+type IObservationPolicy interface {
+	// returns the view of the game state available to the observer, whose noisy positions are drawn from the noise
+	Observe(observer objects.IBaseBiker, gameState GameStateDump, noise *ObservationNoise) GameStateDump
+}
+
+// ObservationNoise holds the noise added to the positions seen by each observer. The server samples it once per round,
+// so that an observer given the game state several times during a round sees the same offset of each object
+type ObservationNoise struct {
+	offsets map[uuid.UUID]map[uuid.UUID]utils.Coordinates
+}
+
+func NewObservationNoise() *ObservationNoise {
+	return &ObservationNoise{offsets: make(map[uuid.UUID]map[uuid.UUID]utils.Coordinates)}
+}
+
+// Offset returns the offset of the object seen by the observer, drawn from a gaussian of the given standard deviation
+// the first time it is asked for
+func (n *ObservationNoise) Offset(observer uuid.UUID, object uuid.UUID, deviation float64) utils.Coordinates {
+	offsets, ok := n.offsets[observer]
+	if !ok {
+		offsets = make(map[uuid.UUID]utils.Coordinates)
+		n.offsets[observer] = offsets
+	}
+	offset, ok := offsets[object]
+	if !ok {
+		offset = utils.Coordinates{X: rand.NormFloat64() * deviation, Y: rand.NormFloat64() * deviation}
+		offsets[object] = offset
+	}
+	return offset
+}
+
+// ObservationPolicy is the policy used by the server when handing game states to the agents
+var ObservationPolicy IObservationPolicy = FullObservation{}
+
+// FullObservation lets every agent see the whole game state
+type FullObservation struct{}
+
+func (FullObservation) Observe(observer objects.IBaseBiker, gameState GameStateDump, noise *ObservationNoise) GameStateDump {
+	return gameState
+}
+
+// PartialObservation restricts what an agent can see around its bike (or the bike it is trying to join)
+type PartialObservation struct {
+	// only bikes and lootboxes within this distance of the observer's bike are visible, 0 for no limit.
+	// The observer's own bike, the riders of visible bikes and the audi are always visible
+	SensingRadius float64
+	// number of nearest lootboxes that are visible regardless of the sensing radius, so that bikes always have a destination
+	MinVisibleLootBoxes int
+	// hides the energy level and points of agents on other bikes (set to HiddenValue) and the reputation maps of all other agents
+	HidePrivateState bool
+	// standard deviation of the gaussian noise added to the positions of everything but the observer's own bike
+	PositionNoise float64
+}
+
+func (p PartialObservation) Observe(observer objects.IBaseBiker, gameState GameStateDump, noise *ObservationNoise) GameStateDump {
+	addNoise := func(id uuid.UUID, position utils.Coordinates) utils.Coordinates {
+		if p.PositionNoise <= 0 {
+			return position
+		}
+		offset := noise.Offset(observer.GetID(), id, p.PositionNoise)
+		return utils.Coordinates{X: position.X + offset.X, Y: position.Y + offset.Y}
+	}
+
+	ownBikeID := observer.GetBike()
+	ownBike, hasBike := gameState.Bikes[ownBikeID]
+	centre := ownBike.GetPosition()
+	// at founding agents have no bike yet, so there is nothing to centre the sensing radius on
+	inRange := func(position utils.Coordinates) bool {
+		return !hasBike || p.SensingRadius <= 0 || math.Sqrt(physics.ComputeDistance(centre, position)) <= p.SensingRadius
+	}
+
+	bikes := make(map[uuid.UUID]BikeDump)
+	for id, bike := range gameState.Bikes {
+		if id == ownBikeID || inRange(bike.GetPosition()) {
+			bikes[id] = bike
+		}
+	}
+
+	agents := make(map[uuid.UUID]AgentDump)
+	for id, agent := range gameState.Agents {
+		_, onVisibleBike := bikes[agent.BikeID]
+		if id == observer.GetID() || (agent.OnBike && onVisibleBike) {
+			agents[id] = p.observeAgent(observer, agent, ownBikeID, addNoise)
+		}
+	}
+
+	lootBoxes := make(map[uuid.UUID]LootBoxDump)
+	for id, lootBox := range gameState.LootBoxes {
+		if inRange(lootBox.GetPosition()) {
+			lootBoxes[id] = lootBox
+		}
+	}
+	if hasBike {
+		for _, id := range nearestLootBoxes(centre, gameState.LootBoxes, p.MinVisibleLootBoxes) {
+			lootBoxes[id] = gameState.LootBoxes[id]
+		}
+	}
+	for id, lootBox := range lootBoxes {
+		lootBox.PhysicalState.Position = addNoise(id, lootBox.PhysicalState.Position)
+		lootBoxes[id] = lootBox
+	}
+
+	for id, bike := range bikes {
+		riders := make([]AgentDump, 0, len(bike.AgentIDs))
+		for _, riderID := range bike.AgentIDs {
+			riders = append(riders, agents[riderID])
+		}
+		bike.Agents = riders
+		if id != ownBikeID {
+			bike.PhysicalState.Position = addNoise(id, bike.PhysicalState.Position)
+		}
+		bikes[id] = bike
+	}
+
+	audis := make([]AudiDump, len(gameState.Audis))
+	for i, audi := range gameState.Audis {
+		audi.PhysicalState.Position = addNoise(audi.ID, audi.PhysicalState.Position)
+		audis[i] = audi
+	}
+
+	return GameStateDump{
+		Iteration: gameState.Iteration,
+		Agents:    agents,
+		Bikes:     bikes,
+		LootBoxes: lootBoxes,
+		Audis:     audis,
+		Terrain:   gameState.Terrain,
+	}
+}
+
+func (p PartialObservation) observeAgent(observer objects.IBaseBiker, agent AgentDump, ownBikeID uuid.UUID,
+	addNoise func(id uuid.UUID, position utils.Coordinates) utils.Coordinates) AgentDump {
+	if agent.ID == observer.GetID() {
+		return agent
+	}
+	if p.HidePrivateState {
+		agent.Reputation = nil
+		if agent.BikeID != ownBikeID {
+			agent.EnergyLevel = HiddenValue
+			agent.Points = HiddenValue
+		}
+	}
+	if agent.BikeID != ownBikeID {
+		agent.Location = addNoise(agent.ID, agent.Location)
+	}
+	return agent
+}
+
+// returns the ids of the n lootboxes closest to the position
+func nearestLootBoxes(position utils.Coordinates, lootBoxes map[uuid.UUID]LootBoxDump, n int) []uuid.UUID {
+	nearest := make([]uuid.UUID, 0, n)
+	for len(nearest) < n && len(nearest) < len(lootBoxes) {
+		minDistance := math.Inf(1)
+		var closest uuid.UUID
+	outer:
+		for id, lootBox := range lootBoxes {
+			for _, chosen := range nearest {
+				if chosen == id {
+					continue outer
+				}
+			}
+			if distance := physics.ComputeDistance(position, lootBox.GetPosition()); distance < minDistance {
+				minDistance, closest = distance, id
+			}
+		}
+		nearest = append(nearest, closest)
+	}
+	return nearest
+}
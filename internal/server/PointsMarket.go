@@ -0,0 +1,148 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"cmp"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// MarketModel describes the points market, on which agents trade points and colour rights for energy.
+// The zero value disables it
+type MarketModel struct {
+	Enabled bool
+	// number of rounds a colour right lasts once bought, colour rights can't be traded when 0
+	ColourRightDuration int
+}
+
+var PointsMarket = MarketModel{}
+
+type marketOrder struct {
+	objects.Order
+	agent objects.IBaseBiker
+}
+
+// collects the orders of the agents and clears the market of every asset with a call auction: the highest bids are
+// matched with the lowest asks as long as the bid price is at least the ask price, each trade at the midpoint of the two
+func (s *Server) RunPointsMarket() {
+	s.tradeLog = make([]objects.Trade, 0)
+	if !PointsMarket.Enabled {
+		return
+	}
+
+	bids := make(map[objects.Asset][]*marketOrder)
+	asks := make(map[objects.Asset][]*marketOrder)
+	for _, agent := range s.GetAgentMap() {
+		// agents can't be on both sides of the market of an asset
+		sides := make(map[objects.Asset]objects.OrderSide)
+		for _, order := range agent.DecideMarketOrders() {
+			// points are the same asset whatever the colour of the order
+			if order.Asset.Type == objects.Points {
+				order.Asset.Colour = 0
+			}
+			if side, ok := sides[order.Asset]; (ok && side != order.Side) || !s.isValidOrder(agent, order) {
+				continue
+			}
+			sides[order.Asset] = order.Side
+			if order.Side == objects.Bid {
+				bids[order.Asset] = append(bids[order.Asset], &marketOrder{Order: order, agent: agent})
+			} else {
+				asks[order.Asset] = append(asks[order.Asset], &marketOrder{Order: order, agent: agent})
+			}
+		}
+	}
+
+	for asset, assetBids := range bids {
+		s.clearMarket(asset, assetBids, asks[asset])
+	}
+}
+
+func (s *Server) isValidOrder(agent objects.IBaseBiker, order objects.Order) bool {
+	if order.Quantity < 1 || !isFiniteAmount(order.Price) || (order.Side != objects.Bid && order.Side != objects.Ask) {
+		return false
+	}
+	switch order.Asset.Type {
+	case objects.Points:
+		return true
+	case objects.ColourRight:
+		// a colour right is a single unit, which sellers can create once a round at no cost
+		if PointsMarket.ColourRightDuration == 0 || order.Quantity != 1 {
+			return false
+		}
+		return order.Side == objects.Bid || order.Asset.Colour == agent.GetColour()
+	default:
+		return false
+	}
+}
+
+func (s *Server) clearMarket(asset objects.Asset, bids []*marketOrder, asks []*marketOrder) {
+	// orders with the same price are filled in a random order
+	rand.Shuffle(len(bids), func(i, j int) { bids[i], bids[j] = bids[j], bids[i] })
+	rand.Shuffle(len(asks), func(i, j int) { asks[i], asks[j] = asks[j], asks[i] })
+	slices.SortStableFunc(bids, func(a, b *marketOrder) int { return cmp.Compare(b.Price, a.Price) })
+	slices.SortStableFunc(asks, func(a, b *marketOrder) int { return cmp.Compare(a.Price, b.Price) })
+
+	for i, j := 0, 0; i < len(bids) && j < len(asks) && bids[i].Price >= asks[j].Price; {
+		bid, ask := bids[i], asks[j]
+		price := (bid.Price + ask.Price) / 2
+		// orders are only filled as far as the buyer can pay and the seller has the points
+		quantity := min(bid.Quantity, ask.Quantity)
+		if price > 0 {
+			quantity = min(quantity, int(math.Floor(bid.agent.GetEnergyLevel()/price)))
+		}
+		if asset.Type == objects.Points {
+			quantity = min(quantity, ask.agent.GetPoints())
+		}
+
+		if quantity > 0 {
+			s.settleTrade(objects.Trade{
+				Asset:    asset,
+				Buyer:    bid.agent.GetID(),
+				Seller:   ask.agent.GetID(),
+				Quantity: quantity,
+				Price:    price,
+			})
+			bid.Quantity -= quantity
+			ask.Quantity -= quantity
+		}
+		if bid.Quantity == 0 || (quantity == 0 && bid.agent.GetEnergyLevel() < price) {
+			i++
+		}
+		if ask.Quantity == 0 || (quantity == 0 && asset.Type == objects.Points && ask.agent.GetPoints() == 0) {
+			j++
+		}
+	}
+}
+
+func (s *Server) settleTrade(trade objects.Trade) {
+	buyer, seller := s.GetAgentMap()[trade.Buyer], s.GetAgentMap()[trade.Seller]
+	energy := float64(trade.Quantity) * trade.Price
+	buyer.UpdateEnergyLevel(-energy)
+	seller.UpdateEnergyLevel(energy)
+
+	switch trade.Asset.Type {
+	case objects.Points:
+		seller.UpdatePoints(-trade.Quantity)
+		buyer.UpdatePoints(trade.Quantity)
+	case objects.ColourRight:
+		if _, ok := s.colourRights[trade.Buyer]; !ok {
+			s.colourRights[trade.Buyer] = make(map[utils.Colour]int)
+		}
+		// the right starts in the next round, and buying a right already held never shortens it
+		s.colourRights[trade.Buyer][trade.Asset.Colour] = max(s.colourRights[trade.Buyer][trade.Asset.Colour], s.round+PointsMarket.ColourRightDuration)
+	}
+
+	s.tradeLog = append(s.tradeLog, trade)
+	buyer.HandleTrade(trade)
+	seller.HandleTrade(trade)
+}
+
+// whether the agent holds a colour right for the colour in the current round
+func (s *Server) hasColourRight(agentID uuid.UUID, colour utils.Colour) bool {
+	lastRound, ok := s.colourRights[agentID][colour]
+	return ok && lastRound >= s.round
+}
@@ -2,10 +2,10 @@ package server
 
 import (
 	"SOMAS2023/internal/common/objects"
-	"SOMAS2023/internal/common/physics"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/common/voting"
 	"fmt"
+	"maps"
 	"slices"
 
 	"github.com/google/uuid"
@@ -14,7 +14,17 @@ import (
 func (s *Server) RunRoundLoop() {
 	// Capture dump of starting state
 	gameState := s.NewGameStateDump(0)
+	// the noise of the observations is sampled again every round
+	s.observationNoise = NewObservationNoise()
 	s.UpdateGameStates()
+	s.votes = newCastVotes()
+	s.treasuryFlows = make(map[uuid.UUID]*TreasuryFlows)
+	s.allocationLog = make([]AllocationDump, 0)
+	s.deathLog = make([]DeathDump, 0)
+	s.bikeEvents = make(map[uuid.UUID]*BikeEvents)
+
+	// sign the new contracts
+	s.RunContractNegotiation()
 
 	// get destination bikes from bikers not on bike
 	s.SetDestinationBikes()
@@ -22,33 +32,74 @@ func (s *Server) RunRoundLoop() {
 	// take care of agents that want to leave the bike and of the acceptance/ expulsion process
 	s.RunBikeSwitch(gameState)
 
+	// set the tax rate of every bike
+	s.RunTaxRateDecision()
+
 	// get the direction decisions and pedalling forces
 	s.RunActionProcess()
 
 	// The Audi makes a decision
 	s.audi.UpdateGameState(gameState)
 
-	// Move the mega bikes
+	// Steer the mega bikes and the audi, forces and orientations are kept for the whole round
 	for _, bike := range s.megaBikes {
 		// update mass dependent on number of agents on bike
 		bike.UpdateMass()
-		s.MovePhysicsObject(bike)
+		s.SteerPhysicsObject(bike)
 	}
+	s.SteerPhysicsObject(s.audi)
+
+	// Only the physics is integrated in the sub-steps, the collisions of every sub-step are resolved once at the end
+	// of them so that the agents are asked for their allocations once per lootbox
+	touched := make(map[uuid.UUID]map[uuid.UUID]bool)
+	hit := make(map[uuid.UUID]bool)
+	for step := 0; step < s.physicsEngine.SubSteps; step++ {
+		// Move the mega bikes
+		for _, bike := range s.megaBikes {
+			s.MovePhysicsObject(bike)
+		}
 
-	// Move the audi
-	s.MovePhysicsObject(s.audi)
+		// Move the audi
+		s.MovePhysicsObject(s.audi)
+
+		// Drifting lootboxes keep moving at their own velocity
+		for _, lootBox := range s.lootBoxes {
+			lootBox.SetPhysicalState(s.physicsEngine.Drift(lootBox.GetPhysicalState(), lootBox.GetOrientation()))
+		}
 
+		s.checkLootBoxCollisions(touched)
+		s.checkAudiCollisions(hit)
+	}
 	s.UpdateGameStates()
 
 	// Lootbox Distribution
-	s.LootboxCheckAndDistributions()
+	s.distributeLootBoxes(touched)
+
+	// Audi collisions
+	s.runOverBikes(hit)
+
+	// Decay the remaining lootboxes and remove the expired ones and those that drifted off the map
+	s.ageLootBoxes()
+
+	// Penalise the contract violations and make the contract payments
+	s.EnforceContracts()
+
+	// Repay the loans and make the voluntary energy transfers
+	s.RunEnergyTransfers()
+
+	// Pay the welfare top-ups and the ruler's spending from the treasuries
+	s.RunTreasurySpending()
+
+	// Rule on the accusations of the riders and sanction the convicted ones
+	s.RunJudiciary()
+
+	// Clear the points market
+	s.RunPointsMarket()
 
 	// Punish bikeless agents
 	s.punishBikelessAgents()
 
 	// Check if agents died
-	// Check Audi collision
-	s.AudiCollisionCheck()
 	s.unaliveAgents()
 	s.UpdateGameStates()
 
@@ -115,6 +166,7 @@ func (s *Server) HandleKickoutProcess() []uuid.UUID {
 
 				// get which agents are getting kicked out
 				agentsVotes = bike.KickOutAgent(weights)
+				maps.Copy(s.votes.kickout, bike.GetKickoutVotes())
 
 			case utils.Leadership:
 				// get the map of weights from the leader
@@ -123,14 +175,21 @@ func (s *Server) HandleKickoutProcess() []uuid.UUID {
 				weights := leader.DecideWeights(utils.Kickout)
 				// get which agents are getting kicked out
 				agentsVotes = bike.KickOutAgent(weights)
+				maps.Copy(s.votes.kickout, bike.GetKickoutVotes())
 
 			case utils.Dictatorship:
 				// in level 2 only the ruler can kick out people
 				dictator := s.GetAgentMap()[bike.GetRuler()]
 				agentsVotes = dictator.DecideKickOut()
+				dictatorVotes := make(map[uuid.UUID]int, len(agentsVotes))
+				for _, agentID := range agentsVotes {
+					dictatorVotes[agentID] = 1
+				}
+				s.votes.kickout[dictator.GetID()] = dictatorVotes
 			}
 
 			// perform kickout
+			s.updateBikeEvents(bike.GetID()).Kickouts += len(agentsVotes)
 			leaderKickedOut := false
 			allKicked = append(allKicked, agentsVotes...)
 			for _, agentID := range agentsVotes {
@@ -157,7 +216,11 @@ func (s *Server) GetLeavingDecisions(gameState objects.IGameState) []uuid.UUID {
 	leavingAgents := make([]uuid.UUID, 0)
 	for agentId, agent := range s.GetAgentMap() {
 		if agent.GetBikeStatus() {
-			agent.UpdateGameState(gameState)
+			if dump, ok := gameState.(GameStateDump); ok {
+				agent.UpdateGameState(ObservationPolicy.Observe(agent, dump, s.observationNoise))
+			} else {
+				agent.UpdateGameState(gameState)
+			}
 			agent.UpdateAgentInternalState()
 			switch agent.DecideAction() {
 			case objects.Pedal:
@@ -171,6 +234,7 @@ func (s *Server) GetLeavingDecisions(gameState objects.IGameState) []uuid.UUID {
 				// the request is handled at the beginning of the next round, so the moving
 				// will only be finalised then
 				leavingAgents = append(leavingAgents, agentId)
+				s.updateBikeEvents(agent.GetBike()).Leaves++
 				s.RemoveAgentFromBike(agent)
 				fmt.Printf("Agent %s left the bike \n", agentId)
 			default:
@@ -201,6 +265,7 @@ func (s *Server) ProcessJoiningRequests(inLimbo []uuid.UUID) {
 				if i <= utils.BikersOnBike {
 					acceptedAgent := s.GetAgentMap()[pendingAgent]
 					s.AddAgentToBike(acceptedAgent)
+					s.updateBikeEvents(bikeID).Joins++
 				} else {
 					break
 				}
@@ -265,6 +330,7 @@ func (s *Server) ProcessJoiningRequests(inLimbo []uuid.UUID) {
 				accepted := acceptedRanked[i]
 				acceptedAgent := s.GetAgentMap()[accepted]
 				s.AddAgentToBike(acceptedAgent)
+				s.updateBikeEvents(bikeID).Joins++
 			}
 		}
 	}
@@ -314,18 +380,21 @@ func (s *Server) RunActionProcess() {
 	}
 }
 
-func (s *Server) MovePhysicsObject(po objects.IPhysicsObject) {
-
+func (s *Server) SteerPhysicsObject(po objects.IPhysicsObject) {
 	// Server requests to update their force and orientation based on agents pedaling
 	po.UpdateForce()
-	force := po.GetForce()
 	po.UpdateOrientation()
+}
+
+// moves the object by a single sub-step of the physics engine, with the force and orientation set by SteerPhysicsObject
+func (s *Server) MovePhysicsObject(po objects.IPhysicsObject) {
+	force := po.GetForce()
 	orientation := po.GetOrientation()
 	// Obtains the current xstate (i.e. velocity, acceleration, position, mass)
 	initialState := po.GetPhysicalState()
 
 	// Generates a new state based on the force and orientation
-	finalState := physics.GenerateNewState(initialState, force, orientation)
+	finalState := s.physicsEngine.Step(initialState, force, orientation)
 
 	// Sets the new physical state (i.e. updates gamestate)
 	po.SetPhysicalState(finalState)
@@ -347,13 +416,30 @@ func (s *Server) GetWinningDirection(finalVotes map[uuid.UUID]voting.LootboxVote
 }
 
 func (s *Server) AudiCollisionCheck() {
-	// Check collision for audi with any megaBike
+	hit := make(map[uuid.UUID]bool)
+	s.checkAudiCollisions(hit)
+	s.runOverBikes(hit)
+}
+
+// records the bikes the audi collides with at this sub-step of the round
+func (s *Server) checkAudiCollisions(hit map[uuid.UUID]bool) {
 	for bikeid, megabike := range s.GetMegaBikes() {
 		if s.audi.CheckForCollision(megabike) {
+			hit[bikeid] = true
+		}
+	}
+}
+
+// kills the riders of the bikes hit by the audi
+func (s *Server) runOverBikes(hit map[uuid.UUID]bool) {
+	for bikeid := range hit {
+		if megabike, ok := s.megaBikes[bikeid]; ok {
 			// Collision detected
 			fmt.Printf("Collision detected between Audi and MegaBike %s \n", bikeid)
+			s.updateBikeEvents(bikeid).AudiKills += len(megabike.GetAgents())
 			for _, agentToDelete := range megabike.GetAgents() {
 				fmt.Printf("Agent %s killed by Audi \n", agentToDelete.GetID())
+				s.logDeath(agentToDelete, AudiCollision)
 				s.RemoveAgent(agentToDelete)
 			}
 			if utils.AudiRemovesMegaBike {
@@ -365,93 +451,122 @@ func (s *Server) AudiCollisionCheck() {
 }
 
 func (s *Server) LootboxCheckAndDistributions() {
+	touched := make(map[uuid.UUID]map[uuid.UUID]bool)
+	s.checkLootBoxCollisions(touched)
+	s.distributeLootBoxes(touched)
+}
 
-	// checks how many bikes have looted one lootbox to split it between them
-	looted := make(map[uuid.UUID]int)
-	for _, megabike := range s.GetMegaBikes() {
+// records the lootboxes touched by every bike at this sub-step of the round
+func (s *Server) checkLootBoxCollisions(touched map[uuid.UUID]map[uuid.UUID]bool) {
+	for bikeid, megabike := range s.GetMegaBikes() {
 		for lootid, lootbox := range s.GetLootBoxes() {
 			if megabike.CheckForCollision(lootbox) { // && len(megabike.GetAgents()) != 0
-				if value, ok := looted[lootid]; ok {
-					looted[lootid] = value + 1
-				} else {
-					looted[lootid] = 1
+				if _, ok := touched[bikeid]; !ok {
+					touched[bikeid] = make(map[uuid.UUID]bool)
 				}
+				touched[bikeid][lootid] = true
 			}
 		}
 	}
-	for bikeid, megabike := range s.GetMegaBikes() {
-		for lootid, lootbox := range s.GetLootBoxes() {
-			if megabike.CheckForCollision(lootbox) {
-				// Collision detected
-				fmt.Printf("Collision detected between MegaBike %s and LootBox %s \n", bikeid, lootid)
-				agents := megabike.GetAgents()
-				totAgents := len(agents)
-
-				if totAgents > 0 {
-					gov := s.GetMegaBikes()[bikeid].GetGovernance()
-					var winningAllocation voting.IdVoteMap
-					switch gov {
-					case utils.Democracy:
-						allAllocations := make(map[uuid.UUID]voting.IdVoteMap)
-						for _, agent := range agents {
-							// the agents return their ideal lootbox split by assigning a number between 0 and 1 to
-							// each biker on their bike (including themselves)
-							allAllocations[agent.GetID()] = agent.DecideAllocation()
-						}
+}
 
-						Iallocations := make(map[uuid.UUID]voting.IVoter)
-						for i, v := range allAllocations {
-							Iallocations[i] = v
-						}
-						// TODO handle error
-						// make weights of 1 for all agents
-						weights := make(map[uuid.UUID]float64)
+// splits the lootboxes touched during the round between the riders of the bikes that touched them
+func (s *Server) distributeLootBoxes(touched map[uuid.UUID]map[uuid.UUID]bool) {
+	// checks how many bikes have looted one lootbox to split it between them
+	looted := make(map[uuid.UUID]int)
+	for _, lootids := range touched {
+		for lootid := range lootids {
+			looted[lootid]++
+		}
+	}
+	for bikeid, lootids := range touched {
+		megabike, ok := s.megaBikes[bikeid]
+		if !ok {
+			continue
+		}
+		for lootid := range lootids {
+			lootbox := s.lootBoxes[lootid]
+			// Collision detected
+			fmt.Printf("Collision detected between MegaBike %s and LootBox %s \n", bikeid, lootid)
+			agents := megabike.GetAgents()
+			totAgents := len(agents)
+
+			if totAgents > 0 {
+				s.updateBikeEvents(bikeid).LootBoxes++
+				gov := s.GetMegaBikes()[bikeid].GetGovernance()
+				var winningAllocation voting.IdVoteMap
+				switch gov {
+				case utils.Democracy:
+					allAllocations := make(map[uuid.UUID]voting.IdVoteMap)
+					for _, agent := range agents {
+						// the agents return their ideal lootbox split by assigning a number between 0 and 1 to
+						// each biker on their bike (including themselves)
+						allAllocations[agent.GetID()] = agent.DecideAllocation()
+						s.votes.allocation[agent.GetID()] = allAllocations[agent.GetID()]
+					}
+
+					Iallocations := make(map[uuid.UUID]voting.IVoter)
+					for i, v := range allAllocations {
+						Iallocations[i] = v
+					}
+					// TODO handle error
+					// make weights of 1 for all agents
+					weights := make(map[uuid.UUID]float64)
+					for _, agent := range agents {
+						weights[agent.GetID()] = 1.0
+					}
+					winningAllocation = voting.CumulativeDist(Iallocations, weights)
+				case utils.Leadership:
+					// get the map of weights from the leader
+					leader := s.GetAgentMap()[megabike.GetRuler()]
+					weights := leader.DecideWeights(utils.Allocation)
+				outer:
+					for id := range weights {
 						for _, agent := range agents {
-							weights[agent.GetID()] = 1.0
-						}
-						winningAllocation = voting.CumulativeDist(Iallocations, weights)
-					case utils.Leadership:
-						// get the map of weights from the leader
-						leader := s.GetAgentMap()[megabike.GetRuler()]
-						weights := leader.DecideWeights(utils.Allocation)
-					outer:
-						for id := range weights {
-							for _, agent := range agents {
-								if agent.GetID() == id {
-									continue outer
-								}
+							if agent.GetID() == id {
+								continue outer
 							}
-							panic("leader gave weight to an agent that isn't on the bike")
-						}
-						// get allocation votes from each agent
-						allAllocations := make(map[uuid.UUID]voting.IdVoteMap)
-						for _, agent := range agents {
-							allAllocations[agent.GetID()] = agent.DecideAllocation()
 						}
-						Iallocations := make(map[uuid.UUID]voting.IVoter)
-						for i, v := range allAllocations {
-							Iallocations[i] = v
-						}
-						winningAllocation = voting.CumulativeDist(Iallocations, weights)
-					case utils.Dictatorship:
-						// dictator decides the allocation
-						leader := s.GetAgentMap()[megabike.GetRuler()]
-						winningAllocation = leader.DecideDictatorAllocation()
+						panic("leader gave weight to an agent that isn't on the bike")
+					}
+					// get allocation votes from each agent
+					allAllocations := make(map[uuid.UUID]voting.IdVoteMap)
+					for _, agent := range agents {
+						allAllocations[agent.GetID()] = agent.DecideAllocation()
+						s.votes.allocation[agent.GetID()] = allAllocations[agent.GetID()]
 					}
+					Iallocations := make(map[uuid.UUID]voting.IVoter)
+					for i, v := range allAllocations {
+						Iallocations[i] = v
+					}
+					winningAllocation = voting.CumulativeDist(Iallocations, weights)
+				case utils.Dictatorship:
+					// dictator decides the allocation
+					leader := s.GetAgentMap()[megabike.GetRuler()]
+					winningAllocation = leader.DecideDictatorAllocation()
+				}
 
-					bikeShare := float64(looted[lootid]) // how many other bikes have looted this box
-
-					for agentID, allocation := range winningAllocation {
-						fmt.Printf("total loot: %f \n", lootbox.GetTotalResources())
-						lootShare := allocation * (lootbox.GetTotalResources() / bikeShare)
-						agent := s.GetAgentMap()[agentID]
-						// Allocate loot based on the calculated utility share
-						fmt.Printf("Agent %s allocated %f loot \n", agent.GetID(), lootShare)
-						agent.UpdateEnergyLevel(lootShare)
-						// Allocate points if the box is of the right colour
-						if agent.GetColour() == lootbox.GetColour() {
-							agent.UpdatePoints(utils.PointsFromSameColouredLootBox)
-						}
+				bikeShare := float64(looted[lootid]) // how many other bikes have looted this box
+				s.logAllocation(megabike, lootbox, lootbox.GetTotalResources()/bikeShare, winningAllocation)
+
+				for agentID, allocation := range winningAllocation {
+					fmt.Printf("total loot: %f \n", lootbox.GetTotalResources())
+					lootShare := allocation * (lootbox.GetTotalResources() / bikeShare)
+					agent := s.GetAgentMap()[agentID]
+					// Pay the tax on the loot into the treasury of the bike
+					if Treasury.Enabled {
+						tax := lootShare * megabike.GetTaxRate()
+						megabike.UpdateTreasury(tax)
+						s.updateTreasuryFlows(bikeid).Tax += tax
+						lootShare -= tax
+					}
+					// Allocate loot based on the calculated utility share
+					fmt.Printf("Agent %s allocated %f loot \n", agent.GetID(), lootShare)
+					agent.UpdateEnergyLevel(lootShare)
+					// Allocate points if the box is of the right colour, or if the agent bought the right to its colour
+					if agent.GetColour() == lootbox.GetColour() || s.hasColourRight(agentID, lootbox.GetColour()) {
+						agent.UpdatePoints(utils.PointsFromSameColouredLootBox)
+						s.updateBikeEvents(bikeid).ColourPoints += utils.PointsFromSameColouredLootBox
 					}
 				}
 			}
@@ -466,6 +581,41 @@ func (s *Server) LootboxCheckAndDistributions() {
 	}
 }
 
+// records the loot allocated to every rider of the bike, as a fraction of the loot of the bike, with their pedalling forces
+func (s *Server) logAllocation(bike objects.IMegaBike, lootBox objects.ILootBox, loot float64, allocation voting.IdVoteMap) {
+	total := 0.0
+	for _, share := range allocation {
+		total += share
+	}
+	dump := AllocationDump{
+		Bike:       bike.GetID(),
+		LootBox:    lootBox.GetID(),
+		Governance: bike.GetGovernance(),
+		Loot:       loot,
+		Shares:     make(map[uuid.UUID]float64, len(allocation)),
+		Pedals:     make(map[uuid.UUID]float64, len(bike.GetAgents())),
+	}
+	for id, share := range allocation {
+		if total > 0 {
+			dump.Shares[id] = share / total
+		}
+	}
+	for _, agent := range bike.GetAgents() {
+		dump.Pedals[agent.GetID()] = agent.GetForces().Pedal
+	}
+	s.allocationLog = append(s.allocationLog, dump)
+}
+
+// records the death of an agent, with the bike it was riding (if any) and its governance
+func (s *Server) logDeath(agent objects.IBaseBiker, cause DeathCause) {
+	death := DeathDump{Agent: agent.GetID(), Cause: cause, Round: s.round, Governance: utils.Invalid}
+	if bikeID, ok := s.megaBikeRiders[agent.GetID()]; ok {
+		death.Bike = bikeID
+		death.Governance = s.megaBikes[bikeID].GetGovernance()
+	}
+	s.deathLog = append(s.deathLog, death)
+}
+
 func (s *Server) SetDestinationBikes() {
 	for _, agent := range s.GetAgentMap() {
 		if !agent.GetBikeStatus() {
@@ -478,11 +628,26 @@ func (s *Server) unaliveAgents() {
 	for id, agent := range s.GetAgentMap() {
 		if agent.GetEnergyLevel() < 0 {
 			fmt.Printf("Agent %s got game ended\n", id)
+			s.logDeath(agent, Starvation)
 			s.RemoveAgent(agent)
 		}
 	}
 }
 
+func (s *Server) ageLootBoxes() {
+	for id, lootBox := range s.lootBoxes {
+		lootBox.Age()
+		if lootBox.GetRemainingLifetime() == 0 {
+			fmt.Printf("LootBox %s expired \n", id)
+			delete(s.lootBoxes, id)
+		} else if !isOnGrid(lootBox.GetPosition()) {
+			// lootboxes that drifted off an open map are replaced by new ones on it
+			fmt.Printf("LootBox %s drifted off the map \n", id)
+			delete(s.lootBoxes, id)
+		}
+	}
+}
+
 func (s *Server) punishBikelessAgents() {
 	for id, agent := range s.GetAgentMap() {
 		if _, ok := s.megaBikeRiders[id]; !ok {
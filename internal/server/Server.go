@@ -2,11 +2,15 @@ package server
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/terrain"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/common/voting"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	baseserver "github.com/MattSScott/basePlatformSOMAS/BaseServer"
 	"github.com/google/uuid"
@@ -16,11 +20,15 @@ const LootBoxCount = BikerAgentCount * 2.5 // 2.5 lootboxes available per Agent
 const MegaBikeCount = 11                   // Megabikes should have 8 riders
 const BikerAgentCount = 56                 // 56 agents in total
 
+// OutputDirectory is the directory in which the server writes the game dump and the statistics at the end of a game
+var OutputDirectory = "."
+
 type IBaseBikerServer interface {
 	baseserver.IServer[objects.IBaseBiker]
 	GetMegaBikes() map[uuid.UUID]objects.IMegaBike
 	GetLootBoxes() map[uuid.UUID]objects.ILootBox
 	GetAudi() objects.IAudi
+	GetTerrain() terrain.ITerrain
 	GetJoiningRequests([]uuid.UUID) map[uuid.UUID][]uuid.UUID
 	GetRandomBikeId() uuid.UUID
 	RulerElection(agents []objects.IBaseBiker, governance utils.Governance) uuid.UUID
@@ -39,11 +47,12 @@ type IBaseBikerServer interface {
 	ResetGameState()
 	GetDeadAgents() map[uuid.UUID]objects.IBaseBiker
 	UpdateGameStates()
+	AddRoundObserver(observer RoundObserver)
 }
 
 type Server struct {
 	baseserver.BaseServer[objects.IBaseBiker]
-	lootBoxes map[uuid.UUID]objects.ILootBox
+	lootBoxes map[uuid.UUID]objects.IServerLootBox
 	megaBikes map[uuid.UUID]objects.IMegaBike
 	// megaBikeRiders is a mapping from Agent ID -> ID of the bike that they are riding
 	// helps with efficiently managing ridership status
@@ -51,17 +60,61 @@ type Server struct {
 	audi            objects.IAudi
 	deadAgents      map[uuid.UUID]objects.IBaseBiker
 	foundingChoices map[uuid.UUID]utils.Governance
+	terrain         terrain.ITerrain
+	physicsEngine   *physics.Engine
+	// round of the current game, used by the time-varying parts of the environment
+	round int
+	// votes cast in the current round and messages delivered in the last messaging session
+	votes      castVotes
+	messageLog []MessageDump
+	// all the contracts proposed and loans made in the current game
+	contracts []*ContractDump
+	loans     []*LoanDump
+	// energy transfers made in the current round
+	transferLog []TransferDump
+	// energy paid into and out of the treasury of each bike in the current round
+	treasuryFlows map[uuid.UUID]*TreasuryFlows
+	// accusations ruled on in the current round, and number of convictions of each agent in the current game
+	caseLog     []CaseDump
+	convictions map[uuid.UUID]int
+	// what happened to each bike in the current round
+	bikeEvents map[uuid.UUID]*BikeEvents
+	// lootbox allocations made and deaths in the current round
+	allocationLog []AllocationDump
+	deathLog      []DeathDump
+	// trades made in the current round, and last round of the colour rights bought by each agent
+	tradeLog     []objects.Trade
+	colourRights map[uuid.UUID]map[utils.Colour]int
+	// noise of the positions observed by the agents in the current round
+	observationNoise *ObservationNoise
+	// iteration of the game being played, and the observers notified of every round of it
+	iteration  int
+	observers  []RoundObserver
+	statistics *StatisticsAccumulator
 }
 
 func Initialize(iterations int) IBaseBikerServer {
+	terrainMap, err := terrain.LoadTerrain(utils.TerrainFile)
+	if err != nil {
+		panic(err)
+	}
 	server := &Server{
-		BaseServer:     *baseserver.CreateServer[objects.IBaseBiker](GetAgentGenerators(), iterations),
-		lootBoxes:      make(map[uuid.UUID]objects.ILootBox),
-		megaBikes:      make(map[uuid.UUID]objects.IMegaBike),
-		megaBikeRiders: make(map[uuid.UUID]uuid.UUID),
-		deadAgents:     make(map[uuid.UUID]objects.IBaseBiker),
-		audi:           objects.GetIAudi(),
+		BaseServer:       *baseserver.CreateServer[objects.IBaseBiker](GetAgentGenerators(), iterations),
+		lootBoxes:        make(map[uuid.UUID]objects.IServerLootBox),
+		megaBikes:        make(map[uuid.UUID]objects.IMegaBike),
+		megaBikeRiders:   make(map[uuid.UUID]uuid.UUID),
+		deadAgents:       make(map[uuid.UUID]objects.IBaseBiker),
+		audi:             objects.GetIAudi(),
+		terrain:          terrainMap,
+		physicsEngine:    physics.NewEngine(utils.PhysicsTimeStep, utils.PhysicsSubSteps, utils.PhysicsIntegrator, terrainMap),
+		votes:            newCastVotes(),
+		convictions:      make(map[uuid.UUID]int),
+		colourRights:     make(map[uuid.UUID]map[utils.Colour]int),
+		statistics:       NewStatisticsAccumulator(),
+		observationNoise: NewObservationNoise(),
 	}
+	server.AddRoundObserver(server.statistics)
+	server.placeOnPassableTerrain(server.audi)
 	server.replenishLootBoxes()
 	server.replenishMegaBikes()
 
@@ -112,7 +165,7 @@ func (s *Server) GetDeadAgents() map[uuid.UUID]objects.IBaseBiker {
 }
 
 func (s *Server) outputResults(gameStates [][]GameStateDump) {
-	statistics := CalculateStatistics(gameStates)
+	statistics := s.statistics.Statistics()
 
 	statisticsJson, err := json.MarshalIndent(statistics.Average, "", "    ")
 	if err != nil {
@@ -120,55 +173,51 @@ func (s *Server) outputResults(gameStates [][]GameStateDump) {
 	}
 	fmt.Println("Average Statistics:\n" + string(statisticsJson))
 
-	file, err := os.Create("statistics.xlsx")
+	teamsJson, err := json.MarshalIndent(statistics.ByTeam, "", "    ")
 	if err != nil {
 		panic(err)
 	}
-	defer file.Close()
-	if err := statistics.ToSpreadsheet().Write(file); err != nil {
-		panic(err)
-	}
+	fmt.Println("Team Statistics:\n" + string(teamsJson))
 
-	file, err = os.Create("game_dump.json")
-	if err != nil {
+	if err := os.MkdirAll(OutputDirectory, 0755); err != nil {
 		panic(err)
 	}
-	defer file.Close()
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "    ")
-	if err := encoder.Encode(gameStates); err != nil {
-		panic(err)
+	writeOutput := func(name string, write func(w io.Writer) error) {
+		file, err := os.Create(filepath.Join(OutputDirectory, name))
+		if err != nil {
+			panic(err)
+		}
+		defer file.Close()
+		if err := write(file); err != nil {
+			panic(err)
+		}
 	}
+	writeOutput("statistics.xlsx", statistics.ToSpreadsheet().Write)
+	writeOutput("statistics.json", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(statistics)
+	})
+	writeOutput("survival.json", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(statistics.Survival)
+	})
+	writeOutput("kaplan_meier.csv", statistics.Survival.WriteKaplanMeierCSV)
+	writeOutput("deaths.csv", statistics.Survival.WriteDeathsCSV)
+
+	writeOutput("trust_network.graphml", func(w io.Writer) error { return WriteGraphML(w, s.statistics.TrustGraphs()) })
+	writeOutput("trust_network.gexf", func(w io.Writer) error { return WriteGEXF(w, s.statistics.TrustGraphs()) })
+	writeOutput("game_dump.json", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "    ")
+		return encoder.Encode(gameStates)
+	})
 }
 
 func (s *Server) UpdateGameStates() {
 	gs := s.NewGameStateDump(0)
 	for _, agent := range s.GetAgentMap() {
-		agent.UpdateGameState(gs)
-	}
-}
-
-// had to override to address the fact that agents only have access to the game dump
-// version of agents, so if the recipients are set to be those it will panic as they
-// can't call the handler functions
-func (s *Server) RunMessagingSession() {
-	agentArray := s.GenerateAgentArrayFromMap()
-
-	for _, agent := range s.GetAgentMap() {
-		allMessages := agent.GetAllMessages(agentArray)
-		for _, msg := range allMessages {
-			recipients := msg.GetRecipients()
-			// make recipient list with actual agents
-			usableRecipients := make([]objects.IBaseBiker, len(recipients))
-			for i, recipient := range recipients {
-				usableRecipients[i] = s.GetAgentMap()[recipient.GetID()]
-			}
-			for _, recip := range usableRecipients {
-				if agent.GetID() == recip.GetID() {
-					continue
-				}
-				msg.InvokeMessageHandler(recip)
-			}
-		}
+		agent.UpdateGameState(ObservationPolicy.Observe(agent, gs, s.observationNoise))
 	}
 }
@@ -2,6 +2,7 @@ package server
 
 import (
 	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/terrain"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/common/voting"
 	"cmp"
@@ -18,15 +19,48 @@ func (s *Server) RunSimLoop(iterations int) []GameStateDump {
 	s.FoundingInstitutions()
 
 	// run this for n iterations
-	gameStates := []GameStateDump{s.NewGameStateDump(-1)}
+	gameStates := []GameStateDump{s.observeRound(s.newRoundDump(-1))}
 	for i := 0; i < iterations; i++ {
+		s.round = i
 		s.RunRoundLoop()
-		gameStates = append(gameStates, s.NewGameStateDump(i))
+		gameStates = append(gameStates, s.observeRound(s.newRoundDump(i)))
 	}
 
+	s.iteration++
 	return gameStates
 }
 
+func (s *Server) AddRoundObserver(observer RoundObserver) {
+	s.observers = append(s.observers, observer)
+}
+
+// notifies the observers of the round and returns its dump
+func (s *Server) observeRound(gameState GameStateDump) GameStateDump {
+	for _, observer := range s.observers {
+		observer.ObserveRound(s.iteration, gameState)
+	}
+	return gameState
+}
+
+// the dump of a round also records the messages sent at the end of it, the contracts, the energy transfers, the loans
+// the judiciary cases, the lootbox allocations, the deaths and the trades, which are not part of the game state given to the agents.
+// The initial dump of the iteration also records its terrain
+func (s *Server) newRoundDump(iteration int) GameStateDump {
+	gameState := s.NewGameStateDump(iteration)
+	gameState.Messages = s.messageLog
+	gameState.Contracts = s.getRoundContracts()
+	gameState.Transfers = s.transferLog
+	gameState.Loans = s.getRoundLoans()
+	gameState.Cases = s.caseLog
+	gameState.Trades = s.tradeLog
+	gameState.Allocations = s.allocationLog
+	gameState.Deaths = s.deathLog
+	if iteration == -1 {
+		gameState.TerrainFeatures = terrain.Copy(s.terrain)
+	}
+	return gameState
+}
+
 func (s *Server) ResetGameState() {
 	// kick everyone off bikes
 	for _, agent := range s.GetAgentMap() {
@@ -63,12 +97,28 @@ func (s *Server) ResetGameState() {
 
 	for _, bike := range s.GetMegaBikes() {
 		bike.SetRuler(uuid.Nil)
+		bike.UpdateTreasury(-bike.GetTreasury())
+		bike.SetTaxRate(0)
 	}
 
 	for _, agent := range s.GetAgentMap() {
 		agent.SetBike(uuid.Nil)
 	}
 
+	s.round = 0
+	s.votes = newCastVotes()
+	s.contracts = nil
+	s.loans = nil
+	s.transferLog = nil
+	s.treasuryFlows = make(map[uuid.UUID]*TreasuryFlows)
+	s.caseLog = nil
+	s.convictions = make(map[uuid.UUID]int)
+	s.tradeLog = nil
+	s.allocationLog = nil
+	s.deathLog = nil
+	s.bikeEvents = make(map[uuid.UUID]*BikeEvents)
+	s.observationNoise = NewObservationNoise()
+	s.colourRights = make(map[uuid.UUID]map[utils.Colour]int)
 	s.replenishLootBoxes()
 	s.replenishMegaBikes()
 }
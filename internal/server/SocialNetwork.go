@@ -0,0 +1,306 @@
+package server
+
+import (
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// agents trust each other when their reputation is at least the threshold, the network metrics are computed on the
+// graph of these trust relations while the exported graphs keep every reputation as the weight of an edge
+var TrustThreshold = 0.5
+
+// TrustGraph is the directed graph of the reputations of the agents alive at the end of a round,
+// with an edge from every agent to each agent it holds a reputation of
+type TrustGraph struct {
+	Iteration int
+	Round     int
+	Nodes     []TrustNode
+	Edges     []TrustEdge
+}
+
+type TrustNode struct {
+	ID                 uuid.UUID
+	Team               string
+	Colour             string
+	Bike               uuid.UUID
+	Community          int
+	InDegreeCentrality float64
+}
+
+type TrustEdge struct {
+	Source uuid.UUID
+	Target uuid.UUID
+	Weight float64 // reputation of the target held by the source
+}
+
+// NetworkMetrics describe the trust graph of a round
+type NetworkMetrics struct {
+	Round       int     `json:"round"`
+	Agents      int     `json:"agents"`
+	TrustEdges  int     `json:"trust_edges"`
+	Reciprocity float64 `json:"reciprocity"` // fraction of the trust relations that are returned
+	Clustering  float64 `json:"clustering"`  // mean local clustering coefficient of the undirected trust graph
+	Communities int     `json:"communities"`
+	Modularity  float64 `json:"modularity"`
+	// normalised mutual information between the communities and the bikes of the riders, and the teams of the agents
+	BikeAlignment      float64               `json:"bike_alignment"`
+	TeamAlignment      float64               `json:"team_alignment"`
+	InDegreeCentrality map[uuid.UUID]float64 `json:"in_degree_centrality"`
+}
+
+// BuildTrustNetwork builds the trust graph of every round of every iteration, with the communities and centrality of the agents,
+// and the metrics of the graphs of each iteration
+func BuildTrustNetwork(gameStates [][]GameStateDump) ([]TrustGraph, [][]NetworkMetrics) {
+	graphs := make([]TrustGraph, 0)
+	metrics := make([][]NetworkMetrics, 0, len(gameStates))
+	for i, iteration := range gameStates {
+		iterationMetrics := make([]NetworkMetrics, 0, len(iteration))
+		for _, gameState := range iteration {
+			if graph, roundMetrics, ok := trustGraphOf(i, gameState); ok {
+				iterationMetrics = append(iterationMetrics, roundMetrics)
+				graphs = append(graphs, graph)
+			}
+		}
+		metrics = append(metrics, iterationMetrics)
+	}
+	return graphs, metrics
+}
+
+// the analysed trust graph of a game state of an iteration and its metrics, none for the initial state of the
+// iteration, which has no reputations yet
+func trustGraphOf(iteration int, gameState GameStateDump) (TrustGraph, NetworkMetrics, bool) {
+	if gameState.Iteration < 0 {
+		return TrustGraph{}, NetworkMetrics{}, false
+	}
+	graph := newTrustGraph(gameState)
+	graph.Iteration = iteration
+	metrics := analyseTrustGraph(&graph)
+	return graph, metrics, true
+}
+
+func newTrustGraph(gameState GameStateDump) TrustGraph {
+	graph := TrustGraph{Round: gameState.Iteration}
+	ids := allAgentIDs(gameState)
+	slices.SortFunc(ids, compareUUIDs)
+	for _, id := range ids {
+		agent := gameState.Agents[id]
+		graph.Nodes = append(graph.Nodes, TrustNode{ID: id, Team: teamName(agent), Colour: agent.ColourString, Bike: agent.BikeID})
+		targets := make([]uuid.UUID, 0, len(agent.Reputation))
+		for target := range agent.Reputation {
+			if _, alive := gameState.Agents[target]; alive && target != id {
+				targets = append(targets, target)
+			}
+		}
+		slices.SortFunc(targets, compareUUIDs)
+		for _, target := range targets {
+			graph.Edges = append(graph.Edges, TrustEdge{Source: id, Target: target, Weight: agent.Reputation[target]})
+		}
+	}
+	return graph
+}
+
+// sets the communities and centrality of the nodes of the graph, and returns its metrics
+func analyseTrustGraph(graph *TrustGraph) NetworkMetrics {
+	n := len(graph.Nodes)
+	index := make(map[uuid.UUID]int, n)
+	for i, node := range graph.Nodes {
+		index[node.ID] = i
+	}
+	trusts := make([]map[int]bool, n)
+	neighbours := make([]map[int]bool, n)
+	for i := range graph.Nodes {
+		trusts[i] = make(map[int]bool)
+		neighbours[i] = make(map[int]bool)
+	}
+	for _, edge := range graph.Edges {
+		if edge.Weight >= TrustThreshold {
+			source, target := index[edge.Source], index[edge.Target]
+			trusts[source][target] = true
+			neighbours[source][target] = true
+			neighbours[target][source] = true
+		}
+	}
+
+	metrics := NetworkMetrics{Round: graph.Round, Agents: n, InDegreeCentrality: make(map[uuid.UUID]float64, n)}
+	inDegree := make([]int, n)
+	reciprocated := 0
+	for source, targets := range trusts {
+		for target := range targets {
+			metrics.TrustEdges++
+			inDegree[target]++
+			if trusts[target][source] {
+				reciprocated++
+			}
+		}
+	}
+	if metrics.TrustEdges > 0 {
+		metrics.Reciprocity = float64(reciprocated) / float64(metrics.TrustEdges)
+	}
+	for i := range graph.Nodes {
+		if n > 1 {
+			graph.Nodes[i].InDegreeCentrality = float64(inDegree[i]) / float64(n-1)
+		}
+		metrics.InDegreeCentrality[graph.Nodes[i].ID] = graph.Nodes[i].InDegreeCentrality
+	}
+
+	clustering := make([]float64, n)
+	for i := range graph.Nodes {
+		clustering[i] = localClustering(neighbours, i)
+	}
+	metrics.Clustering = mean(clustering)
+
+	communities := labelPropagation(neighbours)
+	bikes := make(map[int]int)
+	bikeIDs := make(map[uuid.UUID]int)
+	teams := make([]int, n)
+	teamIDs := make(map[string]int)
+	for i := range graph.Nodes {
+		graph.Nodes[i].Community = communities[i]
+		metrics.Communities = max(metrics.Communities, communities[i]+1)
+		if _, ok := teamIDs[graph.Nodes[i].Team]; !ok {
+			teamIDs[graph.Nodes[i].Team] = len(teamIDs)
+		}
+		teams[i] = teamIDs[graph.Nodes[i].Team]
+		if bike := graph.Nodes[i].Bike; bike != uuid.Nil {
+			if _, ok := bikeIDs[bike]; !ok {
+				bikeIDs[bike] = len(bikeIDs)
+			}
+			bikes[i] = bikeIDs[bike]
+		}
+	}
+	metrics.Modularity = modularity(neighbours, communities)
+	metrics.TeamAlignment = normalisedMutualInformation(communities, teams)
+
+	// only the riders have a bike to align with
+	riderCommunities := make([]int, 0, len(bikes))
+	riderBikes := make([]int, 0, len(bikes))
+	for i := range graph.Nodes {
+		if bike, ok := bikes[i]; ok {
+			riderCommunities = append(riderCommunities, communities[i])
+			riderBikes = append(riderBikes, bike)
+		}
+	}
+	metrics.BikeAlignment = normalisedMutualInformation(riderCommunities, riderBikes)
+	return metrics
+}
+
+// fraction of the pairs of neighbours of the node that are neighbours themselves, 0 with fewer than 2 neighbours
+func localClustering(neighbours []map[int]bool, node int) float64 {
+	degree := len(neighbours[node])
+	if degree < 2 {
+		return 0
+	}
+	links := 0
+	for a := range neighbours[node] {
+		for b := range neighbours[node] {
+			if a < b && neighbours[a][b] {
+				links++
+			}
+		}
+	}
+	return float64(links) / float64(degree*(degree-1)/2)
+}
+
+// finds the communities of the undirected graph by label propagation: every node repeatedly takes the label most
+// common among its neighbours until no label changes. Nodes are visited in order and ties are broken towards the
+// current label, then the lowest one, so the communities are deterministic. Communities are numbered from 0 in order
+// of their first node
+func labelPropagation(neighbours []map[int]bool) []int {
+	labels := make([]int, len(neighbours))
+	for i := range labels {
+		labels[i] = i
+	}
+	for changed, rounds := true, 0; changed && rounds < 100; rounds++ {
+		changed = false
+		for node := range neighbours {
+			counts := make(map[int]int)
+			for neighbour := range neighbours[node] {
+				counts[labels[neighbour]]++
+			}
+			best, bestCount := labels[node], counts[labels[node]]
+			for label, count := range counts {
+				if count > bestCount || (count == bestCount && label < best && best != labels[node]) {
+					best, bestCount = label, count
+				}
+			}
+			if best != labels[node] {
+				labels[node] = best
+				changed = true
+			}
+		}
+	}
+
+	numbers := make(map[int]int)
+	for i, label := range labels {
+		if _, ok := numbers[label]; !ok {
+			numbers[label] = len(numbers)
+		}
+		labels[i] = numbers[label]
+	}
+	return labels
+}
+
+// modularity of the partition of the undirected graph, 0 for a graph without edges
+func modularity(neighbours []map[int]bool, communities []int) float64 {
+	edges := 0
+	internal := make(map[int]int)
+	degrees := make(map[int]int)
+	for node, nodeNeighbours := range neighbours {
+		degrees[communities[node]] += len(nodeNeighbours)
+		for neighbour := range nodeNeighbours {
+			if node < neighbour {
+				edges++
+				if communities[node] == communities[neighbour] {
+					internal[communities[node]]++
+				}
+			}
+		}
+	}
+	if edges == 0 {
+		return 0
+	}
+	m := float64(edges)
+	result := 0.0
+	for community, degree := range degrees {
+		result += float64(internal[community])/m - math.Pow(float64(degree)/(2*m), 2)
+	}
+	return result
+}
+
+// normalised mutual information 2I(X;Y)/(H(X)+H(Y)) between two labellings of the same items,
+// 1 if neither labelling splits the items and 0 without items
+func normalisedMutualInformation(x []int, y []int) float64 {
+	if len(x) == 0 {
+		return 0
+	}
+	n := float64(len(x))
+	countX, countY := make(map[int]float64), make(map[int]float64)
+	joint := make(map[[2]int]float64)
+	for i := range x {
+		countX[x[i]]++
+		countY[y[i]]++
+		joint[[2]int{x[i], y[i]}]++
+	}
+	entropy := func(counts map[int]float64) float64 {
+		h := 0.0
+		for _, count := range counts {
+			h -= count / n * math.Log(count/n)
+		}
+		return h
+	}
+	hx, hy := entropy(countX), entropy(countY)
+	if hx+hy == 0 {
+		return 1
+	}
+	information := 0.0
+	for pair, count := range joint {
+		information += count / n * math.Log(count*n/(countX[pair[0]]*countY[pair[1]]))
+	}
+	return 2 * information / (hx + hy)
+}
+
+func compareUUIDs(a, b uuid.UUID) int {
+	return slices.Compare(a[:], b[:])
+}
@@ -0,0 +1,287 @@
+package server
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Data        []graphMLData `xml:"data"`
+	Nodes       []graphMLNode `xml:"node"`
+	Edges       []graphMLEdge `xml:"edge"`
+}
+
+type graphML struct {
+	XMLName xml.Name       `xml:"graphml"`
+	XMLNS   string         `xml:"xmlns,attr"`
+	Keys    []graphMLKey   `xml:"key"`
+	Graphs  []graphMLGraph `xml:"graph"`
+}
+
+// writes the trust graphs as GraphML, with a graph for every round of every iteration
+func WriteGraphML(w io.Writer, graphs []TrustGraph) error {
+	document := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "iteration", For: "graph", Name: "iteration", Type: "int"},
+			{ID: "round", For: "graph", Name: "round", Type: "int"},
+			{ID: "team", For: "node", Name: "team", Type: "string"},
+			{ID: "colour", For: "node", Name: "colour", Type: "string"},
+			{ID: "bike", For: "node", Name: "bike", Type: "string"},
+			{ID: "community", For: "node", Name: "community", Type: "int"},
+			{ID: "in_degree_centrality", For: "node", Name: "in_degree_centrality", Type: "double"},
+			{ID: "weight", For: "edge", Name: "weight", Type: "double"},
+		},
+	}
+	for _, graph := range graphs {
+		element := graphMLGraph{
+			ID:          fmt.Sprintf("iteration%d-round%d", graph.Iteration, graph.Round),
+			EdgeDefault: "directed",
+			Data: []graphMLData{
+				{Key: "iteration", Value: strconv.Itoa(graph.Iteration)},
+				{Key: "round", Value: strconv.Itoa(graph.Round)},
+			},
+		}
+		for _, node := range graph.Nodes {
+			element.Nodes = append(element.Nodes, graphMLNode{ID: node.ID.String(), Data: []graphMLData{
+				{Key: "team", Value: node.Team},
+				{Key: "colour", Value: node.Colour},
+				{Key: "bike", Value: bikeLabel(node.Bike)},
+				{Key: "community", Value: strconv.Itoa(node.Community)},
+				{Key: "in_degree_centrality", Value: formatFloat(node.InDegreeCentrality)},
+			}})
+		}
+		for _, edge := range graph.Edges {
+			element.Edges = append(element.Edges, graphMLEdge{Source: edge.Source.String(), Target: edge.Target.String(),
+				Data: []graphMLData{{Key: "weight", Value: formatFloat(edge.Weight)}}})
+		}
+		document.Graphs = append(document.Graphs, element)
+	}
+	return writeXML(w, document)
+}
+
+type gexfAttribute struct {
+	ID    string `xml:"id,attr"`
+	Title string `xml:"title,attr"`
+	Type  string `xml:"type,attr"`
+}
+
+type gexfAttributes struct {
+	Class      string          `xml:"class,attr"`
+	Mode       string          `xml:"mode,attr"`
+	Attributes []gexfAttribute `xml:"attribute"`
+}
+
+// a value of an attribute, from the start round to the end round (inclusive) for dynamic attributes
+type gexfValue struct {
+	For   string `xml:"for,attr"`
+	Value string `xml:"value,attr"`
+	Start *int   `xml:"start,attr,omitempty"`
+	End   *int   `xml:"end,attr,omitempty"`
+}
+
+type gexfSpell struct {
+	Start int `xml:"start,attr"`
+	End   int `xml:"end,attr"`
+}
+
+type gexfNode struct {
+	ID     string      `xml:"id,attr"`
+	Label  string      `xml:"label,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+	Spells []gexfSpell `xml:"spells>spell"`
+}
+
+type gexfEdge struct {
+	ID     string      `xml:"id,attr"`
+	Source string      `xml:"source,attr"`
+	Target string      `xml:"target,attr"`
+	Values []gexfValue `xml:"attvalues>attvalue"`
+	Spells []gexfSpell `xml:"spells>spell"`
+}
+
+type gexf struct {
+	XMLName xml.Name `xml:"gexf"`
+	XMLNS   string   `xml:"xmlns,attr"`
+	Version string   `xml:"version,attr"`
+	Graph   struct {
+		Mode            string           `xml:"mode,attr"`
+		DefaultEdgeType string           `xml:"defaultedgetype,attr"`
+		TimeFormat      string           `xml:"timeformat,attr"`
+		Attributes      []gexfAttributes `xml:"attributes"`
+		Nodes           []gexfNode       `xml:"nodes>node"`
+		Edges           []gexfEdge       `xml:"edges>edge"`
+	} `xml:"graph"`
+}
+
+// a value that an attribute of a node or edge took in a round
+type roundValue struct {
+	round int
+	value string
+}
+
+// writes the trust graphs as a single dynamic GEXF graph in which time is the round. The nodes of different
+// iterations are kept apart by prefixing their ids with the iteration
+func WriteGEXF(w io.Writer, graphs []TrustGraph) error {
+	document := gexf{XMLNS: "http://gexf.net/1.3", Version: "1.3"}
+	document.Graph.Mode = "dynamic"
+	document.Graph.DefaultEdgeType = "directed"
+	document.Graph.TimeFormat = "integer"
+	document.Graph.Attributes = []gexfAttributes{
+		{Class: "node", Mode: "static", Attributes: []gexfAttribute{
+			{ID: "iteration", Title: "iteration", Type: "integer"},
+			{ID: "team", Title: "team", Type: "string"},
+		}},
+		{Class: "node", Mode: "dynamic", Attributes: []gexfAttribute{
+			{ID: "colour", Title: "colour", Type: "string"},
+			{ID: "bike", Title: "bike", Type: "string"},
+			{ID: "community", Title: "community", Type: "integer"},
+			{ID: "in_degree_centrality", Title: "in_degree_centrality", Type: "double"},
+		}},
+		{Class: "edge", Mode: "dynamic", Attributes: []gexfAttribute{
+			{ID: "reputation", Title: "reputation", Type: "double"},
+		}},
+	}
+
+	// the history of every node and edge, in order of first appearance
+	type history struct {
+		label, source, target string
+		static                []gexfValue
+		rounds                []int
+		values                map[string][]roundValue
+	}
+	nodes, edges := make(map[string]*history), make(map[string]*history)
+	nodeOrder, edgeOrder := make([]string, 0), make([]string, 0)
+	record := func(histories map[string]*history, order *[]string, id string, round int, values map[string]string) *history {
+		h, ok := histories[id]
+		if !ok {
+			h = &history{values: make(map[string][]roundValue)}
+			histories[id] = h
+			*order = append(*order, id)
+		}
+		h.rounds = append(h.rounds, round)
+		for attribute, value := range values {
+			h.values[attribute] = append(h.values[attribute], roundValue{round: round, value: value})
+		}
+		return h
+	}
+	for _, graph := range graphs {
+		nodeID := func(id uuid.UUID) string { return fmt.Sprintf("%d:%s", graph.Iteration, id) }
+		for _, node := range graph.Nodes {
+			h := record(nodes, &nodeOrder, nodeID(node.ID), graph.Round, map[string]string{
+				"colour":               node.Colour,
+				"bike":                 bikeLabel(node.Bike),
+				"community":            strconv.Itoa(node.Community),
+				"in_degree_centrality": formatFloat(node.InDegreeCentrality),
+			})
+			h.label = node.ID.String()
+			h.static = []gexfValue{
+				{For: "iteration", Value: strconv.Itoa(graph.Iteration)},
+				{For: "team", Value: node.Team},
+			}
+		}
+		for _, edge := range graph.Edges {
+			h := record(edges, &edgeOrder, nodeID(edge.Source)+" "+nodeID(edge.Target), graph.Round, map[string]string{
+				"reputation": formatFloat(edge.Weight),
+			})
+			h.source, h.target = nodeID(edge.Source), nodeID(edge.Target)
+		}
+	}
+
+	for _, id := range nodeOrder {
+		h := nodes[id]
+		node := gexfNode{ID: id, Label: h.label, Values: h.static, Spells: spells(h.rounds)}
+		for _, attribute := range []string{"colour", "bike", "community", "in_degree_centrality"} {
+			node.Values = append(node.Values, dynamicValues(attribute, h.values[attribute])...)
+		}
+		document.Graph.Nodes = append(document.Graph.Nodes, node)
+	}
+	for i, id := range edgeOrder {
+		h := edges[id]
+		document.Graph.Edges = append(document.Graph.Edges, gexfEdge{ID: strconv.Itoa(i), Source: h.source, Target: h.target,
+			Values: dynamicValues("reputation", h.values["reputation"]), Spells: spells(h.rounds)})
+	}
+	return writeXML(w, document)
+}
+
+// merges the consecutive rounds in which an attribute kept the same value
+func dynamicValues(attribute string, values []roundValue) []gexfValue {
+	result := make([]gexfValue, 0)
+	for i := 0; i < len(values); {
+		start, end := values[i].round, values[i].round
+		j := i + 1
+		for ; j < len(values) && values[j].round == end+1 && values[j].value == values[i].value; j++ {
+			end = values[j].round
+		}
+		result = append(result, gexfValue{For: attribute, Value: values[i].value, Start: &start, End: &end})
+		i = j
+	}
+	return result
+}
+
+// the intervals of consecutive rounds in which a node or edge was present
+func spells(rounds []int) []gexfSpell {
+	result := make([]gexfSpell, 0)
+	for _, round := range rounds {
+		if len(result) > 0 && result[len(result)-1].End == round-1 {
+			result[len(result)-1].End = round
+		} else {
+			result = append(result, gexfSpell{Start: round, End: round})
+		}
+	}
+	return result
+}
+
+func writeXML(w io.Writer, document any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(document); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// agents that aren't riding a bike have an empty bike
+func bikeLabel(bike uuid.UUID) string {
+	if bike == uuid.Nil {
+		return ""
+	}
+	return bike.String()
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
@@ -16,6 +16,8 @@ import (
 	"github.com/google/uuid"
 )
 
+const maxSpawnAttempts = 1000
+
 type AgentInitFunction func(baseBiker *objects.BaseBiker) objects.IBaseBiker
 
 var AgentInitFunctions = []AgentInitFunction{
@@ -34,39 +36,60 @@ func GetAgentGenerators() []baseserver.AgentGeneratorCountPair[objects.IBaseBike
 	extraBaseBikers := BikerAgentCount % (len(AgentInitFunctions) + 1)
 	agentGenerators := []baseserver.AgentGeneratorCountPair[objects.IBaseBiker]{
 		// Spawn base bikers
-		baseserver.MakeAgentGeneratorCountPair(BikerAgentGenerator(nil), bikersPerTeam+extraBaseBikers),
+		baseserver.MakeAgentGeneratorCountPair(BikerAgentGenerator(0, nil), bikersPerTeam+extraBaseBikers),
 	}
-	for _, initFunction := range AgentInitFunctions {
-		agentGenerators = append(agentGenerators, baseserver.MakeAgentGeneratorCountPair(BikerAgentGenerator(initFunction), bikersPerTeam))
+	for i, initFunction := range AgentInitFunctions {
+		agentGenerators = append(agentGenerators, baseserver.MakeAgentGeneratorCountPair(BikerAgentGenerator(i+1, initFunction), bikersPerTeam))
 	}
 	return agentGenerators
 }
 
-func BikerAgentGenerator(initFunc func(baseBiker *objects.BaseBiker) objects.IBaseBiker) func() objects.IBaseBiker {
+// the agents of a team are given its number (its position in AgentInitFunctions, from 1) as group ID, which the
+// statistics group them by whatever the package of their class. Base bikers keep the group ID 0
+func BikerAgentGenerator(groupID int, initFunc func(baseBiker *objects.BaseBiker) objects.IBaseBiker) func() objects.IBaseBiker {
 	return func() objects.IBaseBiker {
 		baseBiker := objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New())
 		if initFunc == nil {
 			return baseBiker
 		} else {
+			baseBiker.GroupID = groupID
 			return initFunc(baseBiker)
 		}
 	}
 }
 
-func (s *Server) spawnLootBox() {
-	lootBox := objects.GetLootBox()
+// moves a freshly spawned object out of any obstacle it was placed in
+func (s *Server) placeOnPassableTerrain(po objects.IPhysicsObject) {
+	state := po.GetPhysicalState()
+	for attempts := 0; !s.terrain.IsPassable(state.Position); attempts++ {
+		if attempts == maxSpawnAttempts {
+			panic("terrain leaves no room to spawn objects")
+		}
+		state.Position = utils.GenerateRandomCoordinates()
+	}
+	po.SetPhysicalState(state)
+}
+
+func (s *Server) spawnLootBox(gameState objects.IGameState) {
+	lootBox := LootBoxSpawner.SpawnLootBox(s.round, gameState)
+	s.placeOnPassableTerrain(lootBox)
 	s.lootBoxes[lootBox.GetID()] = lootBox
 }
 
 func (s *Server) replenishLootBoxes() {
-	count := LootBoxCount - len(s.lootBoxes)
+	count := LootBoxSpawner.GetTargetCount(s.round) - len(s.lootBoxes)
+	if count <= 0 {
+		return
+	}
+	gameState := s.NewGameStateDump(s.round)
 	for i := 0; i < count; i++ {
-		s.spawnLootBox()
+		s.spawnLootBox(gameState)
 	}
 }
 
 func (s *Server) spawnMegaBike() {
 	megaBike := objects.GetMegaBike()
+	s.placeOnPassableTerrain(megaBike)
 	s.megaBikes[megaBike.GetID()] = megaBike
 }
 
@@ -2,16 +2,30 @@ package server
 
 import (
 	"fmt"
-	"math"
+	"maps"
+	"slices"
 
 	"github.com/google/uuid"
 	"github.com/tealeg/xlsx/v3"
 )
 
 type GameStatistics struct {
-	PerRound         []AgentStatistics `json:"per_round"`
-	Average          AgentStatistics   `json:"average"`
-	AgentIDToGroupID map[uuid.UUID]int `json:"agent_id_to_group_id"`
+	PerRound         []AgentStatistics    `json:"per_round"`
+	Average          AgentStatistics      `json:"average"`
+	AgentIDToGroupID map[uuid.UUID]int    `json:"agent_id_to_group_id"`
+	AgentIDToTeam    map[uuid.UUID]string `json:"agent_id_to_team"`
+	// outcomes of the agents by team and by the governance of the bike they rode the most
+	ByTeam       map[string]AggregateStatistics `json:"by_team"`
+	ByGovernance map[string]AggregateStatistics `json:"by_governance"`
+	Survival     SurvivalStatistics             `json:"survival"`
+	// population-level statistics of every iteration, and their breakdown by governance type
+	Welfare             []WelfareStatistics          `json:"welfare"`
+	WelfareByGovernance map[string]GovernanceWelfare `json:"welfare_by_governance"`
+	// time series of every bike of every iteration, and the lifespans and membership churn of the bikes by governance type
+	Bikes                    []map[uuid.UUID][]BikeRound      `json:"bikes"`
+	InstitutionsByGovernance map[string]InstitutionStatistics `json:"institutions_by_governance"`
+	// metrics of the trust graph of every round of every iteration
+	Network [][]NetworkMetrics `json:"network"`
 }
 
 type AgentStatistics struct {
@@ -20,16 +34,21 @@ type AgentStatistics struct {
 	AgentEnergyVariance map[uuid.UUID]float64 `json:"agent_energy_variance"`
 	AgentPointsAverage  map[uuid.UUID]float64 `json:"agent_points_average"`
 	AgentPointsVariance map[uuid.UUID]float64 `json:"agent_points_variance"`
+	// honesty of the agents, from the messages that the server could verify
+	AgentLyingRate          map[uuid.UUID]float64 `json:"agent_lying_rate"`
+	AgentReputationAccuracy map[uuid.UUID]float64 `json:"agent_reputation_accuracy"`
 }
 
 type AgentStatisticAccessor func(statistics *AgentStatistics) map[uuid.UUID]float64
 
 var (
-	getLifetime       = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentLifetime }
-	getEnergyAverage  = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentEnergyAverage }
-	getEnergyVariance = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentEnergyVariance }
-	getPointsAverage  = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentPointsAverage }
-	getPointsVariance = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentPointsVariance }
+	getLifetime           = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentLifetime }
+	getEnergyAverage      = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentEnergyAverage }
+	getEnergyVariance     = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentEnergyVariance }
+	getPointsAverage      = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentPointsAverage }
+	getPointsVariance     = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentPointsVariance }
+	getLyingRate          = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentLyingRate }
+	getReputationAccuracy = func(statistics *AgentStatistics) map[uuid.UUID]float64 { return statistics.AgentReputationAccuracy }
 )
 
 func averageStatisticsOverRounds(statisticsPerRound []AgentStatistics, accessor AgentStatisticAccessor) map[uuid.UUID]float64 {
@@ -50,88 +69,51 @@ func averageStatisticsOverRounds(statisticsPerRound []AgentStatistics, accessor
 	return result
 }
 
+// CalculateStatistics streams the game states through a StatisticsAccumulator to compute the statistics of the game
 func CalculateStatistics(gameStates [][]GameStateDump) GameStatistics {
-	getAgentEnergy := func(agent *AgentDump) float64 { return agent.EnergyLevel }
-	getAgentPoints := func(agent *AgentDump) float64 { return float64(agent.Points) }
-
-	agentIDToGroupID := make(map[uuid.UUID]int)
-	for _, roundStates := range gameStates {
-		for _, gameState := range roundStates {
-			for id, agent := range gameState.Agents {
-				agentIDToGroupID[id] = agent.GroupID
-			}
+	accumulator := NewStatisticsAccumulator()
+	for i, iteration := range gameStates {
+		for _, gameState := range iteration {
+			accumulator.ObserveRound(i, gameState)
 		}
 	}
+	return accumulator.Statistics()
+}
 
-	statisticsPerRound := make([]AgentStatistics, 0, len(gameStates))
-	for _, round := range gameStates {
-		statisticsPerRound = append(statisticsPerRound, AgentStatistics{
-			AgentLifetime:       agentLifetime(round),
-			AgentEnergyAverage:  agentAverage(round, getAgentEnergy),
-			AgentEnergyVariance: agentVariance(round, getAgentEnergy),
-			AgentPointsAverage:  agentAverage(round, getAgentPoints),
-			AgentPointsVariance: agentVariance(round, getAgentPoints),
-		})
+// Statistics gives the statistics of the game from the rounds observed so far
+func (sa *StatisticsAccumulator) Statistics() GameStatistics {
+	statisticsPerRound := sa.agentStatistics()
+	welfare := make([]WelfareStatistics, 0, len(sa.iterations))
+	bikes := make([]map[uuid.UUID][]BikeRound, 0, len(sa.iterations))
+	network := make([][]NetworkMetrics, 0, len(sa.iterations))
+	for _, accumulator := range sa.iterations {
+		welfare = append(welfare, accumulator.welfare.statistics)
+		bikes = append(bikes, maps.Clone(accumulator.bikes.series))
+		network = append(network, slices.Clone(accumulator.network))
 	}
 
 	return GameStatistics{
 		PerRound: statisticsPerRound,
 		Average: AgentStatistics{
-			AgentLifetime:       averageStatisticsOverRounds(statisticsPerRound, getLifetime),
-			AgentEnergyAverage:  averageStatisticsOverRounds(statisticsPerRound, getEnergyAverage),
-			AgentEnergyVariance: averageStatisticsOverRounds(statisticsPerRound, getEnergyVariance),
-			AgentPointsAverage:  averageStatisticsOverRounds(statisticsPerRound, getPointsAverage),
-			AgentPointsVariance: averageStatisticsOverRounds(statisticsPerRound, getPointsVariance),
+			AgentLifetime:           averageStatisticsOverRounds(statisticsPerRound, getLifetime),
+			AgentEnergyAverage:      averageStatisticsOverRounds(statisticsPerRound, getEnergyAverage),
+			AgentEnergyVariance:     averageStatisticsOverRounds(statisticsPerRound, getEnergyVariance),
+			AgentPointsAverage:      averageStatisticsOverRounds(statisticsPerRound, getPointsAverage),
+			AgentPointsVariance:     averageStatisticsOverRounds(statisticsPerRound, getPointsVariance),
+			AgentLyingRate:          averageStatisticsOverRounds(statisticsPerRound, getLyingRate),
+			AgentReputationAccuracy: averageStatisticsOverRounds(statisticsPerRound, getReputationAccuracy),
 		},
-		AgentIDToGroupID: agentIDToGroupID,
-	}
-}
-
-func agentLifetime(gameStates []GameStateDump) map[uuid.UUID]float64 {
-	result := make(map[uuid.UUID]float64)
-	for i, gameState := range gameStates {
-		for id := range gameState.Agents {
-			result[id] = float64(i)
-		}
-	}
-	return result
-}
-
-func agentAverage(gameStates []GameStateDump, agentProperty func(agentDump *AgentDump) float64) map[uuid.UUID]float64 {
-	agentLifetime := agentLifetime(gameStates)
-
-	result := make(map[uuid.UUID]float64)
-	// result[id] := Σx
-	for _, gameState := range gameStates {
-		for id, agent := range gameState.Agents {
-			result[id] += agentProperty(&agent)
-		}
-	}
-	// result[id] := Σx/n == E(x)
-	for id := range result {
-		result[id] /= agentLifetime[id] + 1
-	}
-	return result
-}
-
-func agentVariance(gameStates []GameStateDump, agentProperty func(agentDump *AgentDump) float64) map[uuid.UUID]float64 {
-	agentLifetime := agentLifetime(gameStates)
-	agentAverage := agentAverage(gameStates, agentProperty)
-
-	result := make(map[uuid.UUID]float64)
-	// result[id] := Σ(x^2)
-	for _, gameState := range gameStates {
-		for id, agent := range gameState.Agents {
-			result[id] += math.Pow(agentProperty(&agent), 2)
-		}
+		AgentIDToGroupID:         maps.Clone(sa.agentIDToGroupID),
+		AgentIDToTeam:            maps.Clone(sa.agentIDToTeam),
+		ByTeam:                   aggregateStatistics(sa.iterations, agentTeam),
+		ByGovernance:             aggregateStatistics(sa.iterations, agentGovernance),
+		Survival:                 calculateSurvival(sa.deaths, sa.iterations),
+		Welfare:                  welfare,
+		WelfareByGovernance:      sa.governanceWelfare.statistics(),
+		Bikes:                    bikes,
+		InstitutionsByGovernance: institutionsByGovernance(bikes),
+		Network:                  network,
 	}
-	for id := range result {
-		// result[id] := Σ(x^2)/n == E(x^2)
-		result[id] /= agentLifetime[id] + 1
-		// result[id] := E(x^2) - E(x)^2 == Var(x)
-		result[id] -= math.Pow(agentAverage[id]+1, 2)
-	}
-	return result
 }
 
 func (gs *GameStatistics) ToSpreadsheet() *xlsx.File {
@@ -165,8 +147,7 @@ func (gs *GameStatistics) ToSpreadsheet() *xlsx.File {
 			row.GetCell(0).SetValue(i + 1)
 			for id, value := range accessor(&round) {
 				columnIndex := getColumnIndex(id)
-				groupID := gs.AgentIDToGroupID[id]
-				headerRow.GetCell(columnIndex).SetString(fmt.Sprintf("(Group: %d)", groupID))
+				headerRow.GetCell(columnIndex).SetString(fmt.Sprintf("%s (Group: %d)", gs.AgentIDToTeam[id], gs.AgentIDToGroupID[id]))
 				row.GetCell(columnIndex).SetValue(value)
 			}
 		}
@@ -177,6 +158,128 @@ func (gs *GameStatistics) ToSpreadsheet() *xlsx.File {
 	writeSheet("Energy Variance", getEnergyVariance)
 	writeSheet("Points Average", getPointsAverage)
 	writeSheet("Points Variance", getPointsVariance)
+	writeSheet("Lying Rate", getLyingRate)
+	writeSheet("Reputation Accuracy", getReputationAccuracy)
+
+	// one row per round and one column per iteration
+	writeWelfareSheet := func(sheetName string, accessor func(welfare *WelfareStatistics) map[int]float64) {
+		sheet, err := workbook.AddSheet(sheetName)
+		if err != nil {
+			panic(err)
+		}
+
+		headerRow := sheet.AddRow()
+		headerRow.GetCell(0).SetString("Round")
+		values := make([]map[int]float64, len(gs.Welfare))
+		rounds := 0
+		for i := range gs.Welfare {
+			headerRow.GetCell(i + 1).SetString(fmt.Sprintf("Iteration %d", i+1))
+			values[i] = accessor(&gs.Welfare[i])
+			rounds = max(rounds, len(gs.Welfare[i].EnergyGini))
+		}
+		for round := 0; round < rounds; round++ {
+			row := sheet.AddRow()
+			row.GetCell(0).SetValue(round)
+			for i := range values {
+				if value, ok := values[i][round]; ok {
+					row.GetCell(i + 1).SetValue(value)
+				}
+			}
+		}
+	}
+	perRound := func(values []float64) map[int]float64 {
+		result := make(map[int]float64, len(values))
+		for round, value := range values {
+			result[round] = value
+		}
+		return result
+	}
+
+	writeWelfareSheet("Energy Gini", func(welfare *WelfareStatistics) map[int]float64 { return perRound(welfare.EnergyGini) })
+	writeWelfareSheet("Points Gini", func(welfare *WelfareStatistics) map[int]float64 { return perRound(welfare.PointsGini) })
+	writeWelfareSheet("Utilitarian Welfare", func(welfare *WelfareStatistics) map[int]float64 { return perRound(welfare.UtilitarianWelfare) })
+	writeWelfareSheet("Egalitarian Welfare", func(welfare *WelfareStatistics) map[int]float64 { return perRound(welfare.EgalitarianWelfare) })
+	writeWelfareSheet("Nash Welfare", func(welfare *WelfareStatistics) map[int]float64 { return perRound(welfare.NashWelfare) })
+	writeWelfareSheet("Survival Rate", func(welfare *WelfareStatistics) map[int]float64 { return perRound(welfare.SurvivalRate) })
+	writeWelfareSheet("Allocation Fairness", func(welfare *WelfareStatistics) map[int]float64 { return welfare.AllocationFairness })
+
+	sheet, err := workbook.AddSheet("Welfare by Governance")
+	if err != nil {
+		panic(err)
+	}
+	sheet.AddRow().WriteSlice([]string{"Governance", "Bike Rounds", "Energy Gini", "Points Gini", "Utilitarian Welfare",
+		"Egalitarian Welfare", "Nash Welfare", "Survival Rate", "Allocations", "Allocation Fairness"}, -1)
+	governances := make([]string, 0, len(gs.WelfareByGovernance))
+	for governance := range gs.WelfareByGovernance {
+		governances = append(governances, governance)
+	}
+	slices.Sort(governances)
+	for _, governance := range governances {
+		welfare := gs.WelfareByGovernance[governance]
+		sheet.AddRow().WriteSlice([]any{governance, welfare.BikeRounds, welfare.EnergyGini, welfare.PointsGini, welfare.UtilitarianWelfare,
+			welfare.EgalitarianWelfare, welfare.NashWelfare, welfare.SurvivalRate, welfare.Allocations, welfare.AllocationFairness}, -1)
+	}
+
+	writeAggregateSheet := func(sheetName string, keyName string, aggregates map[string]AggregateStatistics) {
+		sheet, err := workbook.AddSheet(sheetName)
+		if err != nil {
+			panic(err)
+		}
+		header := []string{keyName, "Iterations"}
+		for _, statistic := range []string{"Lifetime", "Final Points", "Final Energy", "Win Rate"} {
+			header = append(header, statistic+" Mean", statistic+" CI Lower", statistic+" CI Upper")
+		}
+		sheet.AddRow().WriteSlice(header, -1)
+
+		keys := make([]string, 0, len(aggregates))
+		for key := range aggregates {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+		for _, key := range keys {
+			aggregate := aggregates[key]
+			row := []any{key, aggregate.Iterations}
+			for _, interval := range []ConfidenceInterval{aggregate.Lifetime, aggregate.FinalPoints, aggregate.FinalEnergy, aggregate.WinRate} {
+				row = append(row, interval.Mean, interval.Lower, interval.Upper)
+			}
+			sheet.AddRow().WriteSlice(row, -1)
+		}
+	}
+
+	writeAggregateSheet("Team Aggregates", "Team", gs.ByTeam)
+	writeAggregateSheet("Governance Aggregates", "Governance", gs.ByGovernance)
+
+	sheet, err = workbook.AddSheet("Institutions by Governance")
+	if err != nil {
+		panic(err)
+	}
+	sheet.AddRow().WriteSlice([]string{"Governance", "Institutions", "Mean Lifespan", "Ruler Tenures", "Mean Ruler Tenure", "Bike Rounds",
+		"Rider Rounds", "Joins", "Leaves", "Kickouts", "Churn", "Loot Box Rate", "Colour Point Rate", "Audi Kill Rate", "Distance Rate"}, -1)
+	governances = make([]string, 0, len(gs.InstitutionsByGovernance))
+	for governance := range gs.InstitutionsByGovernance {
+		governances = append(governances, governance)
+	}
+	slices.Sort(governances)
+	for _, governance := range governances {
+		institutions := gs.InstitutionsByGovernance[governance]
+		sheet.AddRow().WriteSlice([]any{governance, institutions.Institutions, institutions.MeanLifespan, institutions.RulerTenures,
+			institutions.MeanRulerTenure, institutions.BikeRounds, institutions.RiderRounds, institutions.Joins, institutions.Leaves,
+			institutions.Kickouts, institutions.Churn, institutions.LootBoxRate, institutions.ColourPointRate, institutions.AudiKillRate,
+			institutions.DistanceRate}, -1)
+	}
+
+	sheet, err = workbook.AddSheet("Trust Network")
+	if err != nil {
+		panic(err)
+	}
+	sheet.AddRow().WriteSlice([]string{"Iteration", "Round", "Agents", "Trust Edges", "Reciprocity", "Clustering", "Communities",
+		"Modularity", "Bike Alignment", "Team Alignment"}, -1)
+	for i, iteration := range gs.Network {
+		for _, metrics := range iteration {
+			sheet.AddRow().WriteSlice([]any{i, metrics.Round, metrics.Agents, metrics.TrustEdges, metrics.Reciprocity, metrics.Clustering,
+				metrics.Communities, metrics.Modularity, metrics.BikeAlignment, metrics.TeamAlignment}, -1)
+		}
+	}
 
 	return workbook
 }
@@ -0,0 +1,228 @@
+package server
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+// RoundObserver is notified of every round of the game as soon as it is played
+type RoundObserver interface {
+	// ObserveRound is called with the dump of every round of every iteration of the game, starting with the initial
+	// state of the iteration (whose Iteration field is -1)
+	ObserveRound(iteration int, gameState GameStateDump)
+}
+
+// Welford accumulates the mean and variance of a stream of values with Welford's algorithm,
+// which is numerically stable and doesn't keep the values
+type Welford struct {
+	n    int
+	mean float64
+	m2   float64 // sum of the squared differences from the mean
+}
+
+func (w *Welford) Add(value float64) {
+	w.n++
+	delta := value - w.mean
+	w.mean += delta / float64(w.n)
+	w.m2 += delta * (value - w.mean)
+}
+
+func (w *Welford) Count() int {
+	return w.n
+}
+
+// mean of the values, 0 without values
+func (w *Welford) Mean() float64 {
+	return w.mean
+}
+
+// population variance of the values, 0 without values
+func (w *Welford) Variance() float64 {
+	if w.n == 0 {
+		return 0
+	}
+	return w.m2 / float64(w.n)
+}
+
+// unbiased variance of the values, NaN with fewer than 2 values
+func (w *Welford) SampleVariance() float64 {
+	if w.n < 2 {
+		return math.NaN()
+	}
+	return w.m2 / float64(w.n-1)
+}
+
+// what is known about an agent in an iteration
+type agentAccumulator struct {
+	team string
+	// number of rounds the agent was alive at the end of
+	lifetime int
+	// energy and points of the agent in every game state it was alive in, including the initial one
+	energy Welford
+	points Welford
+	// last reputation map of the agent
+	reputation map[uuid.UUID]float64
+	// energy and points of the agent in the last game state it was alive in, and the round of that game state
+	finalEnergy float64
+	finalPoints int
+	lastSeen    int
+	died        bool
+	// number of game states in which the agent rode a bike of each governance
+	governances map[string]int
+}
+
+type iterationAccumulator struct {
+	agents map[uuid.UUID]*agentAccumulator
+	// verifiable messages and lies sent by every agent
+	verifiable map[uuid.UUID]int
+	lies       map[uuid.UUID]int
+	welfare    welfareAccumulator
+	// riders of the bikes of each governance in the last game state, to count those who survived the next round
+	riders map[string][]uuid.UUID
+	bikes  bikeAccumulator
+	// agents alive in the last game state with the most points
+	winners []uuid.UUID
+	network []NetworkMetrics
+}
+
+func newIterationAccumulator() *iterationAccumulator {
+	return &iterationAccumulator{
+		agents:     make(map[uuid.UUID]*agentAccumulator),
+		verifiable: make(map[uuid.UUID]int),
+		lies:       make(map[uuid.UUID]int),
+		welfare:    welfareAccumulator{statistics: WelfareStatistics{AllocationFairness: make(map[int]float64)}},
+		riders:     make(map[string][]uuid.UUID),
+		bikes: bikeAccumulator{
+			series:   make(map[uuid.UUID][]BikeRound),
+			previous: make(map[uuid.UUID]BikeDump),
+		},
+		winners: make([]uuid.UUID, 0),
+		network: make([]NetworkMetrics, 0),
+	}
+}
+
+// StatisticsAccumulator computes the statistics of the game round by round, without keeping the game states: it
+// keeps a constant amount of memory for each agent of each iteration, and otherwise only the statistics it outputs
+// (the time series of the welfare, the bikes and the trust graphs). It is fed by the server as a RoundObserver
+type StatisticsAccumulator struct {
+	iterations        []*iterationAccumulator
+	agentIDToGroupID  map[uuid.UUID]int
+	agentIDToTeam     map[uuid.UUID]string
+	governanceWelfare governanceWelfareAccumulator
+	deaths            []DeathRecord
+	trustGraphs       []TrustGraph
+}
+
+func NewStatisticsAccumulator() *StatisticsAccumulator {
+	return &StatisticsAccumulator{
+		iterations:       make([]*iterationAccumulator, 0),
+		agentIDToGroupID: make(map[uuid.UUID]int),
+		agentIDToTeam:    make(map[uuid.UUID]string),
+		governanceWelfare: governanceWelfareAccumulator{
+			sums:      make(map[string]*GovernanceWelfare),
+			riders:    make(map[string]int),
+			survivors: make(map[string]int),
+		},
+		deaths:      make([]DeathRecord, 0),
+		trustGraphs: make([]TrustGraph, 0),
+	}
+}
+
+func (sa *StatisticsAccumulator) ObserveRound(iteration int, gameState GameStateDump) {
+	for len(sa.iterations) <= iteration {
+		sa.iterations = append(sa.iterations, newIterationAccumulator())
+	}
+	accumulator := sa.iterations[iteration]
+
+	for id, agent := range gameState.Agents {
+		sa.agentIDToGroupID[id] = agent.GroupID
+		sa.agentIDToTeam[id] = teamName(agent)
+
+		agentStatistics, ok := accumulator.agents[id]
+		if !ok {
+			agentStatistics = &agentAccumulator{team: teamName(agent), governances: make(map[string]int)}
+			accumulator.agents[id] = agentStatistics
+		}
+		// the initial state of the iteration isn't a round the agent survived
+		if gameState.Iteration >= 0 {
+			agentStatistics.lifetime++
+		}
+		agentStatistics.energy.Add(agent.EnergyLevel)
+		agentStatistics.points.Add(float64(agent.Points))
+		agentStatistics.reputation = agent.Reputation
+		agentStatistics.finalEnergy = agent.EnergyLevel
+		agentStatistics.finalPoints = agent.Points
+		agentStatistics.lastSeen = gameState.Iteration
+	}
+	for _, bike := range gameState.Bikes {
+		for _, id := range bike.AgentIDs {
+			if agent, ok := accumulator.agents[id]; ok {
+				agent.governances[bike.Governance.String()]++
+			}
+		}
+	}
+	for _, death := range gameState.Deaths {
+		// agents die after having been alive in an earlier game state
+		team := ""
+		if agent, ok := accumulator.agents[death.Agent]; ok {
+			agent.died = true
+			team = agent.team
+		}
+		sa.deaths = append(sa.deaths, DeathRecord{DeathDump: death, Iteration: iteration, Team: team})
+	}
+
+	for _, msg := range gameState.Messages {
+		if msg.Truthful == nil {
+			continue
+		}
+		accumulator.verifiable[msg.Sender]++
+		if !*msg.Truthful {
+			accumulator.lies[msg.Sender]++
+		}
+	}
+
+	accumulator.welfare.observe(gameState)
+	accumulator.riders = sa.governanceWelfare.observe(gameState, accumulator.riders)
+	accumulator.bikes.observe(gameState)
+	accumulator.winners = roundWinners(gameState, accumulator.winners[:0])
+	if graph, metrics, ok := trustGraphOf(iteration, gameState); ok {
+		sa.trustGraphs = append(sa.trustGraphs, graph)
+		accumulator.network = append(accumulator.network, metrics)
+	}
+}
+
+// TrustGraphs gives the trust graph of every round observed
+func (sa *StatisticsAccumulator) TrustGraphs() []TrustGraph {
+	return sa.trustGraphs
+}
+
+// the statistics of the agents in every iteration observed
+func (sa *StatisticsAccumulator) agentStatistics() []AgentStatistics {
+	result := make([]AgentStatistics, 0, len(sa.iterations))
+	for _, accumulator := range sa.iterations {
+		statistics := AgentStatistics{
+			AgentLifetime:       make(map[uuid.UUID]float64, len(accumulator.agents)),
+			AgentEnergyAverage:  make(map[uuid.UUID]float64, len(accumulator.agents)),
+			AgentEnergyVariance: make(map[uuid.UUID]float64, len(accumulator.agents)),
+			AgentPointsAverage:  make(map[uuid.UUID]float64, len(accumulator.agents)),
+			AgentPointsVariance: make(map[uuid.UUID]float64, len(accumulator.agents)),
+			AgentLyingRate:      make(map[uuid.UUID]float64, len(accumulator.verifiable)),
+		}
+		finalReputation := make(map[uuid.UUID]map[uuid.UUID]float64, len(accumulator.agents))
+		for id, agent := range accumulator.agents {
+			statistics.AgentLifetime[id] = float64(agent.lifetime)
+			statistics.AgentEnergyAverage[id] = agent.energy.Mean()
+			statistics.AgentEnergyVariance[id] = agent.energy.Variance()
+			statistics.AgentPointsAverage[id] = agent.points.Mean()
+			statistics.AgentPointsVariance[id] = agent.points.Variance()
+			finalReputation[id] = agent.reputation
+		}
+		for id, n := range accumulator.verifiable {
+			statistics.AgentLyingRate[id] = float64(accumulator.lies[id]) / float64(n)
+		}
+		statistics.AgentReputationAccuracy = reputationAccuracy(statistics.AgentLyingRate, finalReputation)
+		result = append(result, statistics)
+	}
+	return result
+}
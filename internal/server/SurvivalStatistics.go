@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/csv"
+	"io"
+	"slices"
+	"strconv"
+
+	"github.com/google/uuid"
+)
+
+// SurvivalStatistics describe when and how the agents died, pooling the agents of all the iterations
+type SurvivalStatistics struct {
+	Deaths []DeathRecord `json:"deaths"`
+	// Kaplan-Meier estimate of the survival function of each team, agents alive at the end of an iteration are censored
+	KaplanMeier map[string][]SurvivalPoint `json:"kaplan_meier"`
+	// deaths of each team broken down by cause
+	Hazards map[string]map[DeathCause]Hazard `json:"hazards"`
+}
+
+type DeathRecord struct {
+	DeathDump
+	Iteration int    `json:"iteration"`
+	Team      string `json:"team"`
+}
+
+// SurvivalPoint is a step of a Kaplan-Meier curve, at a round in which agents of the team died or were censored
+type SurvivalPoint struct {
+	Round    int     `json:"round"`
+	AtRisk   int     `json:"at_risk"` // agents alive at the start of the round
+	Deaths   int     `json:"deaths"`
+	Censored int     `json:"censored"`
+	Survival float64 `json:"survival"` // estimated probability of surviving past the round
+}
+
+type Hazard struct {
+	Deaths int     `json:"deaths"`
+	Rate   float64 `json:"rate"`  // deaths per agent-round at risk
+	Share  float64 `json:"share"` // fraction of the deaths of the team
+}
+
+// the last round an agent was at risk in, and whether it died in it
+type survivalTime struct {
+	round int
+	died  bool
+}
+
+// the survival of the agents from the deaths observed, agents of the iterations that didn't die are censored in the
+// last round they were seen in
+func calculateSurvival(deathRecords []DeathRecord, iterations []*iterationAccumulator) SurvivalStatistics {
+	result := SurvivalStatistics{
+		Deaths:      slices.Clone(deathRecords),
+		KaplanMeier: make(map[string][]SurvivalPoint),
+		Hazards:     make(map[string]map[DeathCause]Hazard),
+	}
+	times := make(map[string][]survivalTime)
+	deaths := make(map[string]map[DeathCause]int)
+
+	for _, death := range deathRecords {
+		times[death.Team] = append(times[death.Team], survivalTime{round: death.Round, died: true})
+		if _, ok := deaths[death.Team]; !ok {
+			deaths[death.Team] = make(map[DeathCause]int)
+		}
+		deaths[death.Team][death.Cause]++
+	}
+	for _, iteration := range iterations {
+		for _, agent := range iteration.agents {
+			if !agent.died {
+				times[agent.team] = append(times[agent.team], survivalTime{round: max(agent.lastSeen, 0), died: false})
+			}
+		}
+	}
+
+	for team, teamTimes := range times {
+		result.KaplanMeier[team] = kaplanMeier(teamTimes)
+
+		// every agent is at risk from round 0 to the round it died or was censored in
+		exposure, total := 0, 0
+		for _, time := range teamTimes {
+			exposure += time.round + 1
+		}
+		for _, n := range deaths[team] {
+			total += n
+		}
+		result.Hazards[team] = make(map[DeathCause]Hazard)
+		for cause, n := range deaths[team] {
+			result.Hazards[team][cause] = Hazard{
+				Deaths: n,
+				Rate:   float64(n) / float64(max(exposure, 1)),
+				Share:  float64(n) / float64(total),
+			}
+		}
+	}
+	return result
+}
+
+func kaplanMeier(times []survivalTime) []SurvivalPoint {
+	slices.SortFunc(times, func(a, b survivalTime) int { return a.round - b.round })
+
+	points := make([]SurvivalPoint, 0)
+	survival := 1.0
+	atRisk := len(times)
+	for i := 0; i < len(times); {
+		point := SurvivalPoint{Round: times[i].round, AtRisk: atRisk}
+		for ; i < len(times) && times[i].round == point.Round; i++ {
+			if times[i].died {
+				point.Deaths++
+			} else {
+				point.Censored++
+			}
+		}
+		survival *= 1 - float64(point.Deaths)/float64(point.AtRisk)
+		point.Survival = survival
+		points = append(points, point)
+		atRisk -= point.Deaths + point.Censored
+	}
+	return points
+}
+
+// writes one row for every step of the Kaplan-Meier curve of every team
+func (ss *SurvivalStatistics) WriteKaplanMeierCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"team", "round", "at_risk", "deaths", "censored", "survival"}); err != nil {
+		return err
+	}
+	teams := make([]string, 0, len(ss.KaplanMeier))
+	for team := range ss.KaplanMeier {
+		teams = append(teams, team)
+	}
+	slices.Sort(teams)
+	for _, team := range teams {
+		for _, point := range ss.KaplanMeier[team] {
+			record := []string{
+				team,
+				strconv.Itoa(point.Round),
+				strconv.Itoa(point.AtRisk),
+				strconv.Itoa(point.Deaths),
+				strconv.Itoa(point.Censored),
+				strconv.FormatFloat(point.Survival, 'f', -1, 64),
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writes one row for every death
+func (ss *SurvivalStatistics) WriteDeathsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"iteration", "agent", "team", "cause", "round", "bike", "governance"}); err != nil {
+		return err
+	}
+	for _, death := range ss.Deaths {
+		governance := death.Governance.String()
+		if death.Bike == uuid.Nil {
+			governance = "none"
+		}
+		record := []string{
+			strconv.Itoa(death.Iteration),
+			death.Agent.String(),
+			death.Team,
+			string(death.Cause),
+			strconv.Itoa(death.Round),
+			death.Bike.String(),
+			governance,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// TreasuryModel describes the common energy pool of the bikes. The zero value disables it,
+// in which case the loot is split between the riders without any tax
+type TreasuryModel struct {
+	Enabled    bool
+	MaxTaxRate float64 // highest tax rate a bike can choose, 1 when 0
+	// riders whose energy is below the threshold at the end of the round are topped up to it from the treasury, no welfare when 0
+	WelfareThreshold float64
+}
+
+var Treasury = TreasuryModel{}
+
+type TreasuryFlows struct {
+	Tax           float64 `json:"tax"`            // paid into the treasury from the loot
+	Welfare       float64 `json:"welfare"`        // paid out by the automatic welfare top-ups
+	RulerSpending float64 `json:"ruler_spending"` // paid out by decision of the ruler
+}
+
+// sets the tax rate of every bike through its governance: democracies take the mean of the votes of the riders,
+// leaderships weight the votes with the leader's weights and dictators choose the tax rate themselves
+func (s *Server) RunTaxRateDecision() {
+	if !Treasury.Enabled {
+		return
+	}
+	for _, bike := range s.GetMegaBikes() {
+		agents := bike.GetAgents()
+		if len(agents) == 0 {
+			continue
+		}
+
+		var taxRate float64
+		switch bike.GetGovernance() {
+		case utils.Democracy:
+			weights := make(map[uuid.UUID]float64)
+			for _, agent := range agents {
+				weights[agent.GetID()] = 1.0
+			}
+			taxRate = s.voteTaxRate(agents, weights)
+		case utils.Leadership:
+			leader, ok := s.GetAgentMap()[bike.GetRuler()]
+			if !ok {
+				continue
+			}
+			taxRate = s.voteTaxRate(agents, leader.DecideWeights(utils.Taxation))
+		case utils.Dictatorship:
+			dictator, ok := s.GetAgentMap()[bike.GetRuler()]
+			if !ok {
+				continue
+			}
+			taxRate = dictator.DecideTaxRate()
+		}
+
+		// the tax rate is kept if the vote gave no valid result
+		if !math.IsNaN(taxRate) {
+			bike.SetTaxRate(math.Max(0, math.Min(taxRate, Treasury.getMaxTaxRate())))
+		}
+	}
+}
+
+// weighted mean of the tax rates voted by the riders, NaN if no rider has a positive weight
+func (s *Server) voteTaxRate(agents []objects.IBaseBiker, weights map[uuid.UUID]float64) float64 {
+	total, totalWeight := 0.0, 0.0
+	for _, agent := range agents {
+		weight := weights[agent.GetID()]
+		if weight <= 0 {
+			continue
+		}
+		total += weight * agent.VoteTaxRate()
+		totalWeight += weight
+	}
+	return total / totalWeight
+}
+
+// tops up the riders below the welfare threshold, poorest first, then pays the energy the ruler decides to give
+func (s *Server) RunTreasurySpending() {
+	if !Treasury.Enabled {
+		return
+	}
+	for bikeID, bike := range s.GetMegaBikes() {
+		agents := slices.Clone(bike.GetAgents())
+		slices.SortFunc(agents, func(a, b objects.IBaseBiker) int {
+			if a.GetEnergyLevel() < b.GetEnergyLevel() {
+				return -1
+			} else if a.GetEnergyLevel() > b.GetEnergyLevel() {
+				return 1
+			}
+			return 0
+		})
+		for _, agent := range agents {
+			if agent.GetEnergyLevel() >= Treasury.WelfareThreshold {
+				break
+			}
+			s.updateTreasuryFlows(bikeID).Welfare += payFromTreasury(bike, agent, Treasury.WelfareThreshold-agent.GetEnergyLevel())
+		}
+
+		if bike.GetGovernance() == utils.Democracy {
+			continue
+		}
+		ruler, ok := s.GetAgentMap()[bike.GetRuler()]
+		if !ok {
+			continue
+		}
+		spending := ruler.DecideTreasurySpending()
+		for _, agent := range bike.GetAgents() {
+			if energy := spending[agent.GetID()]; energy > 0 {
+				s.updateTreasuryFlows(bikeID).RulerSpending += payFromTreasury(bike, agent, energy)
+			}
+		}
+	}
+}
+
+// gives the agent as much of the energy as the treasury holds, and returns the energy paid
+func payFromTreasury(bike objects.IMegaBike, agent objects.IBaseBiker, energy float64) float64 {
+	energy = math.Min(energy, bike.GetTreasury())
+	bike.UpdateTreasury(-energy)
+	agent.UpdateEnergyLevel(energy)
+	return energy
+}
+
+func (t TreasuryModel) getMaxTaxRate() float64 {
+	if t.MaxTaxRate == 0 {
+		return 1.0
+	}
+	return t.MaxTaxRate
+}
+
+func (s *Server) getTreasuryFlows(bikeID uuid.UUID) TreasuryFlows {
+	if flows, ok := s.treasuryFlows[bikeID]; ok {
+		return *flows
+	}
+	return TreasuryFlows{}
+}
+
+func (s *Server) updateTreasuryFlows(bikeID uuid.UUID) *TreasuryFlows {
+	if s.treasuryFlows == nil {
+		s.treasuryFlows = make(map[uuid.UUID]*TreasuryFlows)
+	}
+	if _, ok := s.treasuryFlows[bikeID]; !ok {
+		s.treasuryFlows[bikeID] = &TreasuryFlows{}
+	}
+	return s.treasuryFlows[bikeID]
+}
@@ -0,0 +1,263 @@
+package server
+
+import (
+	"math"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// WelfareStatistics are the population-level statistics of an iteration, with a value for each round.
+// Welfare is measured on the energy levels of the agents alive
+type WelfareStatistics struct {
+	EnergyGini         []float64 `json:"energy_gini"`
+	PointsGini         []float64 `json:"points_gini"`
+	UtilitarianWelfare []float64 `json:"utilitarian_welfare"` // mean energy
+	EgalitarianWelfare []float64 `json:"egalitarian_welfare"` // lowest energy
+	NashWelfare        []float64 `json:"nash_welfare"`        // geometric mean of the energy, 0 if an agent has no energy left
+	SurvivalRate       []float64 `json:"survival_rate"`       // fraction of the agents of the first round still alive
+	// mean fairness of the lootbox allocations of the round, rounds without allocations have no value
+	AllocationFairness map[int]float64 `json:"allocation_fairness"`
+	// Gini coefficients of the riders of each bike
+	PerBike []map[uuid.UUID]BikeInequality `json:"per_bike"`
+}
+
+type BikeInequality struct {
+	Governance string  `json:"governance"`
+	EnergyGini float64 `json:"energy_gini"`
+	PointsGini float64 `json:"points_gini"`
+}
+
+// GovernanceWelfare are the welfare statistics of the riders of the bikes of a governance type, averaged over all
+// the rounds and iterations in which a bike with riders had that governance
+type GovernanceWelfare struct {
+	BikeRounds         int     `json:"bike_rounds"`
+	EnergyGini         float64 `json:"energy_gini"`
+	PointsGini         float64 `json:"points_gini"`
+	UtilitarianWelfare float64 `json:"utilitarian_welfare"`
+	EgalitarianWelfare float64 `json:"egalitarian_welfare"`
+	NashWelfare        float64 `json:"nash_welfare"`
+	SurvivalRate       float64 `json:"survival_rate"` // fraction of the riders alive in the next round, 0 without a next round
+	Allocations        int     `json:"allocations"`
+	AllocationFairness float64 `json:"allocation_fairness"` // 0 without allocations
+}
+
+// the welfare statistics of an iteration, accumulated round by round
+type welfareAccumulator struct {
+	statistics WelfareStatistics
+	// agents alive in the first game state of the iteration
+	initialAgents int
+}
+
+func (wa *welfareAccumulator) observe(gameState GameStateDump) {
+	round := len(wa.statistics.EnergyGini)
+	if round == 0 {
+		wa.initialAgents = len(gameState.Agents)
+	}
+	result := &wa.statistics
+	energy, points := agentValues(gameState, allAgentIDs(gameState))
+	result.EnergyGini = append(result.EnergyGini, gini(energy))
+	result.PointsGini = append(result.PointsGini, gini(points))
+	result.UtilitarianWelfare = append(result.UtilitarianWelfare, mean(energy))
+	result.EgalitarianWelfare = append(result.EgalitarianWelfare, minimum(energy))
+	result.NashWelfare = append(result.NashWelfare, geometricMean(energy))
+	result.SurvivalRate = append(result.SurvivalRate, float64(len(gameState.Agents))/float64(max(wa.initialAgents, 1)))
+
+	fairness := make([]float64, 0, len(gameState.Allocations))
+	for _, allocation := range gameState.Allocations {
+		if value, ok := allocationFairness(allocation); ok {
+			fairness = append(fairness, value)
+		}
+	}
+	if len(fairness) > 0 {
+		result.AllocationFairness[round] = mean(fairness)
+	}
+
+	perBike := make(map[uuid.UUID]BikeInequality)
+	for id, bike := range gameState.Bikes {
+		if len(bike.AgentIDs) == 0 {
+			continue
+		}
+		energy, points := agentValues(gameState, bike.AgentIDs)
+		perBike[id] = BikeInequality{
+			Governance: bike.Governance.String(),
+			EnergyGini: gini(energy),
+			PointsGini: gini(points),
+		}
+	}
+	result.PerBike = append(result.PerBike, perBike)
+}
+
+// the welfare of the riders of each governance type, accumulated round by round over all the iterations
+type governanceWelfareAccumulator struct {
+	sums      map[string]*GovernanceWelfare
+	riders    map[string]int
+	survivors map[string]int
+}
+
+func (ga *governanceWelfareAccumulator) get(governance string) *GovernanceWelfare {
+	if _, ok := ga.sums[governance]; !ok {
+		ga.sums[governance] = &GovernanceWelfare{}
+	}
+	return ga.sums[governance]
+}
+
+// adds the bikes of the game state, and counts the riders of the previous game state of the iteration that are still
+// alive in it. Returns the riders of the game state by governance, to be passed with the next game state
+func (ga *governanceWelfareAccumulator) observe(gameState GameStateDump, previousRiders map[string][]uuid.UUID) map[string][]uuid.UUID {
+	for governance, ids := range previousRiders {
+		for _, id := range ids {
+			ga.riders[governance]++
+			if _, alive := gameState.Agents[id]; alive {
+				ga.survivors[governance]++
+			}
+		}
+	}
+
+	riders := make(map[string][]uuid.UUID)
+	for _, bike := range gameState.Bikes {
+		if len(bike.AgentIDs) == 0 {
+			continue
+		}
+		governance := bike.Governance.String()
+		energy, points := agentValues(gameState, bike.AgentIDs)
+		sum := ga.get(governance)
+		sum.BikeRounds++
+		sum.EnergyGini += gini(energy)
+		sum.PointsGini += gini(points)
+		sum.UtilitarianWelfare += mean(energy)
+		sum.EgalitarianWelfare += minimum(energy)
+		sum.NashWelfare += geometricMean(energy)
+		riders[governance] = append(riders[governance], bike.AgentIDs...)
+	}
+	for _, allocation := range gameState.Allocations {
+		if value, ok := allocationFairness(allocation); ok {
+			sum := ga.get(allocation.Governance.String())
+			sum.Allocations++
+			sum.AllocationFairness += value
+		}
+	}
+	return riders
+}
+
+func (ga *governanceWelfareAccumulator) statistics() map[string]GovernanceWelfare {
+	result := make(map[string]GovernanceWelfare, len(ga.sums))
+	for governance, sums := range ga.sums {
+		sum := *sums
+		if sum.BikeRounds > 0 {
+			n := float64(sum.BikeRounds)
+			sum.EnergyGini /= n
+			sum.PointsGini /= n
+			sum.UtilitarianWelfare /= n
+			sum.EgalitarianWelfare /= n
+			sum.NashWelfare /= n
+		}
+		if ga.riders[governance] > 0 {
+			sum.SurvivalRate = float64(ga.survivors[governance]) / float64(ga.riders[governance])
+		}
+		if sum.Allocations > 0 {
+			sum.AllocationFairness /= float64(sum.Allocations)
+		}
+		result[governance] = sum
+	}
+	return result
+}
+
+// how closely the loot follows the pedalling of the riders: 1 minus the total variation distance between the
+// allocated shares and the shares of the pedalling force. Allocations on bikes where nobody pedalled have no fairness
+func allocationFairness(allocation AllocationDump) (float64, bool) {
+	totalPedal := 0.0
+	for _, pedal := range allocation.Pedals {
+		totalPedal += math.Max(pedal, 0)
+	}
+	if totalPedal == 0 {
+		return 0, false
+	}
+
+	distance := 0.0
+	for id, pedal := range allocation.Pedals {
+		distance += math.Abs(allocation.Shares[id] - math.Max(pedal, 0)/totalPedal)
+	}
+	// loot allocated to agents that are not riding the bike
+	for id, share := range allocation.Shares {
+		if _, ok := allocation.Pedals[id]; !ok {
+			distance += share
+		}
+	}
+	return 1 - distance/2, true
+}
+
+func allAgentIDs(gameState GameStateDump) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(gameState.Agents))
+	for id := range gameState.Agents {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// energy levels and points of the given agents that are alive in the game state
+func agentValues(gameState GameStateDump, ids []uuid.UUID) ([]float64, []float64) {
+	energy := make([]float64, 0, len(ids))
+	points := make([]float64, 0, len(ids))
+	for _, id := range ids {
+		if agent, ok := gameState.Agents[id]; ok {
+			energy = append(energy, agent.EnergyLevel)
+			points = append(points, float64(agent.Points))
+		}
+	}
+	return energy, points
+}
+
+// Gini coefficient of the sample, with negative values counted as 0. 0 for an empty sample or one that sums to 0
+func gini(values []float64) float64 {
+	sorted := make([]float64, len(values))
+	for i, value := range values {
+		sorted[i] = math.Max(value, 0)
+	}
+	slices.Sort(sorted)
+
+	// G = Σ(2i - n - 1)x_i / (n Σx_i), with the values sorted in ascending order and 1-based i
+	n := float64(len(sorted))
+	weighted, total := 0.0, 0.0
+	for i, value := range sorted {
+		weighted += (2*float64(i+1) - n - 1) * value
+		total += value
+	}
+	if total == 0 {
+		return 0
+	}
+	return weighted / (n * total)
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
+
+func minimum(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return slices.Min(values)
+}
+
+// geometric mean of the sample, 0 if any value isn't positive
+func geometricMean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	logSum := 0.0
+	for _, value := range values {
+		if value <= 0 {
+			return 0
+		}
+		logSum += math.Log(value)
+	}
+	return math.Exp(logSum / float64(len(values)))
+}
@@ -0,0 +1,237 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type contractBiker struct {
+	*objects.BaseBiker
+	proposals    []objects.Contract
+	signs        bool
+	kickoutVotes map[uuid.UUID]int
+	statuses     map[uuid.UUID]objects.ContractStatus
+}
+
+func (cb *contractBiker) ProposeContracts() []objects.Contract {
+	proposals := cb.proposals
+	cb.proposals = nil
+	return proposals
+}
+
+func (cb *contractBiker) SignContract(contract objects.Contract) bool {
+	return cb.signs
+}
+
+func (cb *contractBiker) HandleContractStatus(contractID uuid.UUID, status objects.ContractStatus) {
+	cb.statuses[contractID] = status
+}
+
+func (cb *contractBiker) VoteForKickout() map[uuid.UUID]int {
+	return cb.kickoutVotes
+}
+
+// sets up a server with only two agents sharing a democratic bike
+func setupContractServer(t *testing.T) (*server.Server, *contractBiker, *contractBiker) {
+	bikers := make([]*contractBiker, 2)
+	for i := range bikers {
+		bikers[i] = &contractBiker{
+			BaseBiker:    objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New()),
+			signs:        true,
+			kickoutVotes: make(map[uuid.UUID]int),
+			statuses:     make(map[uuid.UUID]objects.ContractStatus),
+		}
+	}
+	s, _ := server2.SetupBike(t, utils.Democracy, bikers...)
+	return s, bikers[0], bikers[1]
+}
+
+// returns the only contract the agent is a party of
+func getContract(t *testing.T, biker *contractBiker) (uuid.UUID, objects.ContractStatus) {
+	if len(biker.statuses) != 1 {
+		t.Fatalf("Expected the agent to be a party of 1 contract, got %d", len(biker.statuses))
+	}
+	for id, status := range biker.statuses {
+		return id, status
+	}
+	return uuid.Nil, ""
+}
+
+func TestContractSigning(t *testing.T) {
+	s, proposer, other := setupContractServer(t)
+	other.signs = false
+	proposer.proposals = []objects.Contract{{
+		Parties:     []uuid.UUID{proposer.GetID(), other.GetID()},
+		Obligations: []objects.Obligation{{Party: proposer.GetID(), Type: objects.MinimumPedalling, Pedal: 0.5}},
+		Duration:    1,
+	}}
+	s.RunContractNegotiation()
+
+	if _, status := getContract(t, proposer); status != objects.ContractRejected {
+		t.Error("Contracts need the signature of all the parties")
+	}
+	if _, status := getContract(t, other); status != objects.ContractRejected {
+		t.Error("All the parties should be notified")
+	}
+
+	// invalid contracts are rejected whatever the signatures
+	other.signs = true
+	proposer.proposals = []objects.Contract{{Parties: []uuid.UUID{other.GetID()}, Duration: 1}}
+	for _, energy := range []float64{-0.1, math.NaN(), math.Inf(1)} {
+		proposer.proposals = append(proposer.proposals, objects.Contract{
+			Parties:  []uuid.UUID{proposer.GetID(), other.GetID()},
+			Payments: []objects.Payment{{From: other.GetID(), To: proposer.GetID(), Energy: energy}},
+			Duration: 1,
+		}, objects.Contract{
+			Parties:       []uuid.UUID{proposer.GetID(), other.GetID()},
+			Duration:      1,
+			EnergyPenalty: energy,
+		})
+	}
+	// obligations of unknown types can't be enforced
+	proposer.proposals = append(proposer.proposals, objects.Contract{
+		Parties:     []uuid.UUID{proposer.GetID(), other.GetID()},
+		Obligations: []objects.Obligation{{Party: other.GetID(), Type: 99}},
+		Duration:    1,
+	})
+	s.RunContractNegotiation()
+	if len(other.statuses) != 9 {
+		t.Errorf("Expected 9 contracts, got %d", len(other.statuses))
+	}
+	s.EnforceContracts()
+	for id, status := range other.statuses {
+		if status != objects.ContractRejected {
+			t.Errorf("Contract %v should have been rejected", id)
+		}
+	}
+}
+
+func TestVoteForLootboxWithoutVote(t *testing.T) {
+	for _, bikeless := range []bool{false, true} {
+		s, proposer, voter := setupContractServer(t)
+		bike := s.GetMegaBikes()[voter.GetBike()]
+		proposer.proposals = []objects.Contract{{
+			Parties:       []uuid.UUID{proposer.GetID(), voter.GetID()},
+			Obligations:   []objects.Obligation{{Party: voter.GetID(), Type: objects.VoteForLootbox, LootboxID: voter.ProposeDirection()}},
+			Duration:      1,
+			EnergyPenalty: 0.3,
+		}}
+		s.RunContractNegotiation()
+		if bikeless {
+			// bikeless agents don't vote
+			s.RemoveAgentFromBike(voter)
+		} else {
+			// the dictator chooses the direction
+			bike.SetGovernance(utils.Dictatorship)
+			bike.SetRuler(proposer.GetID())
+		}
+		s.EnforceContracts()
+		if _, status := getContract(t, voter); status != objects.ContractCompleted || voter.GetEnergyLevel() != 1.0 {
+			t.Errorf("Expected the contract to be completed without penalty when no vote was held (bikeless: %v), got %s", bikeless, status)
+		}
+	}
+}
+
+func TestPaymentForVote(t *testing.T) {
+	s, proposer, voter := setupContractServer(t)
+	voter.UpdateEnergyLevel(-0.5)
+	bike := s.GetMegaBikes()[voter.GetBike()]
+	proposer.proposals = []objects.Contract{{
+		Parties:     []uuid.UUID{proposer.GetID(), voter.GetID()},
+		Obligations: []objects.Obligation{{Party: voter.GetID(), Type: objects.VoteForLootbox, LootboxID: voter.ProposeDirection()}},
+		Payments:    []objects.Payment{{From: proposer.GetID(), To: voter.GetID(), Energy: 0.2}},
+		Duration:    1,
+	}}
+	s.RunContractNegotiation()
+	if _, status := getContract(t, voter); status != objects.ContractActive {
+		t.Fatal("Expected the contract to be signed")
+	}
+
+	// both base bikers vote for the nearest lootbox
+	s.RunDemocraticAction(bike, map[uuid.UUID]float64{proposer.GetID(): 1, voter.GetID(): 1})
+	s.EnforceContracts()
+
+	if math.Abs(proposer.GetEnergyLevel()-0.8) > 1e-9 || math.Abs(voter.GetEnergyLevel()-0.7) > 1e-9 {
+		t.Errorf("Expected the payment to be made, energies are %f and %f", proposer.GetEnergyLevel(), voter.GetEnergyLevel())
+	}
+	if _, status := getContract(t, voter); status != objects.ContractCompleted {
+		t.Error("Expected the contract to be completed after its duration")
+	}
+}
+
+func TestContractViolation(t *testing.T) {
+	s, proposer, other := setupContractServer(t)
+	proposer.proposals = []objects.Contract{{
+		Parties:       []uuid.UUID{proposer.GetID(), other.GetID()},
+		Obligations:   []objects.Obligation{{Party: proposer.GetID(), Type: objects.MinimumPedalling, Pedal: 0.6}},
+		Payments:      []objects.Payment{{From: other.GetID(), To: proposer.GetID(), Energy: 0.2}},
+		Duration:      5,
+		EnergyPenalty: 0.3,
+		PointsPenalty: 2,
+	}}
+	s.RunContractNegotiation()
+	proposer.SetForces(utils.Forces{Pedal: 0.3})
+	s.EnforceContracts()
+
+	if _, status := getContract(t, other); status != objects.ContractBroken {
+		t.Error("Expected the contract to be broken")
+	}
+	if math.Abs(proposer.GetEnergyLevel()-0.7) > 1e-9 || proposer.GetPoints() != -2 {
+		t.Error("Expected the violating party to be penalised")
+	}
+	if other.GetEnergyLevel() != 1.0 {
+		t.Error("Payments should not be made when the contract is broken")
+	}
+}
+
+func TestNoKickoutPact(t *testing.T) {
+	s, proposer, other := setupContractServer(t)
+	proposer.proposals = []objects.Contract{{
+		Parties: []uuid.UUID{proposer.GetID(), other.GetID()},
+		Obligations: []objects.Obligation{
+			{Party: proposer.GetID(), Type: objects.NoKickout},
+			{Party: other.GetID(), Type: objects.NoKickout},
+		},
+		Duration:      5,
+		EnergyPenalty: 0.1,
+	}}
+	s.RunContractNegotiation()
+
+	s.HandleKickoutProcess()
+	s.EnforceContracts()
+	if _, status := getContract(t, other); status != objects.ContractActive {
+		t.Error("Expected the pact to hold")
+	}
+
+	other.kickoutVotes[proposer.GetID()] = 1
+	s.HandleKickoutProcess()
+	s.EnforceContracts()
+	if _, status := getContract(t, other); status != objects.ContractBroken {
+		t.Error("Expected the pact to be broken")
+	}
+	if proposer.GetEnergyLevel() != 1.0 || math.Abs(other.GetEnergyLevel()-0.9) > 1e-9 {
+		t.Error("Only the agent voting for a kickout should be penalised")
+	}
+}
+
+func TestVoidContract(t *testing.T) {
+	s, proposer, other := setupContractServer(t)
+	proposer.proposals = []objects.Contract{{
+		Parties:  []uuid.UUID{proposer.GetID(), other.GetID()},
+		Payments: []objects.Payment{{From: other.GetID(), To: proposer.GetID(), Energy: 0.2}},
+		Duration: 5,
+	}}
+	s.RunContractNegotiation()
+	s.RemoveAgent(other)
+	s.EnforceContracts()
+
+	if _, status := getContract(t, proposer); status != objects.ContractVoid {
+		t.Error("Contracts should be void when a party dies")
+	}
+}
@@ -0,0 +1,182 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type transferBiker struct {
+	*objects.BaseBiker
+	transfers  []objects.EnergyTransfer
+	acceptLoan bool
+	repays     bool
+	defaults   []objects.Loan
+}
+
+func (tb *transferBiker) DecideEnergyTransfers() []objects.EnergyTransfer {
+	transfers := tb.transfers
+	tb.transfers = nil
+	return transfers
+}
+
+func (tb *transferBiker) AcceptLoan(lender uuid.UUID, amount float64, terms objects.LoanTerms) bool {
+	return tb.acceptLoan
+}
+
+func (tb *transferBiker) DecideRepayment(loan objects.Loan) bool {
+	return tb.repays
+}
+
+func (tb *transferBiker) HandleLoanDefault(loan objects.Loan) {
+	tb.defaults = append(tb.defaults, loan)
+}
+
+// sets up a server with only two agents sharing a democratic bike and a third on another bike
+func setupTransferServer(t *testing.T) (*server.Server, *transferBiker, *transferBiker, *transferBiker) {
+	bikers := make([]*transferBiker, 3)
+	for i := range bikers {
+		bikers[i] = &transferBiker{
+			BaseBiker:  objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New()),
+			acceptLoan: true,
+			repays:     true,
+		}
+	}
+	s, bike := server2.SetupBike(t, utils.Democracy, bikers[0], bikers[1])
+	server2.RideBike(s, server2.OtherBike(s, bike), bikers[2])
+	return s, bikers[0], bikers[1], bikers[2]
+}
+
+func TestEnergyGift(t *testing.T) {
+	s, giver, sameBike, otherBike := setupTransferServer(t)
+	sameBike.UpdateEnergyLevel(-0.5)
+	otherBike.UpdateEnergyLevel(-0.5)
+	giver.transfers = []objects.EnergyTransfer{
+		{To: sameBike.GetID(), Amount: 0.3},
+		{To: otherBike.GetID(), Amount: 0.3},
+	}
+	s.RunEnergyTransfers()
+
+	if math.Abs(giver.GetEnergyLevel()-0.7) > 1e-9 || math.Abs(sameBike.GetEnergyLevel()-0.8) > 1e-9 {
+		t.Errorf("Expected the gift to be transferred, energy levels are %f and %f", giver.GetEnergyLevel(), sameBike.GetEnergyLevel())
+	}
+	if otherBike.GetEnergyLevel() != 0.5 {
+		t.Error("Energy should not be transferred to another bike by default")
+	}
+
+	giver.transfers = []objects.EnergyTransfer{{To: sameBike.GetID(), Amount: 0.8}}
+	s.RunEnergyTransfers()
+	if math.Abs(giver.GetEnergyLevel()-0.7) > 1e-9 {
+		t.Error("Agents should not be able to give more energy than they have")
+	}
+
+	giver.transfers = []objects.EnergyTransfer{{To: sameBike.GetID(), Amount: math.NaN()}, {To: sameBike.GetID(), Amount: math.Inf(1)}}
+	s.RunEnergyTransfers()
+	if math.Abs(giver.GetEnergyLevel()-0.7) > 1e-9 || math.Abs(sameBike.GetEnergyLevel()-0.8) > 1e-9 {
+		t.Errorf("Non-finite gifts should be rejected, energy levels are %f and %f", giver.GetEnergyLevel(), sameBike.GetEnergyLevel())
+	}
+}
+
+func TestEnergyTransferRadius(t *testing.T) {
+	oldRadius := server.EnergyTransferRadius
+	server.EnergyTransferRadius = 10
+	t.Cleanup(func() {
+		server.EnergyTransferRadius = oldRadius
+	})
+	s, giver, _, otherBike := setupTransferServer(t)
+	otherBike.UpdateEnergyLevel(-0.5)
+	s.GetMegaBikes()[giver.GetBike()].SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: 0, Y: 0}, Mass: 1})
+	s.GetMegaBikes()[otherBike.GetBike()].SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: 6, Y: 8}, Mass: 1})
+	giver.transfers = []objects.EnergyTransfer{{To: otherBike.GetID(), Amount: 0.2}}
+	s.RunEnergyTransfers()
+	if math.Abs(otherBike.GetEnergyLevel()-0.7) > 1e-9 {
+		t.Error("Energy should be transferred to a bike within the radius")
+	}
+
+	s.GetMegaBikes()[otherBike.GetBike()].SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: 6, Y: 9}, Mass: 1})
+	giver.transfers = []objects.EnergyTransfer{{To: otherBike.GetID(), Amount: 0.2}}
+	s.RunEnergyTransfers()
+	if math.Abs(otherBike.GetEnergyLevel()-0.7) > 1e-9 {
+		t.Error("Energy should not be transferred to a bike outside of the radius")
+	}
+}
+
+func TestLoanRepayment(t *testing.T) {
+	s, lender, borrower, _ := setupTransferServer(t)
+	borrower.UpdateEnergyLevel(-0.5)
+	lender.transfers = []objects.EnergyTransfer{{To: borrower.GetID(), Amount: 0.5, Loan: &objects.LoanTerms{InterestRate: 0.2, Instalments: 2}}}
+	s.RunEnergyTransfers()
+	if lender.GetEnergyLevel() != 0.5 || borrower.GetEnergyLevel() != 1.0 {
+		t.Fatal("Expected the loan to be transferred to the borrower")
+	}
+
+	// the debt of 0.6 is repaid in two instalments of 0.3
+	for i := 1; i <= 3; i++ {
+		s.RunEnergyTransfers()
+	}
+	if math.Abs(lender.GetEnergyLevel()-1.0) > 1e-9 || math.Abs(borrower.GetEnergyLevel()-0.4) > 1e-9 {
+		t.Errorf("Expected the loan to be repaid with interest, energy levels are %f and %f", lender.GetEnergyLevel(), borrower.GetEnergyLevel())
+	}
+	if len(lender.defaults) != 0 {
+		t.Error("A repaid loan should not default")
+	}
+}
+
+func TestRejectedLoan(t *testing.T) {
+	s, lender, borrower, _ := setupTransferServer(t)
+	borrower.acceptLoan = false
+	borrower.UpdateEnergyLevel(-0.5)
+	lender.transfers = []objects.EnergyTransfer{{To: borrower.GetID(), Amount: 0.5, Loan: &objects.LoanTerms{Instalments: 1}}}
+	s.RunEnergyTransfers()
+	if lender.GetEnergyLevel() != 1.0 || borrower.GetEnergyLevel() != 0.5 {
+		t.Error("Energy should not be lent when the borrower rejects the loan")
+	}
+}
+
+func TestNonFiniteLoan(t *testing.T) {
+	s, lender, borrower, _ := setupTransferServer(t)
+	borrower.UpdateEnergyLevel(-0.5)
+	for _, rate := range []float64{math.NaN(), math.Inf(1), -0.1} {
+		lender.transfers = []objects.EnergyTransfer{{To: borrower.GetID(), Amount: 0.5, Loan: &objects.LoanTerms{InterestRate: rate, Instalments: 1}}}
+		s.RunEnergyTransfers()
+	}
+	if lender.GetEnergyLevel() != 1.0 || borrower.GetEnergyLevel() != 0.5 {
+		t.Error("Loans with negative or non-finite interest rates should be rejected")
+	}
+}
+
+func TestLoanDefault(t *testing.T) {
+	s, lender, borrower, _ := setupTransferServer(t)
+	borrower.repays = false
+	borrower.UpdateEnergyLevel(-0.5)
+	lender.transfers = []objects.EnergyTransfer{{To: borrower.GetID(), Amount: 0.5, Loan: &objects.LoanTerms{Instalments: 2}}}
+	s.RunEnergyTransfers()
+	s.RunEnergyTransfers()
+	if len(lender.defaults) != 1 || lender.defaults[0].Borrower != borrower.GetID() {
+		t.Fatal("Expected the lender to be told about the default")
+	}
+
+	// defaulted loans are not collected anymore
+	borrower.repays = true
+	s.RunEnergyTransfers()
+	if lender.GetEnergyLevel() != 0.5 || len(lender.defaults) != 1 {
+		t.Error("A defaulted loan should not be repaid")
+	}
+}
+
+func TestLoanForgiven(t *testing.T) {
+	s, lender, borrower, _ := setupTransferServer(t)
+	borrower.UpdateEnergyLevel(-0.5)
+	lender.transfers = []objects.EnergyTransfer{{To: borrower.GetID(), Amount: 0.5, Loan: &objects.LoanTerms{Instalments: 1}}}
+	s.RunEnergyTransfers()
+	s.RemoveAgent(lender)
+	s.RunEnergyTransfers()
+	if borrower.GetEnergyLevel() != 1.0 {
+		t.Error("Loans should be forgiven when the lender dies")
+	}
+}
@@ -0,0 +1,158 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type judgeBiker struct {
+	*objects.BaseBiker
+	accusations []objects.Accusation
+	convicts    bool
+	judged      []objects.Accusation
+	sanctions   []objects.Sanction
+	acquittals  int
+}
+
+func (jb *judgeBiker) FileAccusations() []objects.Accusation {
+	return jb.accusations
+}
+
+func (jb *judgeBiker) JudgeAccusation(accusation objects.Accusation) bool {
+	jb.judged = append(jb.judged, accusation)
+	return jb.convicts
+}
+
+func (jb *judgeBiker) HandleVerdict(accusation objects.Accusation, guilty bool, sanction *objects.Sanction) {
+	if accusation.Accused != jb.GetID() {
+		return
+	}
+	if guilty != (sanction != nil) {
+		panic("the sanction should be given with the conviction only")
+	}
+	if guilty {
+		jb.sanctions = append(jb.sanctions, *sanction)
+	} else {
+		jb.acquittals++
+	}
+}
+
+// sets up a server with only the given number of agents sharing a bike with the given governance, the first being its ruler
+func setupJudiciaryServer(t *testing.T, governance utils.Governance, n int) (*server.Server, objects.IMegaBike, []*judgeBiker) {
+	bikers := make([]*judgeBiker, n)
+	for i := range bikers {
+		bikers[i] = &judgeBiker{BaseBiker: objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New()), convicts: true}
+	}
+	s, bike := server2.SetupBike(t, governance, bikers...)
+	return s, bike, bikers
+}
+
+func TestJudiciaryDisabledByDefault(t *testing.T) {
+	s, _, bikers := setupJudiciaryServer(t, utils.Democracy, 3)
+	bikers[0].accusations = []objects.Accusation{{Accused: bikers[1].GetID(), Offence: objects.Freeriding}}
+	s.RunJudiciary()
+	if len(bikers[2].judged) != 0 || len(bikers[1].sanctions) != 0 {
+		t.Error("Accusations should not be ruled on when the judiciary is disabled")
+	}
+}
+
+func TestJuryVerdict(t *testing.T) {
+	server2.WithGlobal(t, &server.Judiciary, server.JudiciaryModel{Enabled: true, JurySize: 2})
+	s, _, bikers := setupJudiciaryServer(t, utils.Democracy, 4)
+	bikers[0].accusations = []objects.Accusation{
+		{Accused: bikers[1].GetID(), Offence: objects.Freeriding},
+		// duplicated accusations are ignored
+		{Accused: bikers[1].GetID(), Offence: objects.Freeriding},
+	}
+	bikers[2].convicts = false
+	bikers[3].convicts = false
+	s.RunJudiciary()
+
+	if len(bikers[0].judged) != 0 || len(bikers[1].judged) != 0 {
+		t.Error("The parties of a case should not be part of its jury")
+	}
+	if len(bikers[2].judged) != 1 || len(bikers[3].judged) != 1 {
+		t.Fatal("Expected the other riders to judge the accusation once")
+	}
+	if bikers[2].judged[0].Evidence != bikers[1].GetForces().Pedal {
+		t.Error("Expected the pedal force of the accused as evidence of freeriding")
+	}
+	if len(bikers[1].sanctions) != 0 || bikers[1].acquittals != 1 {
+		t.Error("The accused should be acquitted by the jury without sanction")
+	}
+
+	bikers[2].convicts = true
+	s.RunJudiciary()
+	if len(bikers[1].sanctions) != 0 {
+		t.Error("A tied jury should acquit the accused")
+	}
+	bikers[3].convicts = true
+	s.RunJudiciary()
+	if len(bikers[1].sanctions) != 1 {
+		t.Error("Expected the accused to be convicted by the jury")
+	}
+}
+
+func TestRulerVerdict(t *testing.T) {
+	server2.WithGlobal(t, &server.Judiciary, server.JudiciaryModel{Enabled: true})
+	s, _, bikers := setupJudiciaryServer(t, utils.Dictatorship, 3)
+	bikers[1].accusations = []objects.Accusation{{Accused: bikers[2].GetID(), Offence: objects.Lying}}
+	s.RunJudiciary()
+	if len(bikers[0].judged) != 1 || len(bikers[2].sanctions) != 1 {
+		t.Error("Expected the ruler to convict the accused")
+	}
+
+	// the ruler doesn't judge the cases it is a party of
+	bikers[1].accusations = []objects.Accusation{{Accused: bikers[0].GetID(), Offence: objects.Lying}}
+	s.RunJudiciary()
+	if len(bikers[0].judged) != 1 || len(bikers[2].judged) != 1 {
+		t.Error("Expected a jury to judge the ruler")
+	}
+}
+
+func TestGraduatedSanctions(t *testing.T) {
+	server2.WithGlobal(t, &server.Judiciary, server.JudiciaryModel{Enabled: true, EnergyFine: 0.2, PointsFine: 3})
+	s, bike, bikers := setupJudiciaryServer(t, utils.Democracy, 3)
+	accused := bikers[1]
+	accused.UpdatePoints(5)
+	bikers[0].accusations = []objects.Accusation{{Accused: accused.GetID(), Offence: objects.Freeriding}}
+
+	s.RunJudiciary()
+	if accused.GetEnergyLevel() != 1.0 || accused.GetPoints() != 5 {
+		t.Error("The first conviction should only be a warning")
+	}
+	s.RunJudiciary()
+	if math.Abs(accused.GetEnergyLevel()-0.8) > 1e-9 {
+		t.Error("The second conviction should be an energy fine")
+	}
+	s.RunJudiciary()
+	if accused.GetPoints() != 2 {
+		t.Error("The third conviction should be a points fine")
+	}
+	s.RunJudiciary()
+	if accused.GetBikeStatus() || len(bike.GetAgents()) != 2 {
+		t.Error("The fourth conviction should expel the accused from the bike")
+	}
+
+	expected := []objects.Sanction{objects.Warning, objects.EnergyFine, objects.PointsFine, objects.Expulsion}
+	if len(accused.sanctions) != len(expected) {
+		t.Fatalf("Expected %d sanctions, got %d", len(expected), len(accused.sanctions))
+	}
+	for i, sanction := range expected {
+		if accused.sanctions[i] != sanction {
+			t.Errorf("Expected sanction %d to be %d, got %d", i, sanction, accused.sanctions[i])
+		}
+	}
+
+	// riders can't accuse agents that are not on their bike
+	s.RunJudiciary()
+	if len(accused.sanctions) != len(expected) {
+		t.Error("Accusations against agents on other bikes should be ignored")
+	}
+}
@@ -0,0 +1,132 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+)
+
+func TestHotspotLootBoxSpawner(t *testing.T) {
+	centre := utils.Coordinates{X: 50, Y: 200}
+	server2.WithGlobal[server.ILootBoxSpawner](t, &server.LootBoxSpawner, server.HotspotLootBoxSpawner{Hotspots: []server.Hotspot{
+		{Centre: centre, Spread: 2, Weight: 1, LootMultiplier: 10, Colours: []utils.Colour{utils.Blue}},
+	}})
+	s := server.Initialize(1)
+
+	if len(s.GetLootBoxes()) != server.LootBoxCount {
+		t.Errorf("Expected %d lootboxes, got %d", int(server.LootBoxCount), len(s.GetLootBoxes()))
+	}
+	for _, lootBox := range s.GetLootBoxes() {
+		// 10 standard deviations away is practically impossible
+		if math.Sqrt(physics.ComputeDistance(lootBox.GetPosition(), centre)) > 20 {
+			t.Errorf("Lootbox spawned far from the hotspot at %+v", lootBox.GetPosition())
+		}
+		if lootBox.GetColour() != utils.Blue {
+			t.Errorf("Expected only blue lootboxes, got %s", lootBox.GetColour())
+		}
+		if lootBox.GetTotalResources() < 10*utils.MinLootBoxResources {
+			t.Errorf("Expected the hotspot to multiply the loot, got %f", lootBox.GetTotalResources())
+		}
+	}
+}
+
+func TestColourRegionLootBoxSpawner(t *testing.T) {
+	server2.WithGlobal[server.ILootBoxSpawner](t, &server.LootBoxSpawner, server.ColourRegionLootBoxSpawner{})
+	s := server.Initialize(1)
+
+	for _, lootBox := range s.GetLootBoxes() {
+		minX, maxX := server.GetColourRegion(lootBox.GetColour())
+		if x := lootBox.GetPosition().X; x < minX || x > maxX {
+			t.Errorf("%s lootbox spawned at x = %f, outside of [%f, %f]", lootBox.GetColour(), x, minX, maxX)
+		}
+	}
+}
+
+func TestAudiBonusLootBoxSpawner(t *testing.T) {
+	server2.WithGlobal[server.ILootBoxSpawner](t, &server.LootBoxSpawner, server.AudiBonusLootBoxSpawner{
+		Base:     server.UniformLootBoxSpawner{},
+		Radius:   math.Hypot(utils.GridWidth, utils.GridHeight) * 2,
+		MaxBonus: 1,
+	})
+	s := server.Initialize(1)
+
+	// every lootbox is within the radius, so they all get some bonus
+	for _, lootBox := range s.GetLootBoxes() {
+		if lootBox.GetTotalResources() <= utils.MinLootBoxResources*1.5 {
+			t.Errorf("Expected lootbox near the audi to be richer, got %f", lootBox.GetTotalResources())
+		}
+	}
+}
+
+func TestScheduledLootBoxSpawner(t *testing.T) {
+	spawner := server.ScheduledLootBoxSpawner{
+		Base: server.UniformLootBoxSpawner{},
+		Phases: []server.ScarcityPhase{
+			{StartRound: 10, CountMultiplier: 0.5, LootMultiplier: 0.1},
+			{StartRound: 20, CountMultiplier: 2, LootMultiplier: 3},
+		},
+	}
+	expectedCounts := map[int]int{
+		0:  server.LootBoxCount,
+		9:  server.LootBoxCount,
+		10: int(math.Round(server.LootBoxCount * 0.5)),
+		19: int(math.Round(server.LootBoxCount * 0.5)),
+		25: server.LootBoxCount * 2,
+	}
+	for round, expected := range expectedCounts {
+		if count := spawner.GetTargetCount(round); count != expected {
+			t.Errorf("Round %d: expected %d lootboxes, got %d", round, expected, count)
+		}
+	}
+
+	gameState := server.Initialize(1).NewGameStateDump(0)
+	if loot := spawner.SpawnLootBox(15, gameState).GetTotalResources(); loot > utils.MaxLootBoxResources*0.1 {
+		t.Errorf("Expected famine to reduce the loot, got %f", loot)
+	}
+	if loot := spawner.SpawnLootBox(20, gameState).GetTotalResources(); loot < utils.MinLootBoxResources*3 {
+		t.Errorf("Expected boom to increase the loot, got %f", loot)
+	}
+}
+
+func TestUnsetMultipliersLeaveLootUnchanged(t *testing.T) {
+	gameState := server.Initialize(1).NewGameStateDump(0)
+	hotspots := server.HotspotLootBoxSpawner{Hotspots: []server.Hotspot{{Centre: utils.Coordinates{X: 50, Y: 50}, Weight: 1}}}
+	if loot := hotspots.SpawnLootBox(0, gameState).GetTotalResources(); loot < utils.MinLootBoxResources {
+		t.Errorf("Expected a hotspot without a loot multiplier to spawn normal lootboxes, got %f", loot)
+	}
+
+	// a phase that only changes the number of lootboxes keeps their loot, and the other way round
+	spawner := server.ScheduledLootBoxSpawner{
+		Base: server.UniformLootBoxSpawner{},
+		Phases: []server.ScarcityPhase{
+			{StartRound: 10, CountMultiplier: 2},
+			{StartRound: 20, LootMultiplier: 2},
+		},
+	}
+	if loot := spawner.SpawnLootBox(15, gameState).GetTotalResources(); loot < utils.MinLootBoxResources {
+		t.Errorf("Expected a phase without a loot multiplier to keep the loot, got %f", loot)
+	}
+	if count := spawner.GetTargetCount(25); count != server.LootBoxCount {
+		t.Errorf("Expected a phase without a count multiplier to keep %d lootboxes, got %d", int(server.LootBoxCount), count)
+	}
+}
+
+func TestDriftedLootBoxesReplaced(t *testing.T) {
+	server2.WithGlobal(t, &utils.WorldBoundary, utils.OpenBoundary)
+	// every lootbox drifts off the map in the first round
+	server2.WithGlobal(t, &utils.LootBoxMaxDriftSpeed, 1e12)
+	s := server2.EmptyServer(t)
+	s.RunRoundLoop()
+
+	if len(s.GetLootBoxes()) != server.LootBoxCount {
+		t.Errorf("Expected %d lootboxes, got %d", int(server.LootBoxCount), len(s.GetLootBoxes()))
+	}
+	for _, lootBox := range s.GetLootBoxes() {
+		if position := lootBox.GetPosition(); position.X < 0 || position.X > utils.GridWidth || position.Y < 0 || position.Y > utils.GridHeight {
+			t.Errorf("Lootbox left on the map at %+v after drifting off it", position)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
+	"github.com/google/uuid"
+)
+
+// sends the same message (a lootbox message unless specified) to its recipients a given number of times per round
+type chattyBiker struct {
+	*objects.BaseBiker
+	recipients []objects.IBaseBiker
+	messages   int
+	newMessage func(base messaging.BaseMessage[objects.IBaseBiker]) messaging.IMessage[objects.IBaseBiker]
+	received   int
+	rejections []objects.MessageRejection
+	// agents this biker wants a private channel with
+	privateChannels []uuid.UUID
+	// acknowledgements received for the proposals of this biker
+	acknowledgements []objects.AcknowledgementMessage
+}
+
+func newChattyBiker() *chattyBiker {
+	return &chattyBiker{BaseBiker: objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New())}
+}
+
+func (cb *chattyBiker) GetAllMessages([]objects.IBaseBiker) []messaging.IMessage[objects.IBaseBiker] {
+	msgs := make([]messaging.IMessage[objects.IBaseBiker], cb.messages)
+	for i := range msgs {
+		base := messaging.CreateMessage[objects.IBaseBiker](cb, cb.recipients)
+		if cb.newMessage != nil {
+			msgs[i] = cb.newMessage(base)
+		} else {
+			msgs[i] = objects.LootboxMessage{BaseMessage: base}
+		}
+	}
+	return msgs
+}
+
+func (cb *chattyBiker) HandleLootboxMessage(msg objects.LootboxMessage) {
+	cb.received++
+}
+
+func (cb *chattyBiker) HandleMessageRejection(rejection objects.MessageRejection) {
+	cb.rejections = append(cb.rejections, rejection)
+}
+
+// sets up a server with only a sender and two recipients, the first sharing the sender's democratic bike and the second on another bike
+func setupMessagingServer(t *testing.T) (*server.Server, *chattyBiker, *chattyBiker, *chattyBiker) {
+	sender, sameBike, otherBike := newChattyBiker(), newChattyBiker(), newChattyBiker()
+	s, bike := server2.SetupBike(t, utils.Democracy, sender, sameBike)
+	server2.RideBike(s, server2.OtherBike(s, bike), otherBike)
+	sender.recipients = []objects.IBaseBiker{sameBike, otherBike}
+	sender.messages = 1
+	return s, sender, sameBike, otherBike
+}
+
+func TestDefaultMessageChannel(t *testing.T) {
+	s, sender, sameBike, otherBike := setupMessagingServer(t)
+	s.RunMessagingSession()
+
+	if sameBike.received != 1 || otherBike.received != 1 {
+		t.Error("Messages should be delivered to all recipients by default")
+	}
+	if sender.GetEnergyLevel() != 1.0 || len(sender.rejections) != 0 {
+		t.Error("Messages should be free and never rejected by default")
+	}
+}
+
+func TestMessageCost(t *testing.T) {
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{MessageCost: 0.1, RecipientCost: 0.05})
+	s, sender, sameBike, _ := setupMessagingServer(t)
+	s.RunMessagingSession()
+
+	if math.Abs(sender.GetEnergyLevel()-0.8) > 1e-9 {
+		t.Errorf("Expected the sender to pay 0.2 energy, has %f left", sender.GetEnergyLevel())
+	}
+
+	sender.UpdateEnergyLevel(-0.7)
+	s.RunMessagingSession()
+	if sameBike.received != 1 {
+		t.Error("Message should not be delivered when the sender can't afford it")
+	}
+	if len(sender.rejections) != 1 || sender.rejections[0].Reason != objects.InsufficientEnergy || len(sender.rejections[0].Recipients) != 2 {
+		t.Error("Expected the unaffordable message to be rejected for both recipients")
+	}
+	if math.Abs(sender.GetEnergyLevel()-0.1) > 1e-9 {
+		t.Error("Rejected messages should not cost energy")
+	}
+}
+
+func TestSameBikeMessageRange(t *testing.T) {
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{Range: server.SameBike})
+	s, sender, sameBike, otherBike := setupMessagingServer(t)
+	s.RunMessagingSession()
+
+	if sameBike.received != 1 || otherBike.received != 0 {
+		t.Error("Messages should only reach the sender's bike")
+	}
+	if len(sender.rejections) != 1 || sender.rejections[0].Reason != objects.OutOfRange || sender.rejections[0].Recipients[0] != otherBike.GetID() {
+		t.Error("Expected the recipient on the other bike to be reported out of range")
+	}
+}
+
+func TestRadiusMessageRange(t *testing.T) {
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{Range: server.WithinRadius, Radius: 10})
+	s, sender, _, otherBike := setupMessagingServer(t)
+	s.GetMegaBikes()[sender.GetBike()].SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: 0, Y: 0}, Mass: 1})
+	s.GetMegaBikes()[otherBike.GetBike()].SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: 6, Y: 8}, Mass: 1})
+	s.RunMessagingSession()
+	if otherBike.received != 1 {
+		t.Error("Message should reach a bike within the radius")
+	}
+
+	s.GetMegaBikes()[otherBike.GetBike()].SetPhysicalState(utils.PhysicalState{Position: utils.Coordinates{X: 6, Y: 9}, Mass: 1})
+	s.RunMessagingSession()
+	if otherBike.received != 1 || len(sender.rejections) != 1 || sender.rejections[0].Reason != objects.OutOfRange {
+		t.Error("Message should not reach a bike outside of the radius")
+	}
+}
+
+func TestMessageCap(t *testing.T) {
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{MaxMessagesPerRound: 2})
+	s, sender, sameBike, _ := setupMessagingServer(t)
+	sender.messages = 3
+	s.RunMessagingSession()
+
+	if sameBike.received != 2 {
+		t.Errorf("Expected 2 messages to be delivered, got %d", sameBike.received)
+	}
+	if len(sender.rejections) != 1 || sender.rejections[0].Reason != objects.MessageCapReached {
+		t.Error("Expected the third message to be rejected")
+	}
+
+	// the cap is per round
+	s.RunMessagingSession()
+	if sameBike.received != 4 {
+		t.Errorf("Expected 4 messages to be delivered after two rounds, got %d", sameBike.received)
+	}
+}
+
+func TestMessageDrop(t *testing.T) {
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{MessageCost: 0.1, DropProbability: 1})
+	s, sender, sameBike, otherBike := setupMessagingServer(t)
+	s.RunMessagingSession()
+
+	if sameBike.received != 0 || otherBike.received != 0 {
+		t.Error("All messages should be dropped")
+	}
+	if len(sender.rejections) != 0 {
+		t.Error("Dropped messages should not be reported to the sender")
+	}
+	if math.Abs(sender.GetEnergyLevel()-0.9) > 1e-9 {
+		t.Error("Dropped messages should still be paid for")
+	}
+}
@@ -0,0 +1,181 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/common/voting"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
+	"github.com/google/uuid"
+)
+
+func TestMessageLog(t *testing.T) {
+	s, sender, sameBike, otherBike := setupMessagingServer(t)
+	lootBoxID := uuid.New()
+	sender.newMessage = func(base messaging.BaseMessage[objects.IBaseBiker]) messaging.IMessage[objects.IBaseBiker] {
+		return objects.LootboxMessage{BaseMessage: base, LootboxId: lootBoxID}
+	}
+	s.RunMessagingSession()
+
+	log := s.GetMessageLog()
+	if len(log) != 1 {
+		t.Fatalf("Expected 1 logged message, got %d", len(log))
+	}
+	msg := log[0]
+	if msg.Type != "LootboxMessage" || msg.Sender != sender.GetID() || msg.Payload["LootboxId"] != lootBoxID {
+		t.Errorf("Unexpected message record %+v", msg)
+	}
+	if len(msg.Recipients) != 2 || msg.Recipients[0] != sameBike.GetID() || msg.Recipients[1] != otherBike.GetID() {
+		t.Error("Expected both recipients to be recorded")
+	}
+	if msg.Truthful != nil {
+		t.Error("Intentions can't be verified by the server")
+	}
+	if _, err := json.Marshal(log); err != nil {
+		t.Error(err)
+	}
+
+	// the log only holds the last messaging session, and only delivered messages
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{DropProbability: 1})
+	s.RunMessagingSession()
+	if len(s.GetMessageLog()) != 0 {
+		t.Error("Dropped messages should not be logged")
+	}
+}
+
+func TestForcesMessageTruthfulness(t *testing.T) {
+	s, sender, _, _ := setupMessagingServer(t)
+	forces := utils.Forces{Pedal: 0.5, Brake: 0.1, Turning: utils.TurningDecision{SteerBike: true, SteeringForce: 0.3}}
+	sender.SetForces(forces)
+
+	claimed := forces
+	sender.newMessage = func(base messaging.BaseMessage[objects.IBaseBiker]) messaging.IMessage[objects.IBaseBiker] {
+		return objects.ForcesMessage{BaseMessage: base, AgentId: sender.GetID(), AgentForces: claimed}
+	}
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || !*truthful {
+		t.Error("Expected the forces message to be truthful")
+	}
+
+	claimed.Pedal = 1.0
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || *truthful {
+		t.Error("Expected the forces message to be a lie")
+	}
+
+	// the log must stay serialisable whatever the agents send, numbers JSON can't represent are recorded as null
+	claimed.Pedal = math.NaN()
+	claimed.Turning.SteeringForce = math.Inf(1)
+	s.RunMessagingSession()
+	data, err := json.Marshal(s.GetMessageLog())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []struct {
+		Payload         map[string]any `json:"payload"`
+		NonFiniteFields []string       `json:"non_finite_fields"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	recorded, _ := decoded[0].Payload["AgentForces"].(map[string]any)
+	turning, _ := recorded["turning"].(map[string]any)
+	if pedal, ok := recorded["pedal"]; !ok || pedal != nil || recorded["brake"] != 0.1 || turning["steering_force"] != nil || turning["steer_bike"] != true {
+		t.Errorf("Expected the non-finite forces to be null and the others to be kept, got %v", recorded)
+	}
+	if len(decoded[0].NonFiniteFields) != 1 || decoded[0].NonFiniteFields[0] != "AgentForces" {
+		t.Errorf("Expected the forces to be flagged as non-finite, got %v", decoded[0].NonFiniteFields)
+	}
+}
+
+func TestReputationMessageTruthfulness(t *testing.T) {
+	s, sender, sameBike, _ := setupMessagingServer(t)
+	sender.SetReputation(sameBike.GetID(), 0.5)
+
+	claimed := 0.5
+	sender.newMessage = func(base messaging.BaseMessage[objects.IBaseBiker]) messaging.IMessage[objects.IBaseBiker] {
+		return objects.ReputationOfAgentMessage{BaseMessage: base, AgentId: sameBike.GetID(), Reputation: claimed}
+	}
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || !*truthful {
+		t.Error("Expected the reputation message to be truthful")
+	}
+
+	claimed = 0.9
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || *truthful {
+		t.Error("Expected the reputation message to be a lie")
+	}
+}
+
+func TestVoteMessageTruthfulness(t *testing.T) {
+	s, sender, sameBike, _ := setupMessagingServer(t)
+	claimed := voting.IdVoteMap{sameBike.GetID(): 1.0}
+	sender.newMessage = func(base messaging.BaseMessage[objects.IBaseBiker]) messaging.IMessage[objects.IBaseBiker] {
+		return objects.VoteRulerMessage{BaseMessage: base, VoteMap: claimed}
+	}
+
+	// no election has been held yet
+	s.RunMessagingSession()
+	if s.GetMessageLog()[0].Truthful != nil {
+		t.Error("A vote that wasn't cast can't be verified")
+	}
+
+	s.UpdateGameStates()
+	bike := s.GetMegaBikes()[sender.GetBike()]
+	s.RulerElection(bike.GetAgents(), utils.Dictatorship)
+	claimed = sender.VoteDictator()
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || !*truthful {
+		t.Error("Expected the vote message to be truthful")
+	}
+
+	claimed = voting.IdVoteMap{sender.GetID(): 1.0}
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || *truthful {
+		t.Error("Expected the vote message to be a lie")
+	}
+}
+
+func TestVoteGovernanceMessageTruthfulness(t *testing.T) {
+	s, sender, _, _ := setupMessagingServer(t)
+	claimed := voting.IdVoteMap{}
+	sender.newMessage = func(base messaging.BaseMessage[objects.IBaseBiker]) messaging.IMessage[objects.IBaseBiker] {
+		return objects.VoteGoveranceMessage{BaseMessage: base, VoteMap: claimed}
+	}
+
+	// the institutions haven't been founded yet
+	s.RunMessagingSession()
+	if s.GetMessageLog()[0].Truthful != nil {
+		t.Error("A governance vote that wasn't cast can't be verified")
+	}
+
+	// the sender is put on a bike of the governance it voted for
+	s.FoundingInstitutions()
+	ownBike := s.GetMegaBikes()[sender.GetBike()]
+	claimed = voting.IdVoteMap{ownBike.GetID(): 1.0}
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || !*truthful {
+		t.Error("Expected the governance vote message to be truthful")
+	}
+
+	for id, bike := range s.GetMegaBikes() {
+		if id != ownBike.GetID() {
+			bike.SetGovernance(utils.Dictatorship)
+			if ownBike.GetGovernance() == utils.Dictatorship {
+				bike.SetGovernance(utils.Democracy)
+			}
+			claimed = voting.IdVoteMap{id: 1.0}
+			break
+		}
+	}
+	s.RunMessagingSession()
+	if truthful := s.GetMessageLog()[0].Truthful; truthful == nil || *truthful {
+		t.Error("Expected the governance vote message to be a lie")
+	}
+}
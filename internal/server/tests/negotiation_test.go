@@ -0,0 +1,104 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"testing"
+
+	"github.com/MattSScott/basePlatformSOMAS/messaging"
+	"github.com/google/uuid"
+)
+
+// accepts every proposal, the acknowledgement is sent in the next exchange
+func (cb *chattyBiker) HandleProposalMessage(msg objects.ProposalMessage) {
+	cb.received++
+	cb.EnqueueMessage(objects.AcknowledgementMessage{
+		BaseMessage:   messaging.CreateMessage[objects.IBaseBiker](cb, []objects.IBaseBiker{msg.GetSender()}),
+		CorrelationID: msg.CorrelationID,
+		Accepted:      true,
+	})
+}
+
+func (cb *chattyBiker) HandleAcknowledgementMessage(msg objects.AcknowledgementMessage) {
+	cb.acknowledgements = append(cb.acknowledgements, msg)
+}
+
+func (cb *chattyBiker) GetPrivateChannels() []uuid.UUID {
+	return cb.privateChannels
+}
+
+func setupNegotiation(t *testing.T) (*server.Server, *chattyBiker, *chattyBiker, *chattyBiker, uuid.UUID) {
+	s, sender, sameBike, otherBike := setupMessagingServer(t)
+	correlationID := uuid.New()
+	sender.newMessage = func(base messaging.BaseMessage[objects.IBaseBiker]) messaging.IMessage[objects.IBaseBiker] {
+		return objects.ProposalMessage{BaseMessage: base, CorrelationID: correlationID, Topic: "allocation"}
+	}
+	return s, sender, sameBike, otherBike, correlationID
+}
+
+func TestSingleExchange(t *testing.T) {
+	s, sender, sameBike, otherBike, _ := setupNegotiation(t)
+	s.RunMessagingSession()
+
+	if sameBike.received != 1 || otherBike.received != 1 {
+		t.Error("Expected the proposal to be delivered")
+	}
+	if len(sender.acknowledgements) != 0 {
+		t.Error("Replies can't be delivered in a single exchange session")
+	}
+	for _, recipient := range []*chattyBiker{sameBike, otherBike} {
+		if len(recipient.rejections) != 1 || recipient.rejections[0].Reason != objects.ExchangeLimitReached {
+			t.Error("Expected the reply to be rejected")
+		}
+	}
+}
+
+func TestReplies(t *testing.T) {
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{MaxExchanges: 3})
+	s, sender, _, _, correlationID := setupNegotiation(t)
+	s.RunMessagingSession()
+
+	if len(sender.acknowledgements) != 2 {
+		t.Fatalf("Expected 2 acknowledgements, got %d", len(sender.acknowledgements))
+	}
+	for _, ack := range sender.acknowledgements {
+		if ack.CorrelationID != correlationID || !ack.Accepted {
+			t.Error("Expected the acknowledgements to accept the proposal")
+		}
+	}
+
+	log := s.GetMessageLog()
+	if len(log) != 3 || log[0].Type != "ProposalMessage" || log[0].Exchange != 0 {
+		t.Fatal("Expected the proposal to be logged in the first exchange")
+	}
+	for _, msg := range log[1:] {
+		if msg.Type != "AcknowledgementMessage" || msg.Exchange != 1 {
+			t.Error("Expected the acknowledgements to be logged in the second exchange")
+		}
+	}
+}
+
+func TestPrivateChannels(t *testing.T) {
+	server2.WithGlobal(t, &server.MessageChannel, server.MessageChannelModel{Range: server.SameBike, MaxExchanges: 2})
+	s, sender, _, otherBike, _ := setupNegotiation(t)
+
+	// a channel only opens when both agents want it
+	sender.privateChannels = []uuid.UUID{otherBike.GetID()}
+	s.RunMessagingSession()
+	if otherBike.received != 0 {
+		t.Error("A one-sided private channel should not open")
+	}
+
+	otherBike.privateChannels = []uuid.UUID{sender.GetID()}
+	sender.acknowledgements = nil
+	s.RunMessagingSession()
+	if otherBike.received != 1 || len(sender.acknowledgements) != 2 {
+		t.Error("Agents with a private channel should reach each other whatever the range")
+	}
+	for _, msg := range s.GetMessageLog() {
+		if msg.Sender == otherBike.GetID() && !msg.Private {
+			t.Error("Expected the reply from the other bike to go through the private channel")
+		}
+	}
+}
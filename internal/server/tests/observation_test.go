@@ -0,0 +1,130 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/physics"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+)
+
+func getObserverOnBike(t *testing.T, s server.IBaseBikerServer) objects.IBaseBiker {
+	for _, agent := range s.GetAgentMap() {
+		if agent.GetBikeStatus() {
+			return agent
+		}
+	}
+	t.Fatal("No agent on a bike")
+	return nil
+}
+
+func TestFullObservation(t *testing.T) {
+	server2.OnlySpawnBaseBikers(t)
+	s := server.Initialize(1)
+	s.FoundingInstitutions()
+	gs := s.NewGameStateDump(0)
+	observation := server.FullObservation{}.Observe(getObserverOnBike(t, s), gs, server.NewObservationNoise())
+	if len(observation.Agents) != len(gs.Agents) || len(observation.Bikes) != len(gs.Bikes) || len(observation.LootBoxes) != len(gs.LootBoxes) {
+		t.Error("Full observation should see the whole game state")
+	}
+}
+
+func TestSensingRadius(t *testing.T) {
+	server2.OnlySpawnBaseBikers(t)
+	s := server.Initialize(1)
+	s.FoundingInstitutions()
+	gs := s.NewGameStateDump(0)
+	observer := getObserverOnBike(t, s)
+	ownBike := gs.Bikes[observer.GetBike()]
+
+	policy := server.PartialObservation{SensingRadius: 30, MinVisibleLootBoxes: 2}
+	noise := server.NewObservationNoise()
+	observation := policy.Observe(observer, gs, noise)
+
+	if _, ok := observation.Bikes[ownBike.ID]; !ok {
+		t.Error("Observer should always see its own bike")
+	}
+	if _, ok := observation.Agents[observer.GetID()]; !ok {
+		t.Error("Observer should always see itself")
+	}
+	for _, bike := range observation.Bikes {
+		if bike.ID != ownBike.ID && math.Sqrt(physics.ComputeDistance(ownBike.GetPosition(), bike.GetPosition())) > policy.SensingRadius {
+			t.Errorf("Bike %v is visible outside of the sensing radius", bike.ID)
+		}
+		for _, rider := range bike.GetAgents() {
+			if _, ok := observation.Agents[rider.GetID()]; !ok {
+				t.Errorf("Rider %v of a visible bike is not visible", rider.GetID())
+			}
+		}
+	}
+	if len(observation.LootBoxes) < policy.MinVisibleLootBoxes {
+		t.Errorf("Expected at least %d visible lootboxes, got %d", policy.MinVisibleLootBoxes, len(observation.LootBoxes))
+	}
+	if len(observation.Audis) != 1 {
+		t.Error("The audi should always be visible")
+	}
+}
+
+func TestHiddenPrivateStateAndNoise(t *testing.T) {
+	server2.OnlySpawnBaseBikers(t)
+	s := server.Initialize(1)
+	s.FoundingInstitutions()
+	gs := s.NewGameStateDump(0)
+	observer := getObserverOnBike(t, s)
+
+	policy := server.PartialObservation{HidePrivateState: true, PositionNoise: 5}
+	noise := server.NewObservationNoise()
+	observation := policy.Observe(observer, gs, noise)
+
+	for id, agent := range observation.Agents {
+		sameBike := agent.BikeID == observer.GetBike()
+		if !sameBike && (agent.EnergyLevel != server.HiddenValue || agent.Points != server.HiddenValue) {
+			t.Errorf("Energy and points of agent %v on another bike should be hidden", id)
+		}
+		if sameBike && agent.EnergyLevel != gs.Agents[id].EnergyLevel {
+			t.Errorf("Energy of fellow rider %v should be visible", id)
+		}
+		if id != observer.GetID() && agent.Reputation != nil {
+			t.Errorf("Reputation map of agent %v should be hidden", id)
+		}
+	}
+
+	if observation.Bikes[observer.GetBike()].GetPosition() != gs.Bikes[observer.GetBike()].GetPosition() {
+		t.Error("Observer's own bike position should not be noisy")
+	}
+	noisy := 0
+	for id, lootBox := range observation.LootBoxes {
+		if lootBox.GetPosition() != gs.LootBoxes[id].GetPosition() {
+			noisy++
+		}
+	}
+	if noisy == 0 {
+		t.Error("Expected lootbox positions to be noisy")
+	}
+
+	// the noise is sampled once per round, so observing the same game state again gives the same positions
+	again := policy.Observe(observer, gs, noise)
+	for id, lootBox := range observation.LootBoxes {
+		if again.LootBoxes[id].GetPosition() != lootBox.GetPosition() {
+			t.Errorf("Expected the noise of lootbox %v to be the same for the whole round", id)
+		}
+	}
+	if again = policy.Observe(observer, gs, server.NewObservationNoise()); again.Audis[0].GetPosition() == observation.Audis[0].GetPosition() {
+		t.Error("Expected the noise to be sampled again in the next round")
+	}
+}
+
+func TestRoundLoopUnderPartialObservation(t *testing.T) {
+	server2.OnlySpawnBaseBikers(t)
+	oldPolicy := server.ObservationPolicy
+	server.ObservationPolicy = server.PartialObservation{SensingRadius: 50, MinVisibleLootBoxes: 1, HidePrivateState: true, PositionNoise: 1}
+	t.Cleanup(func() {
+		server.ObservationPolicy = oldPolicy
+	})
+
+	s := server.Initialize(10)
+	if gameStates := s.(*server.Server).RunSimLoop(10); len(gameStates) != 11 {
+		t.Errorf("Expected 11 game states, got %d", len(gameStates))
+	}
+}
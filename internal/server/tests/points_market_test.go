@@ -0,0 +1,153 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type traderBiker struct {
+	*objects.BaseBiker
+	orders []objects.Order
+	trades []objects.Trade
+}
+
+func (tb *traderBiker) DecideMarketOrders() []objects.Order {
+	return tb.orders
+}
+
+func (tb *traderBiker) HandleTrade(trade objects.Trade) {
+	tb.trades = append(tb.trades, trade)
+}
+
+// sets up a server with only the given number of traders seeking random colours, each starting with 10 points
+func setupMarketServer(t *testing.T, n int) (*server.Server, []*traderBiker) {
+	s := server2.EmptyServer(t)
+	traders := make([]*traderBiker, n)
+	for i := range traders {
+		traders[i] = &traderBiker{BaseBiker: objects.GetBaseBiker(utils.Red, uuid.New())}
+		traders[i].UpdatePoints(10)
+		s.AddAgent(traders[i])
+	}
+	return s, traders
+}
+
+func pointsOrder(side objects.OrderSide, quantity int, price float64) objects.Order {
+	return objects.Order{Asset: objects.Asset{Type: objects.Points}, Side: side, Quantity: quantity, Price: price}
+}
+
+func TestPointsMarketDisabledByDefault(t *testing.T) {
+	s, traders := setupMarketServer(t, 2)
+	traders[0].orders = []objects.Order{pointsOrder(objects.Bid, 1, 0.1)}
+	traders[1].orders = []objects.Order{pointsOrder(objects.Ask, 1, 0.1)}
+	s.RunPointsMarket()
+	if len(traders[0].trades) != 0 || traders[0].GetPoints() != 10 {
+		t.Error("Orders should not be matched when the market is disabled")
+	}
+}
+
+func TestPointsTrade(t *testing.T) {
+	server2.WithGlobal(t, &server.PointsMarket, server.MarketModel{Enabled: true})
+	s, traders := setupMarketServer(t, 3)
+	buyer, cheapSeller, dearSeller := traders[0], traders[1], traders[2]
+	buyer.UpdateEnergyLevel(-0.5)
+	cheapSeller.UpdateEnergyLevel(-0.5)
+	buyer.orders = []objects.Order{pointsOrder(objects.Bid, 5, 0.06)}
+	cheapSeller.orders = []objects.Order{pointsOrder(objects.Ask, 3, 0.04)}
+	dearSeller.orders = []objects.Order{pointsOrder(objects.Ask, 5, 0.1)}
+	s.RunPointsMarket()
+
+	// only the cheap ask crosses the bid, at the midpoint price of 0.05
+	if buyer.GetPoints() != 13 || cheapSeller.GetPoints() != 7 || dearSeller.GetPoints() != 10 {
+		t.Errorf("Unexpected points after the trade: %d, %d, %d", buyer.GetPoints(), cheapSeller.GetPoints(), dearSeller.GetPoints())
+	}
+	if math.Abs(buyer.GetEnergyLevel()-0.35) > 1e-9 || math.Abs(cheapSeller.GetEnergyLevel()-0.65) > 1e-9 {
+		t.Errorf("Unexpected energy levels after the trade: %f, %f", buyer.GetEnergyLevel(), cheapSeller.GetEnergyLevel())
+	}
+	if len(buyer.trades) != 1 || len(cheapSeller.trades) != 1 || buyer.trades[0].Quantity != 3 {
+		t.Error("Expected both parties to be told about the trade")
+	}
+}
+
+func TestPointsTradeLimits(t *testing.T) {
+	server2.WithGlobal(t, &server.PointsMarket, server.MarketModel{Enabled: true})
+	s, traders := setupMarketServer(t, 2)
+	buyer, seller := traders[0], traders[1]
+	buyer.UpdateEnergyLevel(-0.7)
+	seller.UpdateEnergyLevel(-0.5)
+	buyer.orders = []objects.Order{pointsOrder(objects.Bid, 20, 0.1)}
+	seller.orders = []objects.Order{pointsOrder(objects.Ask, 20, 0.1)}
+	s.RunPointsMarket()
+
+	// the buyer can only afford 3 points
+	if buyer.GetPoints() != 13 || seller.GetPoints() != 7 {
+		t.Errorf("Expected the trade to be limited by the energy of the buyer, got %d and %d points", buyer.GetPoints(), seller.GetPoints())
+	}
+
+	buyer.UpdateEnergyLevel(1)
+	s.RunPointsMarket()
+	if buyer.GetPoints() != 20 || seller.GetPoints() != 0 {
+		t.Errorf("Expected the trade to be limited by the points of the seller, got %d and %d points", buyer.GetPoints(), seller.GetPoints())
+	}
+}
+
+func TestInvalidPriceRejected(t *testing.T) {
+	server2.WithGlobal(t, &server.PointsMarket, server.MarketModel{Enabled: true})
+	s, traders := setupMarketServer(t, 4)
+	buyer, seller, nanSeller, infBuyer := traders[0], traders[1], traders[2], traders[3]
+	buyer.orders = []objects.Order{pointsOrder(objects.Bid, 1, 0.1)}
+	seller.orders = []objects.Order{pointsOrder(objects.Ask, 1, 0.1)}
+	nanSeller.orders = []objects.Order{pointsOrder(objects.Ask, 1, math.NaN())}
+	infBuyer.orders = []objects.Order{pointsOrder(objects.Bid, 1, math.Inf(1))}
+	s.RunPointsMarket()
+
+	// the orders without a finite price are dropped instead of blocking the book
+	if len(nanSeller.trades) != 0 || len(infBuyer.trades) != 0 {
+		t.Error("Orders without a finite price should be rejected")
+	}
+	if len(buyer.trades) != 1 || len(seller.trades) != 1 {
+		t.Error("Expected the valid orders to be matched")
+	}
+}
+
+func TestColourRightTrade(t *testing.T) {
+	server2.WithGlobal(t, &server.PointsMarket, server.MarketModel{Enabled: true, ColourRightDuration: 5})
+	s, traders := setupMarketServer(t, 2)
+	buyer, seller := traders[0], traders[1]
+	right := objects.Asset{Type: objects.ColourRight, Colour: seller.GetColour()}
+
+	// sellers can only sell the right of their own colour
+	otherColour := (seller.GetColour() + 1) % utils.NumOfColours
+	buyer.orders = []objects.Order{{Asset: objects.Asset{Type: objects.ColourRight, Colour: otherColour}, Side: objects.Bid, Quantity: 1, Price: 0.2}}
+	seller.orders = []objects.Order{{Asset: objects.Asset{Type: objects.ColourRight, Colour: otherColour}, Side: objects.Ask, Quantity: 1, Price: 0.1}}
+	s.RunPointsMarket()
+	if len(buyer.trades) != 0 {
+		t.Error("Agents should not be able to sell the right of another colour")
+	}
+
+	buyer.orders = []objects.Order{{Asset: right, Side: objects.Bid, Quantity: 1, Price: 0.2}}
+	seller.orders = []objects.Order{{Asset: right, Side: objects.Ask, Quantity: 1, Price: 0.1}}
+	s.RunPointsMarket()
+	if len(buyer.trades) != 1 || buyer.trades[0].Asset != right {
+		t.Fatal("Expected the colour right to be traded")
+	}
+	if math.Abs(buyer.GetEnergyLevel()-0.85) > 1e-9 {
+		t.Errorf("Expected the buyer to pay 0.15 energy, has %f left", buyer.GetEnergyLevel())
+	}
+
+	// sellers can only sell their right once a round
+	other := &traderBiker{BaseBiker: objects.GetBaseBiker(utils.Red, uuid.New())}
+	s.AddAgent(other)
+	buyer.orders = []objects.Order{{Asset: right, Side: objects.Bid, Quantity: 1, Price: 0.2}}
+	other.orders = []objects.Order{{Asset: right, Side: objects.Bid, Quantity: 1, Price: 0.2}}
+	seller.orders = []objects.Order{{Asset: right, Side: objects.Ask, Quantity: 2, Price: 0.1}}
+	s.RunPointsMarket()
+	if len(buyer.trades) != 1 || len(other.trades) != 0 {
+		t.Error("Asks of more than one colour right should be rejected")
+	}
+}
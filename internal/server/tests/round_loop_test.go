@@ -655,3 +655,42 @@ func TestLootboxShareDictator(t *testing.T) {
 	}
 
 }
+
+// counts the game states it is given
+type countingBiker struct {
+	*obj.BaseBiker
+	updates map[uuid.UUID]int
+}
+
+func (cb *countingBiker) UpdateGameState(gameState obj.IGameState) {
+	cb.updates[cb.GetID()]++
+	cb.BaseBiker.UpdateGameState(gameState)
+}
+
+func TestPhysicsSubSteps(t *testing.T) {
+	oldInitFunctions, oldSubSteps := server.AgentInitFunctions, utils.PhysicsSubSteps
+	t.Cleanup(func() {
+		server.AgentInitFunctions, utils.PhysicsSubSteps = oldInitFunctions, oldSubSteps
+	})
+	// the agents are given the game state as many times in a round, whatever the number of sub-steps
+	updatesPerRound := func(subSteps int) int {
+		updates := make(map[uuid.UUID]int)
+		server.AgentInitFunctions = []server.AgentInitFunction{func(baseBiker *obj.BaseBiker) obj.IBaseBiker {
+			return &countingBiker{BaseBiker: baseBiker, updates: updates}
+		}}
+		utils.PhysicsSubSteps = subSteps
+		s := server.Initialize(1).(*server.Server)
+		s.FoundingInstitutions()
+		clear(updates)
+		s.RunRoundLoop()
+		// agents killed during the round aren't given the last game states
+		most := 0
+		for _, count := range updates {
+			most = max(most, count)
+		}
+		return most
+	}
+	if single, several := updatesPerRound(1), updatesPerRound(8); single != several {
+		t.Errorf("Expected the game states to be updated once per round, got %d updates with 8 sub-steps and %d with 1", several, single)
+	}
+}
@@ -2,6 +2,7 @@ package server_test
 
 import (
 	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
 	"fmt"
 	"testing"
 
@@ -33,5 +34,6 @@ func TestInitialize(t *testing.T) {
 }
 
 func TestRunGame(t *testing.T) {
+	server2.WithGlobal(t, &server.OutputDirectory, t.TempDir())
 	server.Initialize(1).Start()
 }
@@ -4,7 +4,11 @@ import (
 	"SOMAS2023/internal/common/objects"
 	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/server"
+	"encoding/json"
+	"os"
+	"path/filepath"
 	"slices"
+	"strings"
 	"testing"
 
 	"github.com/google/uuid"
@@ -204,3 +208,42 @@ func TestFoundingInstitutions(t *testing.T) {
 		}
 	}
 }
+
+func TestTerrainDump(t *testing.T) {
+	OnlySpawnBaseBikers(t)
+	path := filepath.Join(t.TempDir(), "terrain.json")
+	if err := os.WriteFile(path, []byte(`{"obstacles": [{"min": {"x": 0, "y": 0}, "max": {"x": 2, "y": 2}}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	oldTerrainFile := utils.TerrainFile
+	utils.TerrainFile = path
+	t.Cleanup(func() {
+		utils.TerrainFile = oldTerrainFile
+	})
+
+	s := server.Initialize(3).(*server.Server)
+	rounds := s.RunSimLoop(2)
+	data, err := json.Marshal([][]server.GameStateDump{rounds})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the terrain is only serialised in the initial dump of the iteration
+	if count := strings.Count(string(data), `"terrain"`); count != 1 {
+		t.Errorf("Expected the terrain to be serialised once, got %d times", count)
+	}
+	gameStates, err := server.DecodeGameDump(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gameState := range gameStates[0] {
+		if terrainMap := gameState.GetTerrain(); terrainMap == nil || len(terrainMap.GetObstacles()) != 1 {
+			t.Errorf("Expected every decoded dump to have the terrain, round %d doesn't", gameState.Iteration)
+		}
+	}
+
+	// dumps of games without a serialised terrain are decoded with a flat one
+	gameStates, err = server.DecodeGameDump([]byte(`[[{"iteration": -1}, {"iteration": 0}]]`))
+	if err != nil || gameStates[0][1].GetTerrain() == nil || len(gameStates[0][1].GetTerrain().GetObstacles()) != 0 {
+		t.Errorf("Expected a flat terrain, got %v", err)
+	}
+}
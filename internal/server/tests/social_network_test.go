@@ -0,0 +1,96 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/server"
+	"bytes"
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// two communities: a triangle of riders that trust each other and a pair in which the trust isn't returned
+func newTrustGameStates() ([][]server.GameStateDump, []uuid.UUID) {
+	a, b, c, d, e := uuid.New(), uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	bikeX, bikeY := uuid.New(), uuid.New()
+	// the classes of the agents of teams 1 and 2, whose packages aren't all named after their team
+	agent := func(class string, groupID int, bike uuid.UUID, reputation map[uuid.UUID]float64) server.AgentDump {
+		return server.AgentDump{Class: class, GroupID: groupID, BikeID: bike, Reputation: reputation}
+	}
+	agents := map[uuid.UUID]server.AgentDump{
+		a: agent("team1.Biker1", 1, bikeX, map[uuid.UUID]float64{b: 0.9, c: 0.9, d: 0.2}),
+		b: agent("team1.Biker1", 1, bikeX, map[uuid.UUID]float64{a: 0.9, c: 0.9}),
+		c: agent("team1.Biker1", 1, bikeX, map[uuid.UUID]float64{a: 0.9, b: 0.9}),
+		d: agent("agent.AgentTwo", 2, bikeY, map[uuid.UUID]float64{e: 0.9}),
+		// e isn't riding, and doesn't trust d back
+		e: agent("agent.AgentTwo", 2, uuid.Nil, map[uuid.UUID]float64{d: 0.3}),
+	}
+	return [][]server.GameStateDump{{
+		{Iteration: -1, Agents: agents},
+		{Iteration: 0, Agents: agents},
+		{Iteration: 1, Agents: agents},
+	}}, []uuid.UUID{a, b, c, d, e}
+}
+
+func TestTrustNetworkMetrics(t *testing.T) {
+	gameStates, ids := newTrustGameStates()
+	network := server.CalculateStatistics(gameStates).Network
+	if len(network) != 1 || len(network[0]) != 2 {
+		t.Fatalf("Expected the metrics of 2 rounds, got %+v", network)
+	}
+
+	metrics := network[0][0]
+	if metrics.Agents != 5 || metrics.TrustEdges != 7 || math.Abs(metrics.Reciprocity-6.0/7) > 1e-9 {
+		t.Errorf("Unexpected trust relations: %+v", metrics)
+	}
+	if math.Abs(metrics.Clustering-0.6) > 1e-9 {
+		t.Errorf("Expected a clustering of 0.6, got %f", metrics.Clustering)
+	}
+	if metrics.Communities != 2 || math.Abs(metrics.Modularity-0.375) > 1e-9 {
+		t.Errorf("Expected 2 communities with a modularity of 0.375, got %d and %f", metrics.Communities, metrics.Modularity)
+	}
+	if math.Abs(metrics.TeamAlignment-1) > 1e-9 || math.Abs(metrics.BikeAlignment-1) > 1e-9 {
+		t.Errorf("Expected the communities to align with the teams and bikes, got %f and %f", metrics.TeamAlignment, metrics.BikeAlignment)
+	}
+	if metrics.InDegreeCentrality[ids[0]] != 0.5 || metrics.InDegreeCentrality[ids[3]] != 0 || metrics.InDegreeCentrality[ids[4]] != 0.25 {
+		t.Errorf("Unexpected in-degree centrality: %+v", metrics.InDegreeCentrality)
+	}
+}
+
+func TestTrustNetworkExport(t *testing.T) {
+	gameStates, ids := newTrustGameStates()
+	graphs, _ := server.BuildTrustNetwork(gameStates)
+	if len(graphs) != 2 || len(graphs[0].Nodes) != 5 || len(graphs[0].Edges) != 9 {
+		t.Fatalf("Expected 2 graphs of 5 nodes and 9 edges, got %+v", graphs)
+	}
+
+	var graphML bytes.Buffer
+	if err := server.WriteGraphML(&graphML, graphs); err != nil {
+		t.Fatal(err)
+	}
+	var document struct {
+		Graphs []struct {
+			Nodes []struct{} `xml:"node"`
+			Edges []struct{} `xml:"edge"`
+		} `xml:"graph"`
+	}
+	if err := xml.Unmarshal(graphML.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	if len(document.Graphs) != 2 || len(document.Graphs[1].Nodes) != 5 || len(document.Graphs[1].Edges) != 9 {
+		t.Errorf("Unexpected GraphML output:\n%s", graphML.String())
+	}
+
+	var gexf bytes.Buffer
+	if err := server.WriteGEXF(&gexf, graphs); err != nil {
+		t.Fatal(err)
+	}
+	// the edges are present in both rounds and their reputation doesn't change
+	edge := `source="0:` + ids[0].String() + `" target="0:` + ids[3].String() + `"`
+	if !strings.Contains(gexf.String(), edge) || strings.Count(gexf.String(), `<spell start="0" end="1"></spell>`) != 14 ||
+		strings.Count(gexf.String(), `<attvalue for="reputation" value="0.2" start="0" end="1"></attvalue>`) != 1 {
+		t.Errorf("Unexpected GEXF output:\n%s", gexf.String())
+	}
+}
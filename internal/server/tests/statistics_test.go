@@ -0,0 +1,376 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func newVerifiedMessage(sender uuid.UUID, truthful bool) server.MessageDump {
+	return server.MessageDump{Sender: sender, Type: "ForcesMessage", Truthful: &truthful}
+}
+
+func TestHonestyStatistics(t *testing.T) {
+	honest, liar, halfLiar, observer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	agents := map[uuid.UUID]server.AgentDump{
+		honest:   {ID: honest, Reputation: map[uuid.UUID]float64{liar: 0.9, halfLiar: 0.5}},
+		liar:     {ID: liar},
+		halfLiar: {ID: halfLiar},
+		observer: {ID: observer, Reputation: map[uuid.UUID]float64{honest: 0.9, halfLiar: 0.5, liar: 0.1}},
+	}
+	gameStates := [][]server.GameStateDump{{
+		{Iteration: -1, Agents: agents, Messages: []server.MessageDump{
+			newVerifiedMessage(honest, true),
+			newVerifiedMessage(liar, false),
+			newVerifiedMessage(halfLiar, true),
+			// unverifiable messages don't count
+			{Sender: observer, Type: "LootboxMessage"},
+		}},
+		{Iteration: 0, Agents: agents, Messages: []server.MessageDump{
+			newVerifiedMessage(honest, true),
+			newVerifiedMessage(liar, false),
+			newVerifiedMessage(halfLiar, false),
+		}},
+	}}
+
+	statistics := server.CalculateStatistics(gameStates).Average
+	expectedLyingRates := map[uuid.UUID]float64{honest: 0, liar: 1, halfLiar: 0.5}
+	if len(statistics.AgentLyingRate) != len(expectedLyingRates) {
+		t.Errorf("Expected lying rates for %d agents, got %d", len(expectedLyingRates), len(statistics.AgentLyingRate))
+	}
+	for id, expected := range expectedLyingRates {
+		if math.Abs(statistics.AgentLyingRate[id]-expected) > 1e-9 {
+			t.Errorf("Expected lying rate %f, got %f", expected, statistics.AgentLyingRate[id])
+		}
+	}
+
+	if accuracy, ok := statistics.AgentReputationAccuracy[observer]; !ok || math.Abs(accuracy-1) > 1e-9 {
+		t.Errorf("Expected a perfect reputation accuracy for the observer, got %f", accuracy)
+	}
+	// only 2 of the agents ranked by the honest agent have a lying rate
+	if _, ok := statistics.AgentReputationAccuracy[honest]; ok {
+		t.Error("Reputation accuracy needs at least 3 ranked agents")
+	}
+}
+
+func TestWelfareStatistics(t *testing.T) {
+	rich, poor, dead := uuid.New(), uuid.New(), uuid.New()
+	bike := uuid.New()
+	gameStates := [][]server.GameStateDump{{
+		{
+			Agents: map[uuid.UUID]server.AgentDump{
+				rich: {ID: rich, EnergyLevel: 0.8, Points: 3},
+				poor: {ID: poor, EnergyLevel: 0.2, Points: 1},
+				dead: {ID: dead, EnergyLevel: 0.2, Points: 0},
+			},
+			Bikes: map[uuid.UUID]server.BikeDump{
+				bike: {AgentIDs: []uuid.UUID{rich, poor}, Governance: utils.Leadership},
+			},
+			Allocations: []server.AllocationDump{{
+				Bike:       bike,
+				Governance: utils.Leadership,
+				Shares:     map[uuid.UUID]float64{rich: 1},
+				Pedals:     map[uuid.UUID]float64{rich: 0.5, poor: 0.5},
+			}},
+		},
+		{
+			Agents: map[uuid.UUID]server.AgentDump{
+				rich: {ID: rich, EnergyLevel: 0.5, Points: 2},
+				poor: {ID: poor, EnergyLevel: 0.5, Points: 2},
+			},
+		},
+	}}
+
+	statistics := server.CalculateStatistics(gameStates)
+	welfare := statistics.Welfare[0]
+	expected := map[string][]float64{
+		"energy gini":         {1.0 / 3, 0},
+		"points gini":         {0.5, 0},
+		"utilitarian welfare": {0.4, 0.5},
+		"egalitarian welfare": {0.2, 0.5},
+		"nash welfare":        {math.Cbrt(0.8 * 0.2 * 0.2), 0.5},
+		"survival rate":       {1, 2.0 / 3},
+	}
+	actual := map[string][]float64{
+		"energy gini":         welfare.EnergyGini,
+		"points gini":         welfare.PointsGini,
+		"utilitarian welfare": welfare.UtilitarianWelfare,
+		"egalitarian welfare": welfare.EgalitarianWelfare,
+		"nash welfare":        welfare.NashWelfare,
+		"survival rate":       welfare.SurvivalRate,
+	}
+	for name, values := range expected {
+		for round, value := range values {
+			if math.Abs(actual[name][round]-value) > 1e-9 {
+				t.Errorf("Expected %s %f in round %d, got %f", name, value, round, actual[name][round])
+			}
+		}
+	}
+
+	// the whole loot went to one of two riders that pedalled equally
+	if fairness, ok := welfare.AllocationFairness[0]; !ok || math.Abs(fairness-0.5) > 1e-9 {
+		t.Errorf("Expected an allocation fairness of 0.5, got %f", fairness)
+	}
+	if _, ok := welfare.AllocationFairness[1]; ok {
+		t.Error("Rounds without allocations should have no allocation fairness")
+	}
+	if math.Abs(welfare.PerBike[0][bike].EnergyGini-0.3) > 1e-9 {
+		t.Errorf("Expected an energy Gini of 0.3 on the bike, got %f", welfare.PerBike[0][bike].EnergyGini)
+	}
+
+	leadership, ok := statistics.WelfareByGovernance["leadership"]
+	if !ok || leadership.BikeRounds != 1 || leadership.SurvivalRate != 1 || leadership.Allocations != 1 {
+		t.Errorf("Unexpected leadership welfare: %+v", leadership)
+	}
+}
+
+func TestAggregateStatistics(t *testing.T) {
+	a1, a2, b1 := uuid.New(), uuid.New(), uuid.New()
+	bike := uuid.New()
+	iteration := func(a1Points int, b1Points int, governance utils.Governance) []server.GameStateDump {
+		return []server.GameStateDump{
+			{
+				Iteration: -1,
+				Agents: map[uuid.UUID]server.AgentDump{
+					a1: {ID: a1, Class: "team1.Biker1", GroupID: 1},
+					a2: {ID: a2, Class: "team1.Biker1", GroupID: 1},
+					b1: {ID: b1, Class: "objects.BaseBiker"},
+				},
+				Bikes: map[uuid.UUID]server.BikeDump{bike: {AgentIDs: []uuid.UUID{a1, a2, b1}, Governance: governance}},
+			},
+			{
+				Agents: map[uuid.UUID]server.AgentDump{
+					a1: {ID: a1, Class: "team1.Biker1", GroupID: 1, Points: a1Points, EnergyLevel: 0.5},
+					b1: {ID: b1, Class: "objects.BaseBiker", Points: b1Points, EnergyLevel: 1},
+				},
+				Bikes: map[uuid.UUID]server.BikeDump{bike: {AgentIDs: []uuid.UUID{a1, b1}, Governance: governance}},
+			},
+		}
+	}
+	gameStates := [][]server.GameStateDump{
+		iteration(4, 2, utils.Democracy),
+		iteration(2, 6, utils.Democracy),
+		iteration(3, 1, utils.Dictatorship),
+	}
+
+	statistics := server.CalculateStatistics(gameStates)
+	if statistics.AgentIDToTeam[a1] != "team1" || statistics.AgentIDToTeam[b1] != "base" {
+		t.Errorf("Unexpected team names: %v", statistics.AgentIDToTeam)
+	}
+
+	team1, base := statistics.ByTeam["team1"], statistics.ByTeam["base"]
+	if team1.Iterations != 3 || math.Abs(team1.Lifetime.Mean-0.5) > 1e-9 || team1.Lifetime.Lower != team1.Lifetime.Upper {
+		t.Errorf("Unexpected team1 lifetime: %+v", team1.Lifetime)
+	}
+	// the final points of a2 are the points it had when it died
+	if math.Abs(team1.FinalPoints.Mean-1.5) > 1e-9 || math.Abs(team1.WinRate.Mean-2.0/3) > 1e-9 || math.Abs(base.WinRate.Mean-1.0/3) > 1e-9 {
+		t.Errorf("Unexpected team1 statistics: %+v", team1)
+	}
+	// team1's mean points are 2, 1 and 1.5: the interval is 1.5 ± 4.303 * 0.5 / √3
+	halfWidth := 4.303 * 0.5 / math.Sqrt(3)
+	if math.Abs(team1.FinalPoints.Lower-(1.5-halfWidth)) > 1e-9 || math.Abs(team1.FinalPoints.Upper-(1.5+halfWidth)) > 1e-9 {
+		t.Errorf("Unexpected confidence interval: %+v", team1.FinalPoints)
+	}
+
+	if statistics.ByGovernance["democracy"].Iterations != 2 || statistics.ByGovernance["dictatorship"].WinRate.Mean != 1 {
+		t.Errorf("Unexpected governance statistics: %+v", statistics.ByGovernance)
+	}
+}
+
+// the teams of the agents spawned are their position in AgentInitFunctions, whatever the package of their class
+func TestTeams(t *testing.T) {
+	s := server.Initialize(1).(*server.Server)
+	gameState := s.NewGameStateDump(0)
+	statistics := server.CalculateStatistics([][]server.GameStateDump{{gameState}})
+	expected := map[string]string{
+		"objects.BaseBiker":         "base",
+		"team1.Biker1":              "team1",
+		"agent.AgentTwo":            "team2",
+		"team3.SmartAgent":          "team3",
+		"agents.BaseTeamSevenBiker": "team7",
+	}
+	found := make(map[string]bool)
+	for id, agent := range gameState.Agents {
+		if team, ok := expected[agent.Class]; ok {
+			found[agent.Class] = true
+			if statistics.AgentIDToTeam[id] != team {
+				t.Errorf("Expected %s to be in %s, got %s", agent.Class, team, statistics.AgentIDToTeam[id])
+			}
+		}
+	}
+	if len(found) != len(expected) {
+		t.Errorf("Expected agents of every class in %v, got %v", expected, found)
+	}
+	if teams := len(statistics.ByTeam); teams != len(server.AgentInitFunctions)+1 {
+		t.Errorf("Expected %d teams, got %d", len(server.AgentInitFunctions)+1, teams)
+	}
+}
+
+func TestSurvivalStatistics(t *testing.T) {
+	run, starve, crash, survive := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	agent := func(id uuid.UUID) server.AgentDump {
+		return server.AgentDump{ID: id, Class: "team1.Biker1", GroupID: 1}
+	}
+	gameStates := [][]server.GameStateDump{{
+		{Iteration: -1, Agents: map[uuid.UUID]server.AgentDump{run: agent(run), starve: agent(starve), crash: agent(crash), survive: agent(survive)}},
+		{Iteration: 0, Agents: map[uuid.UUID]server.AgentDump{run: agent(run), crash: agent(crash), survive: agent(survive)},
+			Deaths: []server.DeathDump{{Agent: starve, Cause: server.Starvation, Round: 0}}},
+		{Iteration: 1, Agents: map[uuid.UUID]server.AgentDump{run: agent(run), survive: agent(survive)},
+			Deaths: []server.DeathDump{{Agent: crash, Cause: server.AudiCollision, Round: 1}}},
+		// the last agent vanishes without dying and is censored like the survivor
+		{Iteration: 2, Agents: map[uuid.UUID]server.AgentDump{survive: agent(survive)}},
+	}}
+
+	survival := server.CalculateStatistics(gameStates).Survival
+	if len(survival.Deaths) != 2 || survival.Deaths[0].Team != "team1" {
+		t.Fatalf("Expected 2 deaths of team1, got %+v", survival.Deaths)
+	}
+
+	expected := []server.SurvivalPoint{
+		{Round: 0, AtRisk: 4, Deaths: 1, Survival: 0.75},
+		{Round: 1, AtRisk: 3, Deaths: 1, Censored: 1, Survival: 0.5},
+		{Round: 2, AtRisk: 1, Censored: 1, Survival: 0.5},
+	}
+	curve := survival.KaplanMeier["team1"]
+	if len(curve) != len(expected) {
+		t.Fatalf("Expected %d steps in the survival curve, got %d", len(expected), len(curve))
+	}
+	for i := range expected {
+		if curve[i].Round != expected[i].Round || curve[i].AtRisk != expected[i].AtRisk || curve[i].Deaths != expected[i].Deaths ||
+			curve[i].Censored != expected[i].Censored || math.Abs(curve[i].Survival-expected[i].Survival) > 1e-9 {
+			t.Errorf("Expected step %+v, got %+v", expected[i], curve[i])
+		}
+	}
+
+	// the agents were at risk for 1 + 2 + 2 + 3 rounds
+	hazard := survival.Hazards["team1"][server.Starvation]
+	if hazard.Deaths != 1 || math.Abs(hazard.Rate-1.0/8) > 1e-9 || hazard.Share != 0.5 {
+		t.Errorf("Unexpected starvation hazard: %+v", hazard)
+	}
+
+	var csv strings.Builder
+	if err := survival.WriteKaplanMeierCSV(&csv); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(csv.String()), "\n"); len(lines) != 4 || lines[1] != "team1,0,4,1,0,0.75" {
+		t.Errorf("Unexpected Kaplan-Meier CSV:\n%s", csv.String())
+	}
+}
+
+func TestBikeStatistics(t *testing.T) {
+	bikeID, rider, leaver := uuid.New(), uuid.New(), uuid.New()
+	bike := func(x float64, governance utils.Governance, ruler uuid.UUID, events server.BikeEvents, riders ...uuid.UUID) map[uuid.UUID]server.BikeDump {
+		dump := server.BikeDump{AgentIDs: riders, Governance: governance, Ruler: ruler, Events: events}
+		dump.PhysicalState.Position = utils.Coordinates{X: x, Y: 4 * x / 3}
+		return map[uuid.UUID]server.BikeDump{bikeID: dump}
+	}
+	gameStates := [][]server.GameStateDump{{
+		{Iteration: -1, Bikes: bike(0, utils.Democracy, uuid.Nil, server.BikeEvents{}, rider, leaver)},
+		{Iteration: 0, Bikes: bike(3, utils.Democracy, uuid.Nil, server.BikeEvents{Joins: 1, LootBoxes: 1}, rider, leaver)},
+		{Iteration: 1, Bikes: bike(3, utils.Democracy, uuid.Nil, server.BikeEvents{Leaves: 1}, rider)},
+		{Iteration: 2, Bikes: bike(3, utils.Dictatorship, rider, server.BikeEvents{ColourPoints: 5}, rider)},
+		{Iteration: 3, Bikes: bike(3, utils.Dictatorship, rider, server.BikeEvents{}, rider)},
+		// the Audi removes the bike with its rider
+		{Iteration: 4, Deaths: []server.DeathDump{{Agent: rider, Cause: server.AudiCollision, Round: 4, Bike: bikeID, Governance: utils.Dictatorship}}},
+	}}
+
+	statistics := server.CalculateStatistics(gameStates)
+	series := statistics.Bikes[0][bikeID]
+	if len(series) != 5 {
+		t.Fatalf("Expected 5 rounds for the bike, got %+v", series)
+	}
+	if series[0].Distance != 5 || series[1].Distance != 0 || series[3].RulerTenure != 2 || series[4].AudiKills != 1 {
+		t.Errorf("Unexpected bike time series: %+v", series)
+	}
+
+	democracy := statistics.InstitutionsByGovernance["democracy"]
+	if democracy.Institutions != 1 || democracy.MeanLifespan != 2 || democracy.RiderRounds != 3 || democracy.RulerTenures != 0 ||
+		math.Abs(democracy.Churn-2.0/3) > 1e-9 || democracy.LootBoxRate != 0.5 || democracy.DistanceRate != 2.5 {
+		t.Errorf("Unexpected democracy statistics: %+v", democracy)
+	}
+	dictatorship := statistics.InstitutionsByGovernance["dictatorship"]
+	if dictatorship.Institutions != 1 || dictatorship.MeanLifespan != 2 || dictatorship.RulerTenures != 1 || dictatorship.MeanRulerTenure != 2 ||
+		dictatorship.Churn != 0 || dictatorship.ColourPointRate != 2.5 || dictatorship.AudiKillRate != 0.5 {
+		t.Errorf("Unexpected dictatorship statistics: %+v", dictatorship)
+	}
+}
+
+func TestWelford(t *testing.T) {
+	var empty server.Welford
+	if empty.Mean() != 0 || empty.Variance() != 0 || !math.IsNaN(empty.SampleVariance()) {
+		t.Errorf("Unexpected statistics without values: %f, %f, %f", empty.Mean(), empty.Variance(), empty.SampleVariance())
+	}
+
+	var w server.Welford
+	for _, value := range []float64{2, 4, 4, 4, 5, 5, 7, 9} {
+		w.Add(value)
+	}
+	if w.Count() != 8 || w.Mean() != 5 || w.Variance() != 4 || math.Abs(w.SampleVariance()-32.0/7) > 1e-12 {
+		t.Errorf("Expected a mean of 5 and a variance of 4, got %f and %f", w.Mean(), w.Variance())
+	}
+
+	// the naive E(x^2) - E(x)^2 loses all precision with a large offset
+	var offset server.Welford
+	for _, value := range []float64{4, 7, 13, 16} {
+		offset.Add(1e9 + value)
+	}
+	if math.Abs(offset.Variance()-22.5) > 1e-6 || math.Abs(offset.SampleVariance()-30) > 1e-6 {
+		t.Errorf("Expected a variance of 22.5, got %f", offset.Variance())
+	}
+}
+
+func TestAgentStatistics(t *testing.T) {
+	agent, survivor := uuid.New(), uuid.New()
+	state := func(round int, energy float64, points int) server.GameStateDump {
+		return server.GameStateDump{Iteration: round, Agents: map[uuid.UUID]server.AgentDump{
+			agent:    {EnergyLevel: energy, Points: points},
+			survivor: {EnergyLevel: 1},
+		}}
+	}
+	final := server.GameStateDump{Iteration: 2, Agents: map[uuid.UUID]server.AgentDump{survivor: {EnergyLevel: 1}}}
+	gameStates := [][]server.GameStateDump{{state(-1, 1, 0), state(0, 0.5, 2), state(1, 0.25, 4), final}}
+
+	statistics := server.CalculateStatistics(gameStates).PerRound[0]
+	// the agent was alive at the end of rounds 0 and 1, and its statistics include its initial state
+	if statistics.AgentLifetime[agent] != 2 || statistics.AgentLifetime[survivor] != 3 {
+		t.Errorf("Unexpected lifetimes: %v", statistics.AgentLifetime)
+	}
+	if math.Abs(statistics.AgentEnergyAverage[agent]-1.75/3) > 1e-12 || math.Abs(statistics.AgentEnergyVariance[agent]-0.0972222222) > 1e-9 {
+		t.Errorf("Unexpected energy statistics: %f, %f", statistics.AgentEnergyAverage[agent], statistics.AgentEnergyVariance[agent])
+	}
+	if statistics.AgentPointsAverage[agent] != 2 || math.Abs(statistics.AgentPointsVariance[agent]-8.0/3) > 1e-12 {
+		t.Errorf("Unexpected points statistics: %f, %f", statistics.AgentPointsAverage[agent], statistics.AgentPointsVariance[agent])
+	}
+	if statistics.AgentEnergyVariance[survivor] != 0 {
+		t.Errorf("Expected no variance for a constant energy, got %f", statistics.AgentEnergyVariance[survivor])
+	}
+}
+
+type roundRecorder struct {
+	rounds [][2]int
+}
+
+func (r *roundRecorder) ObserveRound(iteration int, gameState server.GameStateDump) {
+	r.rounds = append(r.rounds, [2]int{iteration, gameState.Iteration})
+}
+
+func TestRoundObserver(t *testing.T) {
+	server2.OnlySpawnBaseBikers(t)
+	s := server.Initialize(2).(*server.Server)
+	recorder := &roundRecorder{}
+	s.AddRoundObserver(recorder)
+	s.UpdateGameStates()
+	s.RunSimLoop(2)
+	s.RunSimLoop(1)
+
+	expected := [][2]int{{0, -1}, {0, 0}, {0, 1}, {1, -1}, {1, 0}}
+	if !slices.Equal(recorder.rounds, expected) {
+		t.Errorf("Expected the rounds %v to be observed, got %v", expected, recorder.rounds)
+	}
+}
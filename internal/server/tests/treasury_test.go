@@ -0,0 +1,149 @@
+package server_test
+
+import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
+	"SOMAS2023/internal/common/voting"
+	"SOMAS2023/internal/server"
+	server2 "SOMAS2023/internal/server/tests"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type treasuryBiker struct {
+	*objects.BaseBiker
+	taxRate  float64
+	weights  map[uuid.UUID]float64
+	spending map[uuid.UUID]float64
+}
+
+func (tb *treasuryBiker) VoteTaxRate() float64 {
+	return tb.taxRate
+}
+
+func (tb *treasuryBiker) DecideTaxRate() float64 {
+	return tb.taxRate
+}
+
+func (tb *treasuryBiker) DecideWeights(action utils.Action) map[uuid.UUID]float64 {
+	return tb.weights
+}
+
+func (tb *treasuryBiker) DecideTreasurySpending() map[uuid.UUID]float64 {
+	return tb.spending
+}
+
+func (tb *treasuryBiker) DecideDictatorAllocation() voting.IdVoteMap {
+	return voting.IdVoteMap{tb.GetID(): 1.0}
+}
+
+// sets up a server with only the given number of agents sharing a bike with the given governance, the first being its ruler
+func setupTreasuryServer(t *testing.T, governance utils.Governance, n int) (*server.Server, objects.IMegaBike, []*treasuryBiker) {
+	bikers := make([]*treasuryBiker, n)
+	for i := range bikers {
+		bikers[i] = &treasuryBiker{BaseBiker: objects.GetBaseBiker(utils.GenerateRandomColour(), uuid.New())}
+	}
+	s, bike := server2.SetupBike(t, governance, bikers...)
+	return s, bike, bikers
+}
+
+func TestTreasuryDisabledByDefault(t *testing.T) {
+	s, bike, bikers := setupTreasuryServer(t, utils.Dictatorship, 1)
+	bikers[0].taxRate = 0.5
+	s.RunTaxRateDecision()
+	if bike.GetTaxRate() != 0 {
+		t.Error("The tax rate should not be set when the treasury is disabled")
+	}
+}
+
+func TestDemocraticTaxRate(t *testing.T) {
+	server2.WithGlobal(t, &server.Treasury, server.TreasuryModel{Enabled: true})
+	s, bike, bikers := setupTreasuryServer(t, utils.Democracy, 2)
+	bikers[0].taxRate = 0.1
+	bikers[1].taxRate = 0.3
+	s.RunTaxRateDecision()
+	if math.Abs(bike.GetTaxRate()-0.2) > 1e-9 {
+		t.Errorf("Expected the mean of the votes as tax rate, got %f", bike.GetTaxRate())
+	}
+}
+
+func TestLeadershipTaxRate(t *testing.T) {
+	server2.WithGlobal(t, &server.Treasury, server.TreasuryModel{Enabled: true})
+	s, bike, bikers := setupTreasuryServer(t, utils.Leadership, 2)
+	bikers[0].taxRate = 0.1
+	bikers[1].taxRate = 0.4
+	bikers[0].weights = map[uuid.UUID]float64{bikers[0].GetID(): 2, bikers[1].GetID(): 1}
+	s.RunTaxRateDecision()
+	if math.Abs(bike.GetTaxRate()-0.2) > 1e-9 {
+		t.Errorf("Expected the votes to be weighted by the leader, got %f", bike.GetTaxRate())
+	}
+}
+
+func TestDictatorTaxRate(t *testing.T) {
+	server2.WithGlobal(t, &server.Treasury, server.TreasuryModel{Enabled: true, MaxTaxRate: 0.5})
+	s, bike, bikers := setupTreasuryServer(t, utils.Dictatorship, 2)
+	bikers[0].taxRate = 0.3
+	bikers[1].taxRate = 0.1
+	s.RunTaxRateDecision()
+	if bike.GetTaxRate() != 0.3 {
+		t.Errorf("Expected the dictator to set the tax rate, got %f", bike.GetTaxRate())
+	}
+
+	bikers[0].taxRate = 0.9
+	s.RunTaxRateDecision()
+	if bike.GetTaxRate() != 0.5 {
+		t.Errorf("Expected the tax rate to be capped, got %f", bike.GetTaxRate())
+	}
+}
+
+func TestLootTax(t *testing.T) {
+	server2.WithGlobal(t, &server.Treasury, server.TreasuryModel{Enabled: true})
+	s, bike, bikers := setupTreasuryServer(t, utils.Dictatorship, 1)
+	bike.SetTaxRate(0.25)
+	// keep the agent's energy far from the cap so that the whole loot is received
+	bikers[0].UpdateEnergyLevel(-10)
+
+	var lootBox objects.ILootBox
+	for _, lootBox = range s.GetLootBoxes() {
+		break
+	}
+	bike.SetPhysicalState(utils.PhysicalState{Position: lootBox.GetPosition(), Mass: bike.GetPhysicalState().Mass})
+	s.LootboxCheckAndDistributions()
+
+	received := bikers[0].GetEnergyLevel() + 9
+	if received <= 0 || math.Abs(bike.GetTreasury()/(bike.GetTreasury()+received)-0.25) > 1e-9 {
+		t.Errorf("Expected a quarter of the loot to be paid into the treasury, got %f of %f", bike.GetTreasury(), bike.GetTreasury()+received)
+	}
+}
+
+func TestWelfareTopUp(t *testing.T) {
+	server2.WithGlobal(t, &server.Treasury, server.TreasuryModel{Enabled: true, WelfareThreshold: 0.5})
+	s, bike, bikers := setupTreasuryServer(t, utils.Democracy, 3)
+	bike.UpdateTreasury(0.35)
+	bikers[0].UpdateEnergyLevel(-0.8)
+	bikers[1].UpdateEnergyLevel(-0.6)
+	s.RunTreasurySpending()
+
+	// the poorest rider is topped up first, the second one with what is left
+	if math.Abs(bikers[0].GetEnergyLevel()-0.5) > 1e-9 || math.Abs(bikers[1].GetEnergyLevel()-0.45) > 1e-9 || bikers[2].GetEnergyLevel() != 1.0 {
+		t.Errorf("Unexpected energy levels after the welfare top-ups: %f, %f, %f", bikers[0].GetEnergyLevel(), bikers[1].GetEnergyLevel(), bikers[2].GetEnergyLevel())
+	}
+	if bike.GetTreasury() > 1e-9 {
+		t.Error("Expected the treasury to be emptied")
+	}
+}
+
+func TestRulerSpending(t *testing.T) {
+	server2.WithGlobal(t, &server.Treasury, server.TreasuryModel{Enabled: true})
+	s, bike, bikers := setupTreasuryServer(t, utils.Leadership, 2)
+	bike.UpdateTreasury(0.3)
+	bikers[1].UpdateEnergyLevel(-0.8)
+	bikers[0].spending = map[uuid.UUID]float64{bikers[1].GetID(): 0.5, uuid.New(): 0.5}
+	s.RunTreasurySpending()
+
+	if math.Abs(bikers[1].GetEnergyLevel()-0.5) > 1e-9 || bike.GetTreasury() != 0 {
+		t.Error("Expected the ruler to spend the whole treasury on the rider")
+	}
+}
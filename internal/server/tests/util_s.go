@@ -1,14 +1,64 @@
 package server
 
 import (
+	"SOMAS2023/internal/common/objects"
+	"SOMAS2023/internal/common/utils"
 	"SOMAS2023/internal/server"
 	"testing"
 )
 
 func OnlySpawnBaseBikers(t *testing.T) {
-	oldInitFunctions := server.AgentInitFunctions
-	server.AgentInitFunctions = []server.AgentInitFunction{nil}
+	WithGlobal(t, &server.AgentInitFunctions, []server.AgentInitFunction{nil})
+}
+
+// WithGlobal sets a package-level variable (e.g. a model of the server) for the duration of the test
+func WithGlobal[T any](t *testing.T, global *T, value T) {
+	oldValue := *global
+	*global = value
 	t.Cleanup(func() {
-		server.AgentInitFunctions = oldInitFunctions
+		*global = oldValue
 	})
 }
+
+// EmptyServer initialises a server for one iteration and removes all of its agents
+func EmptyServer(t *testing.T) *server.Server {
+	OnlySpawnBaseBikers(t)
+	s := server.Initialize(1).(*server.Server)
+	for _, agent := range s.GetAgentMap() {
+		s.RemoveAgent(agent)
+	}
+	return s
+}
+
+// SetupBike initialises a server whose only agents are the given ones, sharing a bike with the given governance.
+// The first agent rules the bike unless it is a democracy
+func SetupBike[T objects.IBaseBiker](t *testing.T, governance utils.Governance, agents ...T) (*server.Server, objects.IMegaBike) {
+	s := EmptyServer(t)
+	bike := s.GetMegaBikes()[s.GetRandomBikeId()]
+	bike.SetGovernance(governance)
+	RideBike(s, bike, agents...)
+	if governance != utils.Democracy && len(agents) > 0 {
+		bike.SetRuler(agents[0].GetID())
+	}
+	s.UpdateGameStates()
+	return s, bike
+}
+
+// RideBike adds the agents to the server and puts them on the bike
+func RideBike[T objects.IBaseBiker](s *server.Server, bike objects.IMegaBike, agents ...T) {
+	for _, agent := range agents {
+		s.AddAgent(agent)
+		agent.SetBike(bike.GetID())
+		s.AddAgentToBike(agent)
+	}
+}
+
+// OtherBike returns a bike of the server other than the given one
+func OtherBike(s *server.Server, bike objects.IMegaBike) objects.IMegaBike {
+	for id, other := range s.GetMegaBikes() {
+		if id != bike.GetID() {
+			return other
+		}
+	}
+	panic("the server has a single bike")
+}
@@ -0,0 +1,248 @@
+package viewer
+
+import (
+	"SOMAS2023/internal/server"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Stream publishes the rounds of a game as server-sent events while they are played, and lets its clients pause the
+// game, step through it round by round and resume it. It is a RoundObserver of the server, which it holds back while
+// the game is paused
+type Stream struct {
+	mu sync.Mutex
+	// the events of the last bufferedRounds rounds, numbered from first, from which the clients that fall behind
+	// catch up. Older rounds are dropped so that the memory of the stream doesn't grow with the game
+	events [][]byte
+	first  int
+	// numbers of the first events of the rounds held, the last being the latest round
+	rounds []int
+	// replayed with the latest round to the clients that connect later: the initial round of the iteration, which
+	// holds its terrain, and the latest control event, with their numbers
+	initial     []byte
+	initialSeq  int
+	lastControl []byte
+	controlSeq  int
+	paused      bool
+	// rounds that can be played while the game is paused
+	steps  int
+	closed bool
+	// closed and replaced whenever an event is published or the game is paused or resumed
+	changed chan struct{}
+}
+
+// number of rounds whose events are held for the clients that fall behind
+const bufferedRounds = 16
+
+// the data of the round event, sent after every round with its dump
+type roundEvent struct {
+	Iteration int                  `json:"iteration"`
+	State     server.GameStateDump `json:"state"`
+}
+
+// the data of the events of a round: deaths, judiciary cases, lootbox allocations, energy transfers and trades.
+// Contracts and loans last several rounds, so they are only part of the round dumps
+type gameEvent struct {
+	Iteration int `json:"iteration"`
+	Round     int `json:"round"`
+	Event     any `json:"event"`
+}
+
+type controlEvent struct {
+	Paused bool `json:"paused"`
+}
+
+func NewStream(paused bool) *Stream {
+	return &Stream{paused: paused, changed: make(chan struct{})}
+}
+
+// ObserveRound publishes the round and its events, then waits while the game is paused
+func (st *Stream) ObserveRound(iteration int, gameState server.GameStateDump) {
+	st.publish("round", roundEvent{Iteration: iteration, State: gameState})
+	for _, events := range []struct {
+		kind   string
+		events []any
+	}{
+		{"death", toAny(gameState.Deaths)},
+		{"case", toAny(gameState.Cases)},
+		{"allocation", toAny(gameState.Allocations)},
+		{"transfer", toAny(gameState.Transfers)},
+		{"trade", toAny(gameState.Trades)},
+	} {
+		for _, event := range events.events {
+			st.publish(events.kind, gameEvent{Iteration: iteration, Round: gameState.Iteration, Event: event})
+		}
+	}
+
+	for {
+		st.mu.Lock()
+		if !st.paused || st.closed {
+			st.mu.Unlock()
+			return
+		}
+		if st.steps > 0 {
+			st.steps--
+			st.mu.Unlock()
+			return
+		}
+		changed := st.changed
+		st.mu.Unlock()
+		<-changed
+	}
+}
+
+func toAny[T any](values []T) []any {
+	result := make([]any, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+func (st *Stream) Pause() {
+	st.update(func() { st.paused, st.steps = true, 0 })
+}
+
+// Step plays one more round then pauses the game
+func (st *Stream) Step() {
+	st.update(func() { st.paused, st.steps = true, st.steps+1 })
+}
+
+func (st *Stream) Resume() {
+	st.update(func() { st.paused, st.steps = false, 0 })
+}
+
+// changes the state of the game under the lock and publishes it to the clients
+func (st *Stream) update(change func()) {
+	st.mu.Lock()
+	change()
+	paused := st.paused
+	st.mu.Unlock()
+	st.publish("control", controlEvent{Paused: paused})
+}
+
+func (st *Stream) Paused() bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.paused
+}
+
+// Close publishes the end of the game and lets it run to completion without pausing
+func (st *Stream) Close() {
+	st.mu.Lock()
+	st.closed = true
+	st.mu.Unlock()
+	st.publish("end", struct{}{})
+}
+
+// publishes the event to the clients, events that can't be encoded (e.g. with NaN values) are left out rather than
+// stopping the game
+func (st *Stream) publish(kind string, data any) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		fmt.Printf("Could not stream %s event: %v\n", kind, err)
+		return
+	}
+	event := []byte(fmt.Sprintf("event: %s\ndata: %s\n\n", kind, encoded))
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	switch kind {
+	case "round":
+		if len(st.rounds) == bufferedRounds {
+			st.events = st.events[st.rounds[1]-st.first:]
+			st.first, st.rounds = st.rounds[1], st.rounds[1:]
+		}
+		st.rounds = append(st.rounds, st.first+len(st.events))
+		if data.(roundEvent).State.Iteration < 0 {
+			st.initial, st.initialSeq = event, st.first+len(st.events)
+		}
+	case "control":
+		st.lastControl, st.controlSeq = event, st.first+len(st.events)
+	}
+	st.events = append(st.events, event)
+	close(st.changed)
+	st.changed = make(chan struct{})
+}
+
+// the events sent to a client that connects, or that falls behind the rounds held: the initial round of the
+// iteration and the state of the game, unless they were published in the latest round, then the latest round with
+// its events
+func (st *Stream) replay() [][]byte {
+	latest := st.first
+	if len(st.rounds) > 0 {
+		latest = st.rounds[len(st.rounds)-1]
+	}
+	events := make([][]byte, 0, st.first+len(st.events)-latest+2)
+	if st.initial != nil && st.initialSeq < latest {
+		events = append(events, st.initial)
+	}
+	if st.lastControl != nil && st.controlSeq < latest {
+		events = append(events, st.lastControl)
+	}
+	return append(events, st.events[latest-st.first:]...)
+}
+
+// ServeHTTP streams the replay then every event published until the client disconnects, going back to the replay if
+// the client falls behind the rounds held
+func (st *Stream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// number of the next event to send, the replay is sent first
+	next := -1
+	for {
+		st.mu.Lock()
+		var events [][]byte
+		if next < st.first {
+			events = st.replay()
+		} else {
+			events = st.events[next-st.first:]
+		}
+		next = st.first + len(st.events)
+		changed := st.changed
+		st.mu.Unlock()
+		for _, event := range events {
+			if _, err := w.Write(event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		select {
+		case <-changed:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handles the pause, step and resume requests of the clients
+func (st *Stream) control(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "controls must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+	switch strings.TrimPrefix(r.URL.Path, "/control/") {
+	case "pause":
+		st.Pause()
+	case "step":
+		st.Step()
+	case "resume":
+		st.Resume()
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,122 @@
+package viewer
+
+import (
+	"SOMAS2023/internal/server"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+//go:embed static
+var static embed.FS
+
+// NewHandler serves the viewer page and its assets, and the game dump at /game_dump.json
+func NewHandler(dumpPath string) http.Handler {
+	return newMux(dumpPath, false)
+}
+
+// NewLiveHandler serves the viewer of a game being played: the events of the stream at /events and its controls
+// at /control/pause, /control/step and /control/resume, as well as the game dump once the game is over
+func NewLiveHandler(dumpPath string, stream *Stream) http.Handler {
+	mux := newMux(dumpPath, true)
+	mux.Handle("/events", stream)
+	mux.HandleFunc("/control/", stream.control)
+	return mux
+}
+
+func newMux(dumpPath string, live bool) *http.ServeMux {
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(assets)))
+	// tells the page whether to load the dump or to follow the stream
+	mux.HandleFunc("/config.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		fmt.Fprintf(w, `{"live":%t}`, live)
+	})
+	mux.HandleFunc("/game_dump.json", func(w http.ResponseWriter, r *http.Request) {
+		if _, err := os.Stat(dumpPath); err != nil {
+			http.Error(w, fmt.Sprintf("game dump %s not found, run a game first", dumpPath), http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeFile(w, r, dumpPath)
+	})
+	return mux
+}
+
+// Run is the serve command: it serves the viewer of a game dump until interrupted
+func Run(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	dump := flags.String("dump", "game_dump.json", "game dump to view")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: serve [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("serve takes no arguments")
+	}
+	if _, err := os.Stat(*dump); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "Viewing %s on http://%s\n", *dump, listener.Addr())
+	return http.Serve(listener, NewHandler(*dump))
+}
+
+// RunLive is the live command: it plays a game with play, which must notify the stream of every round, and serves
+// the viewer of the game while it is played. The viewer keeps being served once the game is over until interrupted
+func RunLive(args []string, stdout io.Writer, play func(observers ...server.RoundObserver)) error {
+	flags := flag.NewFlagSet("live", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	paused := flags.Bool("paused", false, "start the game paused, to be stepped through or resumed from the viewer")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: live [flags]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		flags.Usage()
+		return errors.New("live takes no arguments")
+	}
+
+	listener, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	stream := NewStream(*paused)
+	served := make(chan error, 1)
+	go func() {
+		served <- http.Serve(listener, NewLiveHandler(filepath.Join(server.OutputDirectory, "game_dump.json"), stream))
+	}()
+	fmt.Fprintf(stdout, "Streaming the game on http://%s\n", listener.Addr())
+
+	play(stream)
+	stream.Close()
+	fmt.Fprintln(stdout, "The game is over, the viewer is still served until interrupted")
+	return <-served
+}
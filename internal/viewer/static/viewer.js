@@ -0,0 +1,704 @@
+"use strict";
+
+// visual encoding of visualiser/util/Constants.py
+const COORDINATESCALE = 20;
+const PRECISION = 2;
+const MINZOOM = 0.02, MAXZOOM = 2.5;
+const GRIDSPACING = 20;
+const GRID_COLOUR = "#E5E5E5";
+const COLOURS = {
+    red: "#E05558",
+    orange: "#D57901",
+    yellow: "#D5C801",
+    green: "#7BBD01",
+    blue: "#5E82FD",
+    purple: "#A575ED",
+    pink: "#DE82C3",
+    brown: "#AC6223",
+    gray: "#666666",
+    white: "#FFFFFF",
+};
+const GOVERNANCE = [
+    ["Democracy", COLOURS.blue],
+    ["Leadership", COLOURS.green],
+    ["Dictatorship", COLOURS.red],
+    ["Invalid", "#000000"],
+];
+const BIKE = {LINE_WIDTH: 1, LINE_COLOUR: "#000000", TRANSPARENCY: 150 / 255};
+const OWDI = {COLOUR: "#0F0F0F", LINE_WIDTH: 2, LINE_COLOUR: "#000000", FONT_SIZE: 30, SIZE: 140};
+const AGENT = {SIZE: 10, LINE_WIDTH: 2, LINE_COLOUR: "#000000", FONT_SIZE: 20, PADDING: 2};
+const LOOTBOX = {HEIGHT: 60, WIDTH: 120, LINE_WIDTH: 2, LINE_COLOUR: "#000000", FONT_SIZE: 20};
+const ENERGY_BAR = {HEIGHT: 4, FULL: "#7BBD01", EMPTY: "#E05558"};
+// the terrain isn't drawn by the visualiser, its encoding is shared with the renderer
+const TERRAIN = {OBSTACLE: "#8C8C8C", ROUGH: COLOURS.brown, SLOPE: COLOURS.blue, TRANSPARENCY: 70 / 255, SLOPE_LINE_WIDTH: 4};
+
+const canvas = document.getElementById("game");
+const context = canvas.getContext("2d");
+const elements = {
+    play: document.getElementById("play"),
+    previous: document.getElementById("previous"),
+    next: document.getElementById("next"),
+    iteration: document.getElementById("iteration"),
+    timeline: document.getElementById("timeline"),
+    round: document.getElementById("round"),
+    speed: document.getElementById("speed"),
+    legend: document.getElementById("legend"),
+    properties: document.getElementById("properties"),
+    status: document.getElementById("status"),
+    live: document.getElementById("live"),
+    gameStatus: document.getElementById("game-status"),
+    pauseGame: document.getElementById("pause-game"),
+    stepGame: document.getElementById("step-game"),
+    resumeGame: document.getElementById("resume-game"),
+    events: document.getElementById("events"),
+    eventLog: document.getElementById("event-log"),
+};
+const MAX_EVENTS = 200;
+
+const viewer = {
+    // game states of every iteration, as in game_dump.json
+    iterations: [],
+    iteration: 0,
+    round: 0,
+    playing: false,
+    timer: null,
+    zoom: 0.1,
+    offsetX: 20,
+    offsetY: 20,
+    // entity selected for inspection, as {kind, id}
+    selected: null,
+    // clickable areas of the last frame drawn, in drawing order
+    hitAreas: [],
+    // whether the rounds of a live game are shown as soon as they are received
+    following: true,
+};
+
+function setStatus(text) {
+    elements.status.textContent = text;
+}
+
+function currentState() {
+    const rounds = viewer.iterations[viewer.iteration];
+    return rounds ? rounds[viewer.round] : undefined;
+}
+
+// the terrain of the current iteration, which is only part of its initial dump
+function currentTerrain() {
+    const rounds = viewer.iterations[viewer.iteration] || [];
+    const initial = rounds.find(state => state.terrain);
+    return initial ? initial.terrain : undefined;
+}
+
+function round(value) {
+    return typeof value === "number" ? Number(value.toFixed(PRECISION)) : value;
+}
+
+const NIL_ID = "00000000-0000-0000-0000-000000000000";
+
+function shortID(id) {
+    return id && id !== NIL_ID ? id.slice(0, 8) : "-";
+}
+
+function textColour(colour) {
+    return colour === COLOURS.white ? "#000000" : "#FFFFFF";
+}
+
+// ---- loading ----
+
+function load(iterations) {
+    viewer.iterations = iterations.filter(rounds => rounds.length > 0);
+    elements.iteration.replaceChildren(...viewer.iterations.map((_, index) => new Option(index, index)));
+    if (viewer.iterations.length === 0) {
+        setStatus("The game dump has no rounds");
+        return;
+    }
+    setStatus("");
+    showIteration(0);
+    fitToGrid();
+}
+
+function showIteration(iteration) {
+    viewer.iteration = Math.max(0, Math.min(iteration, viewer.iterations.length - 1));
+    elements.iteration.value = viewer.iteration;
+    elements.timeline.max = viewer.iterations[viewer.iteration].length - 1;
+    showRound(0);
+}
+
+function showRound(index) {
+    const rounds = viewer.iterations[viewer.iteration];
+    if (!rounds) {
+        return;
+    }
+    viewer.round = Math.max(0, Math.min(index, rounds.length - 1));
+    elements.timeline.value = viewer.round;
+    const state = rounds[viewer.round];
+    elements.round.textContent = state.iteration < 0 ? "Initial state" : `Round ${state.iteration}`;
+    // going back in a live game stops following it until the latest round is shown again
+    viewer.following = isLatest();
+    draw();
+}
+
+// advances to the next round, then to the next iteration, and stops at the end of the game
+function step() {
+    if (viewer.round < viewer.iterations[viewer.iteration].length - 1) {
+        showRound(viewer.round + 1);
+    } else if (viewer.iteration < viewer.iterations.length - 1) {
+        showIteration(viewer.iteration + 1);
+    } else {
+        pause();
+    }
+}
+
+function play() {
+    viewer.playing = true;
+    elements.play.textContent = "Pause";
+    clearInterval(viewer.timer);
+    viewer.timer = setInterval(step, 1000 / Number(elements.speed.value));
+}
+
+function pause() {
+    viewer.playing = false;
+    elements.play.textContent = "Play";
+    clearInterval(viewer.timer);
+}
+
+// ---- drawing ----
+
+function toScreen(position) {
+    return [
+        position.x * COORDINATESCALE * viewer.zoom + viewer.offsetX,
+        position.y * COORDINATESCALE * viewer.zoom + viewer.offsetY,
+    ];
+}
+
+function fitToGrid() {
+    // the grid is 250 by 250
+    const size = 250 * COORDINATESCALE;
+    viewer.zoom = Math.max(MINZOOM, Math.min(MAXZOOM, Math.min(canvas.width, canvas.height) / (size * 1.05)));
+    viewer.offsetX = (canvas.width - size * viewer.zoom) / 2;
+    viewer.offsetY = (canvas.height - size * viewer.zoom) / 2;
+    draw();
+}
+
+function resize() {
+    canvas.width = canvas.clientWidth;
+    canvas.height = canvas.clientHeight;
+    draw();
+}
+
+function drawGrid() {
+    const spacing = GRIDSPACING * COORDINATESCALE * viewer.zoom;
+    context.strokeStyle = GRID_COLOUR;
+    context.lineWidth = 1;
+    context.beginPath();
+    for (let x = viewer.offsetX % spacing; x < canvas.width; x += spacing) {
+        context.moveTo(x, 0);
+        context.lineTo(x, canvas.height);
+    }
+    for (let y = viewer.offsetY % spacing; y < canvas.height; y += spacing) {
+        context.moveTo(0, y);
+        context.lineTo(canvas.width, y);
+    }
+    context.stroke();
+}
+
+// rough zones and slopes are translucent, and slopes have a line from their centre pointing downhill. Obstacles are
+// drawn last as nothing goes through them
+function drawTerrain(terrain) {
+    const region = ({min, max}) => {
+        const [x1, y1] = toScreen(min), [x2, y2] = toScreen(max);
+        return [x1, y1, x2 - x1, y2 - y1];
+    };
+    context.globalAlpha = TERRAIN.TRANSPARENCY;
+    context.fillStyle = TERRAIN.ROUGH;
+    for (const zone of terrain.rough_zones || []) {
+        context.fillRect(...region(zone.region));
+    }
+    for (const slope of terrain.slopes || []) {
+        const [x, y, width, height] = region(slope.region);
+        context.globalAlpha = TERRAIN.TRANSPARENCY;
+        context.fillStyle = TERRAIN.SLOPE;
+        context.fillRect(x, y, width, height);
+        const length = Math.min(width, height) / 2, angle = slope.downhill * Math.PI;
+        context.globalAlpha = 1;
+        context.strokeStyle = TERRAIN.SLOPE;
+        context.lineWidth = Math.max(TERRAIN.SLOPE_LINE_WIDTH * viewer.zoom, 1);
+        context.beginPath();
+        context.moveTo(x + width / 2, y + height / 2);
+        context.lineTo(x + width / 2 + length * Math.cos(angle), y + height / 2 + length * Math.sin(angle));
+        context.stroke();
+    }
+    context.globalAlpha = 1;
+    context.fillStyle = TERRAIN.OBSTACLE;
+    for (const obstacle of terrain.obstacles || []) {
+        context.fillRect(...region(obstacle));
+    }
+}
+
+function drawLabel(text, x, y, size, colour) {
+    context.fillStyle = colour;
+    context.font = `${size}px Arial`;
+    context.textAlign = "center";
+    context.textBaseline = "middle";
+    context.fillText(text, x, y);
+}
+
+function drawLootbox(id, lootbox) {
+    const [x, y] = toScreen(lootbox.physical_state.position);
+    const width = LOOTBOX.WIDTH * viewer.zoom, height = LOOTBOX.HEIGHT * viewer.zoom;
+    const line = LOOTBOX.LINE_WIDTH * viewer.zoom;
+    const colour = COLOURS[lootbox.colour] || "#000000";
+    context.fillStyle = LOOTBOX.LINE_COLOUR;
+    context.fillRect(x - width / 2 - line, y - height / 2 - line, width + 2 * line, height + 2 * line);
+    context.fillStyle = colour;
+    context.fillRect(x - width / 2, y - height / 2, width, height);
+    drawLabel("Lootbox", x, y, LOOTBOX.FONT_SIZE * viewer.zoom, textColour(colour));
+    viewer.hitAreas.push({kind: "lootbox", id, contains: (mx, my) =>
+        Math.abs(mx - x) <= width / 2 && Math.abs(my - y) <= height / 2});
+}
+
+function drawAgent(id, agent, x, y) {
+    const radius = AGENT.SIZE * viewer.zoom;
+    const colour = COLOURS[agent.colour] || "#000000";
+    context.beginPath();
+    context.arc(x, y, radius + Math.max(AGENT.LINE_WIDTH * viewer.zoom, 1), 0, 2 * Math.PI);
+    context.fillStyle = AGENT.LINE_COLOUR;
+    context.fill();
+    context.beginPath();
+    context.arc(x, y, radius, 0, 2 * Math.PI);
+    context.fillStyle = colour;
+    context.fill();
+    drawLabel(agent.group_id ? String(agent.group_id) : "-", x, y, AGENT.FONT_SIZE * viewer.zoom, textColour(colour));
+
+    // energy bar under the agent, the energy level is between 0 and 1
+    const energy = Math.max(0, Math.min(1, agent.energy_level));
+    const barWidth = 2 * radius, barHeight = Math.max(ENERGY_BAR.HEIGHT * viewer.zoom, 1);
+    const barY = y + radius + AGENT.LINE_WIDTH * viewer.zoom;
+    context.fillStyle = ENERGY_BAR.EMPTY;
+    context.fillRect(x - radius, barY, barWidth, barHeight);
+    context.fillStyle = ENERGY_BAR.FULL;
+    context.fillRect(x - radius, barY, barWidth * energy, barHeight);
+
+    viewer.hitAreas.push({kind: "agent", id, contains: (mx, my) => Math.hypot(mx - x, my - y) <= radius});
+    if (viewer.selected && viewer.selected.id === id) {
+        drawSelection(x - radius, y - radius, 2 * radius, 2 * radius);
+    }
+}
+
+// draws the bike as a square holding its agents in a grid of at most 3 columns, as in visualiser/entities/Bikes.py
+function drawBike(id, bike, agents) {
+    const riders = (bike.agent_ids || []).filter(agentID => agents[agentID]);
+    const gridSize = Math.min(3, Math.max(1, Math.ceil(Math.sqrt(riders.length))));
+    const padding = AGENT.PADDING * viewer.zoom;
+    const agentSize = (AGENT.SIZE + AGENT.LINE_WIDTH) * 2 * viewer.zoom;
+    const side = gridSize * agentSize + (gridSize + 1) * padding;
+    const rows = Math.max(1, Math.ceil(riders.length / gridSize));
+    const height = rows * agentSize + (rows + 1) * padding;
+    const [centreX, centreY] = toScreen(bike.physical_state.position);
+    const x = centreX - side / 2, y = centreY - side / 2;
+    const line = BIKE.LINE_WIDTH * viewer.zoom;
+    const governance = GOVERNANCE[bike.governance] || GOVERNANCE[3];
+
+    // the outline surrounds the square without overlapping it, as both are translucent
+    context.globalAlpha = BIKE.TRANSPARENCY;
+    context.fillStyle = governance[1];
+    context.fillRect(x, y, side, Math.max(side, height));
+    context.fillStyle = BIKE.LINE_COLOUR;
+    context.fillRect(x - line, y - line, side + 2 * line, line);
+    context.fillRect(x - line, y + Math.max(side, height), side + 2 * line, line);
+    context.fillRect(x - line, y, line, Math.max(side, height));
+    context.fillRect(x + side, y, line, Math.max(side, height));
+    context.globalAlpha = 1;
+    viewer.hitAreas.push({kind: "bike", id, contains: (mx, my) =>
+        mx >= x && mx <= x + side && my >= y && my <= y + Math.max(side, height)});
+    if (viewer.selected && viewer.selected.id === id) {
+        drawSelection(x, y, side, Math.max(side, height));
+    }
+
+    riders.forEach((agentID, index) => {
+        const row = Math.floor(index / gridSize), column = index % gridSize;
+        const agentX = x + agentSize / 2 + agentSize * column + padding * (column + 1);
+        const agentY = y + agentSize / 2 + agentSize * row + padding * (row + 1);
+        drawAgent(agentID, agents[agentID], agentX, agentY);
+    });
+}
+
+function drawAudi(audi, bikes) {
+    const [x, y] = toScreen(audi.physical_state.position);
+    const size = OWDI.SIZE * viewer.zoom;
+    const target = bikes[audi.target_bike];
+    if (target) {
+        // line to the bike the Audi is chasing
+        const [targetX, targetY] = toScreen(target.physical_state.position);
+        context.strokeStyle = OWDI.COLOUR;
+        context.lineWidth = Math.max(OWDI.LINE_WIDTH * viewer.zoom * 2, 1);
+        context.setLineDash([8, 6]);
+        context.beginPath();
+        context.moveTo(x, y);
+        context.lineTo(targetX, targetY);
+        context.stroke();
+        context.setLineDash([]);
+    }
+    const line = OWDI.LINE_WIDTH * viewer.zoom;
+    context.fillStyle = OWDI.LINE_COLOUR;
+    context.fillRect(x - size / 2 - line, y - size / 2 - line, size + 2 * line, size + 2 * line);
+    context.fillStyle = OWDI.COLOUR;
+    context.fillRect(x - size / 2, y - size / 2, size, size);
+    drawLabel("owdi", x, y, OWDI.FONT_SIZE * viewer.zoom, "#FFFFFF");
+    viewer.hitAreas.push({kind: "audi", id: audi.id, contains: (mx, my) =>
+        Math.abs(mx - x) <= size / 2 && Math.abs(my - y) <= size / 2});
+}
+
+function drawSelection(x, y, width, height) {
+    context.strokeStyle = "#000000";
+    context.lineWidth = 2;
+    context.setLineDash([4, 3]);
+    context.strokeRect(x - 3, y - 3, width + 6, height + 6);
+    context.setLineDash([]);
+}
+
+function draw() {
+    context.clearRect(0, 0, canvas.width, canvas.height);
+    drawGrid();
+    viewer.hitAreas = [];
+    const state = currentState();
+    if (!state) {
+        updateInspector();
+        return;
+    }
+    const terrain = currentTerrain();
+    if (terrain) {
+        drawTerrain(terrain);
+    }
+    const agents = state.agents || {};
+    const bikes = state.bikes || {};
+    for (const [id, lootbox] of Object.entries(state.loot_boxes || {})) {
+        drawLootbox(id, lootbox);
+    }
+    for (const [id, bike] of Object.entries(bikes)) {
+        drawBike(id, bike, agents);
+    }
+    // agents that aren't on a bike are drawn where they are
+    for (const [id, agent] of Object.entries(agents)) {
+        if (!agent.on_bike || !bikes[agent.bike_id]) {
+            drawAgent(id, agent, ...toScreen(agent.location));
+        }
+    }
+    for (const audi of state.audis || []) {
+        drawAudi(audi, bikes);
+    }
+    updateInspector();
+}
+
+// ---- inspection ----
+
+function properties(kind, id, state) {
+    switch (kind) {
+    case "agent": {
+        const agent = state.agents[id];
+        if (!agent) {
+            return null;
+        }
+        return {
+            "Agent": id,
+            "Class": agent.class,
+            "Colour": agent.colour,
+            "Energy": round(agent.energy_level),
+            "Points": agent.points,
+            "Pedal": round(agent.forces.pedal),
+            "Brake": round(agent.forces.brake),
+            "Steering": `${agent.forces.turning.steer_bike}, ${round(agent.forces.turning.steering_force)}`,
+            "On bike": agent.on_bike ? shortID(agent.bike_id) : "No",
+            "Group ID": agent.group_id || "-",
+            "Reputation of": Object.keys(agent.reputation || {}).length + " agents",
+        };
+    }
+    case "bike": {
+        const bike = state.bikes[id];
+        if (!bike) {
+            return null;
+        }
+        const riders = (bike.agent_ids || []).map(agentID => state.agents[agentID]).filter(agent => agent);
+        const average = value => riders.length === 0 ? "N/A" :
+            round(riders.reduce((total, agent) => total + value(agent), 0) / riders.length);
+        const result = {
+            "Bike": id,
+            "Governance": (GOVERNANCE[bike.governance] || GOVERNANCE[3])[0],
+            "Ruler": shortID(bike.ruler),
+            "Agents": riders.length,
+            "Velocity": round(bike.physical_state.velocity),
+            "Acceleration": round(bike.physical_state.acceleration),
+            "Mass": round(bike.physical_state.mass),
+            "Orientation": round(bike.orientation),
+            "Average Energy": average(agent => agent.energy_level),
+            "Average Pedal": average(agent => agent.forces.pedal),
+            "Average Points": average(agent => agent.points),
+            "Treasury": round(bike.treasury),
+            "Tax Rate": round(bike.tax_rate),
+        };
+        if (bike.events) {
+            result["Joins / Leaves"] = `${bike.events.joins} / ${bike.events.leaves}`;
+            result["Kickouts"] = bike.events.kickouts;
+            result["Lootboxes"] = bike.events.loot_boxes;
+        }
+        return result;
+    }
+    case "lootbox": {
+        const lootbox = state.loot_boxes[id];
+        if (!lootbox) {
+            return null;
+        }
+        return {
+            "Lootbox": id,
+            "Colour": lootbox.colour,
+            "Resources": round(lootbox.total_resources),
+            "Remaining Lifetime": lootbox.remaining_lifetime,
+            "Jackpot": lootbox.jackpot ? "Yes" : "No",
+        };
+    }
+    case "audi": {
+        const audi = (state.audis || []).find(audi => audi.id === id);
+        if (!audi) {
+            return null;
+        }
+        return {
+            "Audi": id,
+            "Target": audi.target_bike,
+            "Velocity": round(audi.physical_state.velocity),
+            "Acceleration": round(audi.physical_state.acceleration),
+            "Mass": round(audi.physical_state.mass),
+        };
+    }
+    }
+    return null;
+}
+
+function updateInspector() {
+    const state = currentState();
+    const values = viewer.selected && state ? properties(viewer.selected.kind, viewer.selected.id, state) : null;
+    if (!values) {
+        elements.properties.replaceChildren();
+        return;
+    }
+    elements.properties.replaceChildren(...Object.entries(values).map(([name, value]) => {
+        const row = document.createElement("tr");
+        const nameCell = document.createElement("td");
+        const valueCell = document.createElement("td");
+        nameCell.textContent = name;
+        valueCell.textContent = value;
+        row.append(nameCell, valueCell);
+        return row;
+    }));
+}
+
+function legend() {
+    const entries = GOVERNANCE.slice(0, 3).map(([name, colour]) =>
+        `<div><span class="swatch" style="background:${colour}"></span>${name}</div>`);
+    entries.push(`<div><span class="swatch" style="background:${OWDI.COLOUR}"></span>Audi and its target</div>`);
+    entries.push(`<div><span class="swatch" style="background:${ENERGY_BAR.FULL}"></span>Agent energy</div>`);
+    elements.legend.innerHTML = "<h2>Bike governance</h2>" + entries.join("");
+}
+
+// ---- interaction ----
+
+function bindControls() {
+    elements.play.addEventListener("click", () => viewer.playing ? pause() : play());
+    elements.previous.addEventListener("click", () => showRound(viewer.round - 1));
+    elements.next.addEventListener("click", () => showRound(viewer.round + 1));
+    elements.iteration.addEventListener("change", () => showIteration(Number(elements.iteration.value)));
+    elements.timeline.addEventListener("input", () => showRound(Number(elements.timeline.value)));
+    elements.speed.addEventListener("change", () => viewer.playing && play());
+
+    document.addEventListener("keydown", event => {
+        if (event.target.tagName === "SELECT" || event.target.tagName === "INPUT") {
+            return;
+        }
+        switch (event.key) {
+        case " ":
+            viewer.playing ? pause() : play();
+            break;
+        case "ArrowRight":
+            showRound(viewer.round + 1);
+            break;
+        case "ArrowLeft":
+            showRound(viewer.round - 1);
+            break;
+        case "ArrowUp":
+            showIteration(viewer.iteration + 1);
+            break;
+        case "ArrowDown":
+            showIteration(viewer.iteration - 1);
+            break;
+        default:
+            return;
+        }
+        event.preventDefault();
+    });
+
+    let drag = null;
+    canvas.addEventListener("mousedown", event => {
+        drag = {x: event.offsetX, y: event.offsetY, moved: false};
+    });
+    canvas.addEventListener("mousemove", event => {
+        if (!drag) {
+            return;
+        }
+        const dx = event.offsetX - drag.x, dy = event.offsetY - drag.y;
+        if (drag.moved || Math.hypot(dx, dy) > 3) {
+            drag.moved = true;
+            viewer.offsetX += dx;
+            viewer.offsetY += dy;
+            drag.x = event.offsetX;
+            drag.y = event.offsetY;
+            draw();
+        }
+    });
+    canvas.addEventListener("mouseup", event => {
+        if (drag && !drag.moved) {
+            select(event.offsetX, event.offsetY);
+        }
+        drag = null;
+    });
+    canvas.addEventListener("mouseleave", () => drag = null);
+    canvas.addEventListener("wheel", event => {
+        event.preventDefault();
+        const zoom = Math.max(MINZOOM, Math.min(MAXZOOM, viewer.zoom * (event.deltaY < 0 ? 1.1 : 1 / 1.1)));
+        // keep the point under the mouse in place
+        viewer.offsetX = event.offsetX - (event.offsetX - viewer.offsetX) * zoom / viewer.zoom;
+        viewer.offsetY = event.offsetY - (event.offsetY - viewer.offsetY) * zoom / viewer.zoom;
+        viewer.zoom = zoom;
+        draw();
+    }, {passive: false});
+    window.addEventListener("resize", resize);
+}
+
+// selects the topmost entity under the point, agents are drawn over their bike so they are picked first
+function select(x, y) {
+    viewer.selected = null;
+    for (let i = viewer.hitAreas.length - 1; i >= 0; i--) {
+        if (viewer.hitAreas[i].contains(x, y)) {
+            viewer.selected = {kind: viewer.hitAreas[i].kind, id: viewer.hitAreas[i].id};
+            break;
+        }
+    }
+    draw();
+}
+
+// ---- live games ----
+
+function followLive() {
+    elements.live.hidden = false;
+    elements.events.hidden = false;
+    for (const [button, action] of [[elements.pauseGame, "pause"], [elements.stepGame, "step"], [elements.resumeGame, "resume"]]) {
+        button.addEventListener("click", () => fetch(`control/${action}`, {method: "POST"})
+            .catch(error => setStatus(`Could not ${action} the game: ${error.message}`)));
+    }
+
+    const events = new EventSource("events");
+    events.addEventListener("round", event => receiveRound(JSON.parse(event.data)));
+    events.addEventListener("control", event => {
+        const paused = JSON.parse(event.data).paused;
+        elements.gameStatus.textContent = paused ? "Paused" : "Live";
+        elements.pauseGame.disabled = paused;
+        elements.resumeGame.disabled = !paused;
+    });
+    for (const kind of ["death", "case", "allocation", "transfer", "trade"]) {
+        events.addEventListener(kind, event => logEvent(kind, JSON.parse(event.data)));
+    }
+    events.addEventListener("end", () => {
+        events.close();
+        elements.gameStatus.textContent = "Game over";
+        for (const button of [elements.pauseGame, elements.stepGame, elements.resumeGame]) {
+            button.disabled = true;
+        }
+    });
+    events.addEventListener("error", () => {
+        if (events.readyState === EventSource.CLOSED) {
+            setStatus("The connection to the game was lost");
+        }
+    });
+}
+
+function isLatest() {
+    const rounds = viewer.iterations[viewer.iteration];
+    return viewer.iteration === viewer.iterations.length - 1 && rounds && viewer.round === rounds.length - 1;
+}
+
+function receiveRound({iteration, state}) {
+    while (viewer.iterations.length <= iteration) {
+        viewer.iterations.push([]);
+        const index = viewer.iterations.length - 1;
+        elements.iteration.append(new Option(index, index));
+    }
+    // clients that fall behind the stream are sent the initial round of the iteration again
+    const rounds = viewer.iterations[iteration];
+    if (rounds.length > 0 && rounds[rounds.length - 1].iteration >= state.iteration) {
+        return;
+    }
+    rounds.push(state);
+    if (viewer.following && !viewer.playing) {
+        if (iteration !== viewer.iteration) {
+            showIteration(iteration);
+        }
+        elements.timeline.max = viewer.iterations[iteration].length - 1;
+        showRound(viewer.iterations[iteration].length - 1);
+    } else if (iteration === viewer.iteration) {
+        elements.timeline.max = viewer.iterations[iteration].length - 1;
+    }
+    if (viewer.iterations.length === 1 && viewer.iterations[0].length === 1) {
+        setStatus("");
+        fitToGrid();
+    }
+}
+
+function describeEvent(kind, event) {
+    switch (kind) {
+    case "death":
+        return `${shortID(event.agent)} died (${event.cause})`;
+    case "case":
+        return `${shortID(event.accused)} ${event.guilty ? "found guilty" : "acquitted"} of ${event.offence}`;
+    case "allocation":
+        return `bike ${shortID(event.bike)} looted ${round(event.loot)} (${(GOVERNANCE[event.governance] || GOVERNANCE[3])[0]})`;
+    case "transfer":
+        return `${shortID(event.from)} gave ${round(event.amount)} energy to ${shortID(event.to)} (${event.type})`;
+    case "trade":
+        return `${shortID(event.buyer)} bought ${event.quantity} from ${shortID(event.seller)} at ${round(event.price)}`;
+    }
+    return kind;
+}
+
+function logEvent(kind, {iteration, round, event}) {
+    const item = document.createElement("li");
+    item.textContent = `${iteration}:${round} ${describeEvent(kind, event)}`;
+    elements.eventLog.prepend(item);
+    while (elements.eventLog.children.length > MAX_EVENTS) {
+        elements.eventLog.lastChild.remove();
+    }
+}
+
+function loadDump() {
+    setStatus("Loading game_dump.json...");
+    fetch("game_dump.json")
+        .then(response => response.ok ? response.json() : response.text().then(text => Promise.reject(new Error(text))))
+        .then(load)
+        .catch(error => setStatus(`Could not load the game dump: ${error.message}`));
+}
+
+function start() {
+    legend();
+    bindControls();
+    resize();
+    fetch("config.json")
+        .then(response => response.json())
+        .catch(() => ({live: false}))
+        .then(config => {
+            if (config.live) {
+                setStatus("Waiting for the game to start...");
+                followLive();
+            } else {
+                loadDump();
+            }
+        });
+}
+
+start();
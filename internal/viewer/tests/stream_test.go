@@ -0,0 +1,178 @@
+package viewer_test
+
+import (
+	"SOMAS2023/internal/server"
+	"SOMAS2023/internal/viewer"
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// connects to the stream, which has sent its headers once this returns
+func connect(t *testing.T, url string) *http.Response {
+	t.Helper()
+	response, err := http.Get(url + "/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contentType := response.Header.Get("Content-Type"); contentType != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %s", contentType)
+	}
+	return response
+}
+
+// reads the kinds of the events streamed until the end of the game, it is called from other goroutines so it
+// doesn't stop the test
+func readEvents(t *testing.T, response *http.Response) []string {
+	defer response.Body.Close()
+
+	kinds := make([]string, 0)
+	scanner := bufio.NewScanner(response.Body)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if kind, found := strings.CutPrefix(scanner.Text(), "event: "); found {
+			kinds = append(kinds, kind)
+			if kind == "end" {
+				return kinds
+			}
+		}
+	}
+	t.Error("The stream ended before the game", scanner.Err())
+	return nil
+}
+
+func TestStreamEvents(t *testing.T) {
+	stream := viewer.NewStream(false)
+	handler := httptest.NewServer(viewer.NewLiveHandler(filepath.Join(t.TempDir(), "game_dump.json"), stream))
+	defer handler.Close()
+
+	events := make(chan []string)
+	response := connect(t, handler.URL)
+	go func() {
+		events <- readEvents(t, response)
+	}()
+
+	stream.ObserveRound(0, server.GameStateDump{Iteration: -1})
+	stream.ObserveRound(0, server.GameStateDump{
+		Iteration: 0,
+		Deaths:    []server.DeathDump{{Agent: uuid.New(), Cause: server.AudiCollision}},
+		Transfers: []server.TransferDump{{From: uuid.New(), To: uuid.New(), Amount: 0.1, Type: server.GiftTransfer}},
+	})
+	stream.ObserveRound(0, server.GameStateDump{Iteration: 1, Deaths: []server.DeathDump{{Agent: uuid.New(), Cause: server.Starvation}}})
+	stream.Pause()
+	stream.Close()
+
+	expected := []string{"round", "round", "death", "transfer", "round", "death", "control", "end"}
+	if kinds := <-events; !slices.Equal(kinds, expected) {
+		t.Errorf("Expected the events %v, got %v", expected, kinds)
+	}
+	// clients that connect later are sent the initial round of the iteration, the latest round and the state of the game
+	replayed := []string{"round", "round", "death", "control", "end"}
+	if kinds := readEvents(t, connect(t, handler.URL)); !slices.Equal(kinds, replayed) {
+		t.Errorf("Expected the events %v to be replayed, got %v", replayed, kinds)
+	}
+	if code, body := get(t, viewer.NewLiveHandler("", stream), "/config.json"); code != http.StatusOK || body != `{"live":true}` {
+		t.Errorf("Expected the viewer to follow the stream, got %d %s", code, body)
+	}
+}
+
+func TestStreamReplay(t *testing.T) {
+	stream := viewer.NewStream(false)
+	handler := httptest.NewServer(viewer.NewLiveHandler(filepath.Join(t.TempDir(), "game_dump.json"), stream))
+	defer handler.Close()
+
+	response := connect(t, handler.URL)
+	stream.ObserveRound(0, server.GameStateDump{Iteration: -1})
+	for round := 0; round < 100; round++ {
+		stream.ObserveRound(0, server.GameStateDump{Iteration: round})
+	}
+	stream.Close()
+	// the stream only holds the latest rounds, but the initial round of the iteration is still replayed for its terrain
+	if kinds := readEvents(t, connect(t, handler.URL)); !slices.Equal(kinds, []string{"round", "round", "end"}) {
+		t.Errorf("Expected the initial and latest rounds to be replayed, got %v", kinds)
+	}
+	// clients that fall behind are sent the initial round again, which the viewer ignores
+	if kinds := readEvents(t, response); len(kinds) < 3 || kinds[len(kinds)-1] != "end" {
+		t.Errorf("Expected the client to catch up with the game, got %v", kinds)
+	}
+}
+
+// observes a round in the background, the channel is closed once the round is let through
+func observe(stream *viewer.Stream) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		stream.ObserveRound(0, server.GameStateDump{})
+		close(done)
+	}()
+	return done
+}
+
+// whether the round is let through before the timeout
+func waitFor(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	case <-time.After(500 * time.Millisecond):
+		return false
+	}
+}
+
+func post(t *testing.T, url string, action string) int {
+	t.Helper()
+	response, err := http.Post(url+"/control/"+action, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response.Body.Close()
+	return response.StatusCode
+}
+
+func TestStreamControls(t *testing.T) {
+	stream := viewer.NewStream(true)
+	handler := httptest.NewServer(viewer.NewLiveHandler(filepath.Join(t.TempDir(), "game_dump.json"), stream))
+	defer handler.Close()
+
+	done := observe(stream)
+	if waitFor(done) {
+		t.Fatal("Expected the game to start paused")
+	}
+	if code := post(t, handler.URL, "step"); code != http.StatusNoContent {
+		t.Fatalf("Expected the step to be accepted, got %d", code)
+	}
+	if !waitFor(done) {
+		t.Fatal("Expected a step to play the round")
+	}
+	if done := observe(stream); waitFor(done) || !stream.Paused() {
+		t.Fatal("Expected the game to pause after a step")
+	} else if post(t, handler.URL, "resume"); !waitFor(done) {
+		t.Fatal("Expected the game to resume")
+	}
+	if !waitFor(observe(stream)) {
+		t.Error("Expected the rounds to be played once the game is resumed")
+	}
+
+	post(t, handler.URL, "pause")
+	done = observe(stream)
+	if waitFor(done) {
+		t.Fatal("Expected the game to be paused")
+	}
+	// the end of the game lets the last rounds through
+	stream.Close()
+	if !waitFor(done) {
+		t.Error("Expected the game to be played to the end once the stream is closed")
+	}
+
+	if code := post(t, handler.URL, "rewind"); code != http.StatusNotFound {
+		t.Errorf("Expected unknown controls to be not found, got %d", code)
+	}
+	if code, _ := get(t, handler.Config.Handler, "/control/pause"); code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected controls to be posted, got %d", code)
+	}
+}
@@ -0,0 +1,60 @@
+package viewer_test
+
+import (
+	"SOMAS2023/internal/viewer"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, handler http.Handler, path string) (int, string) {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	body, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return recorder.Code, string(body)
+}
+
+func TestViewerAssets(t *testing.T) {
+	handler := viewer.NewHandler(filepath.Join(t.TempDir(), "game_dump.json"))
+	for path, content := range map[string]string{
+		"/":           "<canvas",
+		"/viewer.js":  "GOVERNANCE",
+		"/viewer.css": "canvas",
+	} {
+		code, body := get(t, handler, path)
+		if code != http.StatusOK || !strings.Contains(body, content) {
+			t.Errorf("Expected %s to be served with %q, got %d", path, content, code)
+		}
+	}
+	if code, _ := get(t, handler, "/missing.js"); code != http.StatusNotFound {
+		t.Errorf("Expected unknown assets to be missing, got %d", code)
+	}
+	if code, body := get(t, handler, "/config.json"); code != http.StatusOK || body != `{"live":false}` {
+		t.Errorf("Expected the viewer to load the dump, got %d %s", code, body)
+	}
+}
+
+func TestViewerDump(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game_dump.json")
+	handler := viewer.NewHandler(path)
+	if code, _ := get(t, handler, "/game_dump.json"); code != http.StatusNotFound {
+		t.Errorf("Expected a missing dump to be not found, got %d", code)
+	}
+
+	// the dump is read on every request, so a new game is seen without restarting the viewer
+	dump := `[[{"iteration":-1,"agents":{},"bikes":{},"loot_boxes":{},"audis":[]}]]`
+	if err := os.WriteFile(path, []byte(dump), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if code, body := get(t, handler, "/game_dump.json"); code != http.StatusOK || body != dump {
+		t.Errorf("Expected the dump to be served, got %d %s", code, body)
+	}
+}
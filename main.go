@@ -1,13 +1,54 @@
 package main
 
 import (
+	"SOMAS2023/internal/compare"
+	"SOMAS2023/internal/render"
 	"SOMAS2023/internal/server"
+	"SOMAS2023/internal/viewer"
 	"fmt"
+	"os"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "compare":
+			if err := compare.Run(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		case "serve":
+			if err := viewer.Run(os.Args[2:], os.Stdout); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		case "render":
+			if err := render.Run(os.Args[2:], os.Stdout, play); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		case "live":
+			if err := viewer.RunLive(os.Args[2:], os.Stdout, play); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	fmt.Println("Hello Agents")
+	play()
+}
+
+// plays a game, notifying the observers of every round
+func play(observers ...server.RoundObserver) {
 	s := server.Initialize(10)
+	for _, observer := range observers {
+		s.AddRoundObserver(observer)
+	}
 	s.UpdateGameStates()
 	s.Start()
 }